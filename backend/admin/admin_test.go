@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"backend/config"
+	customerrors "backend/errors"
+	"backend/migrations"
+	"backend/models"
+	"backend/services"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var todoService *services.TodoService
+
+// TestMain 初始化数据库连接并执行迁移
+func TestMain(m *testing.M) {
+	if err := config.InitDB(); err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		return
+	}
+	if _, err := migrations.Up(config.DB, 0); err != nil {
+		fmt.Printf("Failed to run migrations: %v\n", err)
+		return
+	}
+	todoService = services.NewTodoService()
+	m.Run()
+}
+
+// TestCheckDB 测试数据库检查
+func TestCheckDB(t *testing.T) {
+	t.Run("迁移执行后所有检查项都通过", func(t *testing.T) {
+		for _, c := range CheckDB(config.DB) {
+			if !c.OK {
+				t.Errorf("检查项 %s 未通过: %s", c.Name, c.Detail)
+			}
+		}
+		t.Logf("✅ 数据库检查通过")
+	})
+}
+
+// TestSeed 测试生成演示数据
+func TestSeed(t *testing.T) {
+	t.Run("相同种子生成相同的数据", func(t *testing.T) {
+		first, err := Seed(context.Background(), todoService, 3, rand.New(rand.NewSource(42)))
+		if err != nil {
+			t.Fatalf("生成失败: %v", err)
+		}
+		second, err := Seed(context.Background(), todoService, 3, rand.New(rand.NewSource(42)))
+		if err != nil {
+			t.Fatalf("生成失败: %v", err)
+		}
+		defer func() {
+			for _, todo := range append(first, second...) {
+				todoService.DeleteTodo(context.Background(), todo.ID)
+			}
+		}()
+
+		for i := range first {
+			if first[i].Title != second[i].Title || first[i].Category != second[i].Category || first[i].Priority != second[i].Priority {
+				t.Errorf("第 %d 条数据不同: %+v / %+v", i, first[i], second[i])
+			}
+			if first[i].CreatedAt.After(time.Now()) {
+				t.Errorf("创建时间应该在过去: %v", first[i].CreatedAt)
+			}
+		}
+		t.Logf("✅ 生成了 %d 条演示数据", len(first))
+	})
+}
+
+// TestExportImport 测试导出后再导入
+func TestExportImport(t *testing.T) {
+	t.Run("导入保留完成状态和创建时间，非法记录单独报告", func(t *testing.T) {
+		original, err := todoService.ImportTodo(context.Background(), &models.Todo{
+			Title:     "导出测试-" + strconv.FormatInt(time.Now().UnixNano(), 10),
+			Category:  "study",
+			Priority:  3,
+			Completed: true,
+			CreatedAt: time.Now().Add(-48 * time.Hour).Truncate(time.Second),
+		})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer todoService.DeleteTodo(context.Background(), original.ID)
+
+		var buf bytes.Buffer
+		if _, err := ExportTodos(context.Background(), &buf); err != nil {
+			t.Fatalf("导出失败: %v", err)
+		}
+		var export Export
+		if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+			t.Fatalf("导出文件无法解析: %v", err)
+		}
+
+		// 只导入本测试创建的记录和一条非法记录
+		var mine []models.Todo
+		for _, todo := range export.Todos {
+			if todo.ID == original.ID {
+				mine = append(mine, todo)
+			}
+		}
+		if len(mine) != 1 {
+			t.Fatalf("导出文件中应该包含创建的记录")
+		}
+		export.Todos = append(mine, models.Todo{Title: "非法分类", Category: "unknown"})
+		buf.Reset()
+		json.NewEncoder(&buf).Encode(export)
+
+		result, err := ImportTodos(context.Background(), &buf, todoService)
+		if err != nil {
+			t.Fatalf("导入失败: %v", err)
+		}
+		if result.Imported != 1 || len(result.Failed) != 1 || result.Failed[0].Index != 1 {
+			t.Fatalf("应该导入 1 条、失败 1 条，实际: %+v", result)
+		}
+
+		imported, err := models.GetAllLive(context.Background())
+		if err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		var copied *models.Todo
+		for i := range imported {
+			if imported[i].Title == original.Title && imported[i].ID != original.ID {
+				copied = &imported[i]
+			}
+		}
+		if copied == nil {
+			t.Fatalf("没有找到导入的记录")
+		}
+		defer todoService.DeleteTodo(context.Background(), copied.ID)
+		if !copied.Completed || copied.Priority != 3 || !copied.CreatedAt.Equal(original.CreatedAt) {
+			t.Errorf("导入的记录应该保留原有字段，实际: %+v", copied)
+		}
+		t.Logf("✅ 导入 %d 条，失败: %s", result.Imported, result.Failed[0].Error)
+	})
+
+	t.Run("格式不符的文件直接报错", func(t *testing.T) {
+		_, err := ImportTodos(context.Background(), bytes.NewBufferString(`{"format":"other","todos":[]}`), todoService)
+		if err == nil {
+			t.Fatal("应该返回错误")
+		}
+		t.Logf("✅ %v", err)
+	})
+}
+
+// TestPurgeTrash 测试清理回收站后同步接口改为返回全量快照
+func TestPurgeTrash(t *testing.T) {
+	t.Run("清理墓碑后，旧的同步位置得到全量快照", func(t *testing.T) {
+		sync := services.NewSyncService(todoService)
+		before, err := sync.Pull(context.Background(), "", 1)
+		if err != nil {
+			t.Fatalf("拉取失败: %v", err)
+		}
+		oldToken := before.SyncToken
+
+		todo, err := todoService.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "清理测试", Category: "life"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		if err := todoService.DeleteTodo(context.Background(), todo.ID); err != nil {
+			t.Fatalf("删除失败: %v", err)
+		}
+
+		// 把删除时间改到很久以前，只清理这一条，不影响其他测试的墓碑
+		longAgo := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+		config.DB.Unscoped().Model(&models.Todo{}).Where("id = ?", todo.ID).Update("deleted_at", longAgo)
+		purged, err := models.PurgeDeleted(longAgo.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("清理失败: %v", err)
+		}
+		if purged < 1 {
+			t.Fatalf("应该清理至少 1 条，实际 %d", purged)
+		}
+		var histories int64
+		config.DB.Model(&models.TodoHistory{}).Where("todo_id = ?", todo.ID).Count(&histories)
+		if histories != 0 {
+			t.Errorf("墓碑的变更历史应该一起清理，实际剩余 %d 条", histories)
+		}
+
+		result, err := sync.Pull(context.Background(), oldToken, 10)
+		if err != nil {
+			t.Fatalf("拉取失败: %v", err)
+		}
+		if !result.Full {
+			t.Errorf("同步位置早于清理的墓碑，应该返回全量快照")
+		}
+		latest, err := sync.Pull(context.Background(), result.SyncToken, 10)
+		if err != nil {
+			t.Fatalf("拉取失败: %v", err)
+		}
+		if latest.Full {
+			t.Errorf("快照之后的同步位置应该返回增量变更")
+		}
+		t.Logf("✅ 清理 %d 条墓碑，旧位置 %s 得到全量快照", purged, oldToken)
+	})
+}
+
+// TestCreateUser 测试创建用户
+func TestCreateUser(t *testing.T) {
+	username := "admin-test-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	users := services.NewUserService()
+
+	t.Run("密码以哈希保存，可以验证", func(t *testing.T) {
+		user, err := users.CreateUser(context.Background(), &models.CreateUserInput{Username: username, Password: "correct horse", Role: models.RoleAdmin})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		if user.PasswordHash == "correct horse" || !services.VerifyPassword("correct horse", user.PasswordHash) {
+			t.Errorf("密码哈希不正确: %s", user.PasswordHash)
+		}
+		if services.VerifyPassword("wrong password", user.PasswordHash) {
+			t.Errorf("错误的密码不应该通过验证")
+		}
+		t.Logf("✅ 创建用户 %s", user.Username)
+	})
+
+	t.Run("用户名重复或密码过短时返回错误", func(t *testing.T) {
+		_, err := users.CreateUser(context.Background(), &models.CreateUserInput{Username: username, Password: "another password"})
+		if !errors.Is(err, customerrors.ErrUserExists) {
+			t.Errorf("应该返回 ErrUserExists，实际: %v", err)
+		}
+		_, err = users.CreateUser(context.Background(), &models.CreateUserInput{Username: username + "-2", Password: "short"})
+		if !errors.Is(err, customerrors.ErrPasswordTooShort) {
+			t.Errorf("应该返回 ErrPasswordTooShort，实际: %v", err)
+		}
+		t.Logf("✅ 校验错误: %v", err)
+	})
+}
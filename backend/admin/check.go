@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"backend/migrations"
+	"backend/models"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// pingTimeout 连通性检查的超时时间
+const pingTimeout = 5 * time.Second
+
+// schemaModels 需要校验表结构的模型，与 migrations/sql 中的建表语句对应
+var schemaModels = []interface{}{
+	&models.Todo{},
+	&models.SyncCounter{},
+	&models.WebhookSubscription{},
+	&models.WebhookDelivery{},
+	&models.OutboxEvent{},
+	&models.OutboxCursor{},
+	&models.TodoHistory{},
+	&models.User{},
+}
+
+// Check 一项检查的结果
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CheckDB 检查数据库连通性、迁移是否都已执行，以及每个模型的表和列是否存在
+func CheckDB(db *gorm.DB) []Check {
+	var checks []Check
+
+	// 连通性
+	sqlDB, err := db.DB()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err = sqlDB.PingContext(ctx)
+		cancel()
+	}
+	if err != nil {
+		return append(checks, Check{Name: "connection", Detail: err.Error()})
+	}
+	checks = append(checks, Check{Name: "connection", OK: true})
+
+	// 迁移
+	pending, err := migrations.Pending(db)
+	switch {
+	case err != nil:
+		checks = append(checks, Check{Name: "migrations", Detail: err.Error()})
+	case len(pending) > 0:
+		names := make([]string, len(pending))
+		for i, m := range pending {
+			names[i] = fmt.Sprintf("%04d_%s", m.Version, m.Name)
+		}
+		checks = append(checks, Check{Name: "migrations", Detail: "pending: " + strings.Join(names, ", ")})
+	default:
+		checks = append(checks, Check{Name: "migrations", OK: true})
+	}
+
+	// 表结构
+	for _, model := range schemaModels {
+		checks = append(checks, checkTable(db, model))
+	}
+	return checks
+}
+
+// checkTable 检查模型对应的表及其所有列是否存在
+func checkTable(db *gorm.DB, model interface{}) Check {
+	s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+	if err != nil {
+		return Check{Name: fmt.Sprintf("%T", model), Detail: err.Error()}
+	}
+	check := Check{Name: "table " + s.Table}
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(s.Table) {
+		check.Detail = "table does not exist"
+		return check
+	}
+	var missing []string
+	for _, field := range s.Fields {
+		if field.DBName != "" && !migrator.HasColumn(s.Table, field.DBName) {
+			missing = append(missing, field.DBName)
+		}
+	}
+	if len(missing) > 0 {
+		check.Detail = "missing columns: " + strings.Join(missing, ", ")
+		return check
+	}
+	check.OK = true
+	return check
+}
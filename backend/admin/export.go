@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"backend/models"
+	"backend/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 导出文件的格式标识和版本，导入时校验
+const (
+	ExportFormat  = "todo-app-export"
+	ExportVersion = 1
+)
+
+// Export 导出文件的结构
+type Export struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Todos      []models.Todo `json:"todos"`
+}
+
+// ImportFailure 一条未能导入的记录
+type ImportFailure struct {
+	Index int    `json:"index"` // 在导出文件 todos 中的下标
+	Title string `json:"title"`
+	Error string `json:"error"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported int             `json:"imported"`
+	Failed   []ImportFailure `json:"failed,omitempty"`
+}
+
+// ExportTodos 以 JSON 导出所有未删除的待办事项，返回导出的条数
+func ExportTodos(ctx context.Context, w io.Writer) (int, error) {
+	todos, err := models.GetAllLive(ctx)
+	if err != nil {
+		return 0, err
+	}
+	export := Export{
+		Format:     ExportFormat,
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Todos:      todos,
+	}
+	if export.Todos == nil {
+		export.Todos = []models.Todo{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return len(todos), enc.Encode(export)
+}
+
+// ImportTodos 导入 ExportTodos 导出的文件
+// 每条记录作为新的待办事项创建（分配新的 ID），保留完成状态和创建时间；
+// 单条记录校验失败时跳过并记录在结果中，不影响其他记录
+func ImportTodos(ctx context.Context, r io.Reader, todos *services.TodoService) (*ImportResult, error) {
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid export file: %w", err)
+	}
+	if export.Format != ExportFormat {
+		return nil, fmt.Errorf("invalid export file: format is %q, expected %q", export.Format, ExportFormat)
+	}
+	if export.Version > ExportVersion {
+		return nil, fmt.Errorf("export file version %d is newer than supported version %d", export.Version, ExportVersion)
+	}
+
+	result := &ImportResult{}
+	for i := range export.Todos {
+		if _, err := todos.ImportTodo(ctx, &export.Todos[i]); err != nil {
+			result.Failed = append(result.Failed, ImportFailure{Index: i, Title: export.Todos[i].Title, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+	}
+	return result, nil
+}
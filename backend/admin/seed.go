@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"backend/models"
+	"backend/services"
+	"context"
+	"math/rand"
+	"time"
+)
+
+// seedTemplate 演示数据的模板
+type seedTemplate struct {
+	title       string
+	description string
+}
+
+// seedTemplates 按分类准备的演示数据，seed 命令从中随机挑选
+var seedTemplates = map[string][]seedTemplate{
+	"work": {
+		{"写本周周报", "汇总本周完成的需求和遇到的问题"},
+		{"准备季度复盘 PPT", "重点：指标完成情况、下季度计划"},
+		{"Review 同事的 PR", ""},
+		{"和产品确认需求细节", "登录页改版的交互稿还有几处没对齐"},
+		{"修复线上告警", "订单服务偶发超时，先看慢查询日志"},
+		{"更新接口文档", "v2 接口新增了分页参数"},
+		{"预约下周的技术分享会议室", ""},
+		{"整理面试反馈", "两位候选人的评价今天内提交"},
+		{"升级依赖版本", "gin 和 gorm 都有新版本"},
+		{"回复客户邮件", ""},
+	},
+	"study": {
+		{"读完《数据密集型应用系统设计》第 5 章", "复制与一致性"},
+		{"刷两道算法题", "动态规划专题"},
+		{"看 Go 并发模式的视频", ""},
+		{"背 30 个英语单词", ""},
+		{"整理 MySQL 索引的笔记", "联合索引的最左前缀原则"},
+		{"完成在线课程第三周作业", "截止日期周日晚上"},
+		{"学习 Vue 3 组合式 API", ""},
+		{"复习操作系统：进程调度", ""},
+	},
+	"life": {
+		{"买菜", "鸡蛋、牛奶、西红柿、青菜"},
+		{"交电费", ""},
+		{"预约牙医", "上次说半年后复查"},
+		{"给妈妈打电话", ""},
+		{"健身房练腿", ""},
+		{"取快递", "驿站的取件码在短信里"},
+		{"周末大扫除", "顺便把冬天的衣服收起来"},
+		{"续签租房合同", "月底前联系房东"},
+		{"洗车", ""},
+		{"买生日礼物", "下周五是朋友生日"},
+	},
+}
+
+// seedCategories 分类的挑选顺序，保证同一个随机种子生成的数据相同
+var seedCategories = []string{"work", "study", "life"}
+
+// Seed 生成 count 条演示数据：分类、优先级随机，约三分之一已完成，创建时间分布在过去 30 天内
+// 通过 TodoService 写入，与正常创建的数据一样有变更序号和领域事件
+func Seed(ctx context.Context, todos *services.TodoService, count int, rng *rand.Rand) ([]*models.Todo, error) {
+	now := time.Now()
+	created := make([]*models.Todo, 0, count)
+	for i := 0; i < count; i++ {
+		category := seedCategories[rng.Intn(len(seedCategories))]
+		templates := seedTemplates[category]
+		template := templates[rng.Intn(len(templates))]
+
+		todo, err := todos.ImportTodo(ctx, &models.Todo{
+			Title:       template.title,
+			Description: template.description,
+			Category:    category,
+			Priority:    seedPriority(rng),
+			Completed:   rng.Intn(3) == 0,
+			CreatedAt:   now.Add(-time.Duration(rng.Int63n(int64(30 * 24 * time.Hour)))),
+		})
+		if err != nil {
+			return created, err
+		}
+		created = append(created, todo)
+	}
+	return created, nil
+}
+
+// seedPriority 优先级偏向较低的值，和真实数据的分布接近
+func seedPriority(rng *rand.Rand) int {
+	weights := []int{30, 25, 20, 12, 8, 5}
+	n := rng.Intn(100)
+	for priority, w := range weights {
+		if n < w {
+			return priority
+		}
+		n -= w
+	}
+	return 0
+}
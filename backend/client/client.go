@@ -0,0 +1,228 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 待办事项 REST 接口（v2）的 Go 客户端，命令行工具 cmd/todo 使用
+// 只依赖标准库，数据结构与 v2 接口的 JSON 一一对应
+
+// Todo v2 接口返回的待办事项
+type Todo struct {
+	ID          uint      `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CategoryID  uint      `json:"category_id"`
+	Priority    int       `json:"priority"`
+	Completed   bool      `json:"completed"`
+	Version     int       `json:"version"`
+	ChangeSeq   int64     `json:"change_seq"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TodoPage 分页列表
+type TodoPage struct {
+	Items    []Todo `json:"items"`
+	Total    int64  `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// Category 分类
+type Category struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// CreateTodoInput 创建待办事项的请求体，category_id 为 0 时使用默认分类
+type CreateTodoInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CategoryID  uint   `json:"category_id,omitempty"`
+	Priority    int    `json:"priority"`
+}
+
+// UpdateTodoInput 编辑待办事项的请求体，所有字段都需要提供
+type UpdateTodoInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CategoryID  uint   `json:"category_id"`
+	Priority    int    `json:"priority"`
+	Version     int    `json:"version"`
+}
+
+// ListOptions 列表查询参数，零值表示使用服务端默认值
+type ListOptions struct {
+	CategoryID uint
+	Sort       string // created_at 或 priority
+	Page       int
+	PageSize   int
+}
+
+// Client REST 接口客户端
+type Client struct {
+	BaseURL string // 服务地址，如 http://localhost:8080
+	Token   string // 访问令牌，不为空时以 Bearer 方式放在 Authorization 请求头中
+	Lang    string // 错误提示的语言（Accept-Language），为空时由服务端决定
+	HTTP    *http.Client
+}
+
+// New 创建客户端
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// envelope 成功响应的统一结构
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Categories 获取所有分类
+func (c *Client) Categories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := c.do(ctx, http.MethodGet, "/api/v2/categories", nil, &categories)
+	return categories, err
+}
+
+// ListTodos 分页获取待办事项
+func (c *Client) ListTodos(ctx context.Context, opts ListOptions) (*TodoPage, error) {
+	query := url.Values{}
+	if opts.CategoryID != 0 {
+		query.Set("category_id", strconv.FormatUint(uint64(opts.CategoryID), 10))
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Page != 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PageSize != 0 {
+		query.Set("page_size", strconv.Itoa(opts.PageSize))
+	}
+	path := "/api/v2/todos"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var page TodoPage
+	if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetTodo 获取单个待办事项
+func (c *Client) GetTodo(ctx context.Context, id uint) (*Todo, error) {
+	var todo Todo
+	if err := c.do(ctx, http.MethodGet, todoPath(id), nil, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// CreateTodo 创建待办事项
+func (c *Client) CreateTodo(ctx context.Context, input *CreateTodoInput) (*Todo, error) {
+	var todo Todo
+	if err := c.do(ctx, http.MethodPost, "/api/v2/todos", input, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo 编辑待办事项，版本冲突时返回 Conflict 为 true 的 *APIError
+func (c *Client) UpdateTodo(ctx context.Context, id uint, input *UpdateTodoInput) (*Todo, error) {
+	var todo Todo
+	if err := c.do(ctx, http.MethodPut, todoPath(id), input, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodoStatus 更新完成状态，版本冲突时返回 Conflict 为 true 的 *APIError
+func (c *Client) UpdateTodoStatus(ctx context.Context, id uint, completed bool, version int) (*Todo, error) {
+	body := map[string]interface{}{"completed": completed, "version": version}
+	var todo Todo
+	if err := c.do(ctx, http.MethodPut, todoPath(id)+"/status", body, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// DeleteTodo 删除待办事项
+func (c *Client) DeleteTodo(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, todoPath(id), nil, nil)
+}
+
+func todoPath(id uint) string {
+	return "/api/v2/todos/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// do 发送请求，成功时把 data 解析到 out，失败时返回 *APIError
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("invalid response from %s %s: %w", method, path, err)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// newRequest 创建带有认证和语言请求头的请求
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Lang != "" {
+		req.Header.Set("Accept-Language", c.Lang)
+	}
+	return req, nil
+}
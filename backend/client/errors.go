@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// VersionConflictCode 版本冲突的错误码
+const VersionConflictCode = "VERSION_CONFLICT"
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// APIError 服务端返回的 problem+json 错误
+type APIError struct {
+	Status int          `json:"status"`
+	Title  string       `json:"title"`
+	Detail string       `json:"detail"`
+	Code   string       `json:"error_code"`
+	Errors []FieldError `json:"errors,omitempty"`
+
+	// 版本冲突时服务端返回的最新数据
+	CurrentVersion  int   `json:"current_version,omitempty"`
+	ProvidedVersion int   `json:"provided_version,omitempty"`
+	LatestData      *Todo `json:"latest_data,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	for _, f := range e.Errors {
+		msg += fmt.Sprintf("\n  %s: %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// Conflict 是否为版本冲突，此时 LatestData 为服务端的最新数据
+func (e *APIError) Conflict() bool {
+	return e.Code == VersionConflictCode
+}
+
+// AsConflict 判断 err 是否为版本冲突
+func AsConflict(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Conflict() {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// decodeError 解析错误响应；响应体不是 problem+json 时（如代理返回的错误页）只保留状态码
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(body, apiErr) != nil {
+		apiErr.Detail = http.StatusText(resp.StatusCode)
+	}
+	apiErr.Status = resp.StatusCode
+	return apiErr
+}
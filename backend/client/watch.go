@@ -0,0 +1,163 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventReset 服务端无法补齐断线期间的事件，客户端应重新拉取全量数据
+const EventReset = "reset"
+
+// reconnectDelay 连接断开后重连的等待时间
+const reconnectDelay = 2 * time.Second
+
+// Event 数据变更事件（SSE 接口 GET /api/events 推送的消息）
+// SSE 接口使用 v1 的数据结构，分类为名称
+type Event struct {
+	ID       string     `json:"-"`         // 事件 ID，重连时作为 Last-Event-ID
+	Type     string     `json:"type"`      // todo.created、todo.updated、todo.deleted 或 reset
+	TodoID   uint       `json:"todo_id"`   // 相关待办事项 ID
+	Version  int        `json:"version"`   // 变更后的版本号
+	Category string     `json:"category"`  // 变更后的分类名称
+	Data     *EventTodo `json:"data"`      // 变更后的数据，删除时为空
+	Time     time.Time  `json:"timestamp"` // 事件产生时间
+}
+
+// EventTodo 事件中携带的待办事项
+type EventTodo struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	Category  string `json:"category"`
+	Priority  int    `json:"priority"`
+	Completed bool   `json:"completed"`
+	Version   int    `json:"version"`
+}
+
+// WatchOptions 订阅筛选条件
+type WatchOptions struct {
+	Category    string // 分类名称，为空时不筛选
+	TodoID      uint   // 只订阅该待办事项，为 0 时不筛选
+	LastEventID string // 从该事件之后继续
+}
+
+// Watch 订阅数据变更，每收到一个事件调用一次 fn
+// 连接断开后带上最后收到的事件 ID 自动重连，直到 ctx 结束或 fn 返回错误
+func (c *Client) Watch(ctx context.Context, opts WatchOptions, fn func(Event) error) error {
+	lastID := opts.LastEventID
+	for {
+		err := c.watchOnce(ctx, opts, &lastID, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// 服务端拒绝（参数错误、未认证等）时重连也不会成功；fn 的错误原样返回
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+		var cbErr callbackError
+		if errors.As(err, &cbErr) {
+			return cbErr.err
+		}
+
+		// 网络错误或服务端断开（如消费过慢），稍后从最后收到的事件继续
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// callbackError 包装 fn 返回的错误，与连接错误区分
+type callbackError struct{ err error }
+
+func (e callbackError) Error() string { return e.err.Error() }
+
+// watchOnce 建立一次 SSE 连接并读取事件，直到连接断开；服务端正常关闭时返回 nil
+func (c *Client) watchOnce(ctx context.Context, opts WatchOptions, lastID *string, fn func(Event) error) error {
+	query := url.Values{}
+	if opts.Category != "" {
+		query.Set("category", opts.Category)
+	}
+	if opts.TodoID != 0 {
+		query.Set("todo_id", strconv.FormatUint(uint64(opts.TodoID), 10))
+	}
+	path := "/api/events"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastID != "" {
+		req.Header.Set("Last-Event-ID", *lastID)
+	}
+
+	// 长连接不使用 c.HTTP 的超时
+	httpClient := *c.HTTP
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	// 按 SSE 格式解析：以空行分隔消息，event / id / data 字段，冒号开头的是注释（心跳）
+	var eventType, id string
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() > 0 || eventType != "" {
+				e := Event{Type: eventType}
+				if eventType != EventReset {
+					if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+						return err
+					}
+				}
+				e.ID = id
+				if id != "" {
+					*lastID = id
+				}
+				if err := fn(e); err != nil {
+					return callbackError{err}
+				}
+			}
+			eventType, id = "", ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "id":
+			id = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	return scanner.Err()
+}
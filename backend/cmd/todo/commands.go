@@ -0,0 +1,331 @@
+package main
+
+import (
+	"backend/client"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// runAdd todo add <title> [--desc] [--category] [--priority]
+func runAdd(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	desc := fs.String("desc", "", "描述")
+	category := fs.String("category", "", "分类名称，如 work、study、life")
+	priority := fs.Int("priority", 0, "优先级 0-5")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	categoryID, err := a.categoryID(*category)
+	if err != nil {
+		return err
+	}
+	todo, err := a.client.CreateTodo(a.ctx, &client.CreateTodoInput{
+		Title:       strings.Join(args, " "),
+		Description: *desc,
+		CategoryID:  categoryID,
+		Priority:    *priority,
+	})
+	if err != nil {
+		return err
+	}
+	return a.output(*output, "已创建:", todo)
+}
+
+// runList todo ls [--category] [--sort] [--page] [--page-size]
+func runList(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	category := fs.String("category", "", "只显示该分类")
+	sort := fs.String("sort", "", "排序：created_at（默认）或 priority，均为降序")
+	page := fs.Int("page", 0, "页码，从 1 开始")
+	pageSize := fs.Int("page-size", 0, "每页条数，默认 20，最多 100")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	categoryID, err := a.categoryID(*category)
+	if err != nil {
+		return err
+	}
+	result, err := a.client.ListTodos(a.ctx, client.ListOptions{
+		CategoryID: categoryID,
+		Sort:       *sort,
+		Page:       *page,
+		PageSize:   *pageSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		return printJSON(a.stdout, result)
+	}
+	if len(result.Items) == 0 {
+		fmt.Fprintln(a.stdout, "没有待办事项")
+		return nil
+	}
+	a.printTodos(result.Items)
+	if shown := int64((result.Page-1)*result.PageSize + len(result.Items)); shown < result.Total {
+		fmt.Fprintf(a.stdout, "\n第 %d 页，共 %d 条，使用 --page %d 查看下一页\n", result.Page, result.Total, result.Page+1)
+	}
+	return nil
+}
+
+// runShow todo show <id>
+func runShow(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	todo, err := a.client.GetTodo(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.output(*output, "", todo)
+}
+
+// runEdit todo edit <id> [--title] [--desc] [--category] [--priority] [--version]
+// 只修改指定的字段；不指定 --version 时以当前的最新版本为基础
+func runEdit(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	title := fs.String("title", "", "标题")
+	desc := fs.String("desc", "", "描述")
+	category := fs.String("category", "", "分类名称")
+	priority := fs.Int("priority", 0, "优先级 0-5")
+	version := fs.Int("version", -1, "基于的版本号，与服务端不一致时视为冲突")
+	onConflict := fs.String("on-conflict", "ask", "版本冲突时的处理：ask、retry、overwrite 或 abort")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if err := checkConflictPolicy(*onConflict); err != nil {
+		return err
+	}
+
+	// 记录用户指定了哪些字段，冲突后重试时只把这些字段应用到最新数据上
+	var edit todoEdit
+	var setErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			edit.title = title
+		case "desc":
+			edit.description = desc
+		case "category":
+			categoryID, err := a.categoryID(*category)
+			if err != nil {
+				setErr = err
+			}
+			edit.categoryID = &categoryID
+		case "priority":
+			edit.priority = priority
+		}
+	})
+	if setErr != nil {
+		return setErr
+	}
+	if edit.empty() {
+		return fmt.Errorf("没有要修改的字段，使用 --title、--desc、--category 或 --priority 指定")
+	}
+
+	current, err := a.client.GetTodo(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	if *version >= 0 {
+		current.Version = *version
+	}
+	input := edit.apply(current)
+
+	todo, err := a.client.UpdateTodo(a.ctx, id, input)
+	for attempt := 0; err != nil; attempt++ {
+		conflict, ok := client.AsConflict(err)
+		if !ok {
+			return err
+		}
+		// 编辑不修改完成状态，比较时不显示
+		mine := toTodo(id, input)
+		if conflict.LatestData != nil {
+			mine.Completed = conflict.LatestData.Completed
+		}
+		var resolution string
+		resolution, err = a.resolveConflict(*onConflict, attempt, mine, conflict.LatestData)
+		if err != nil {
+			return err
+		}
+		if resolution == resolveRetry {
+			// 只把用户修改的字段应用到最新数据上，保留其他人对其余字段的修改
+			input = edit.apply(conflict.LatestData)
+		} else {
+			input.Version = conflict.LatestData.Version
+		}
+		todo, err = a.client.UpdateTodo(a.ctx, id, input)
+	}
+	return a.output(*output, "已更新:", todo)
+}
+
+// runDone todo done <id>
+func runDone(a *app, args []string) error {
+	return setStatus(a, args, true)
+}
+
+// runUndone todo undone <id>
+func runUndone(a *app, args []string) error {
+	return setStatus(a, args, false)
+}
+
+// setStatus 更新完成状态；不指定 --version 时以当前的最新版本为基础
+func setStatus(a *app, args []string, completed bool) error {
+	fs, output := a.newFlagSet()
+	version := fs.Int("version", -1, "基于的版本号，与服务端不一致时视为冲突")
+	onConflict := fs.String("on-conflict", "ask", "版本冲突时的处理：ask、retry、overwrite 或 abort")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if err := checkConflictPolicy(*onConflict); err != nil {
+		return err
+	}
+
+	current, err := a.client.GetTodo(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	base := current.Version
+	if *version >= 0 {
+		base = *version
+	}
+
+	todo, err := a.client.UpdateTodoStatus(a.ctx, id, completed, base)
+	for attempt := 0; err != nil; attempt++ {
+		conflict, ok := client.AsConflict(err)
+		if !ok {
+			return err
+		}
+		mine := *current
+		mine.Completed = completed
+		mine.Version = base
+		if _, err = a.resolveConflict(*onConflict, attempt, &mine, conflict.LatestData); err != nil {
+			return err
+		}
+		// 只修改完成状态一个字段，重试和覆盖的效果相同
+		current, base = conflict.LatestData, conflict.LatestData.Version
+		todo, err = a.client.UpdateTodoStatus(a.ctx, id, completed, base)
+	}
+	return a.output(*output, "已标记为"+status(completed)+":", todo)
+}
+
+// runRemove todo rm <id>
+func runRemove(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	if err := a.client.DeleteTodo(a.ctx, id); err != nil {
+		return err
+	}
+	if *output == "json" {
+		return printJSON(a.stdout, map[string]interface{}{"id": id, "deleted": true})
+	}
+	fmt.Fprintf(a.stdout, "已删除 %d\n", id)
+	return nil
+}
+
+// parseID 解析只有一个 ID 参数的命令
+func parseID(fs *flag.FlagSet, args []string) (uint, error) {
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		return 0, errUsage
+	}
+	id, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("无效的 ID: %s", args[0])
+	}
+	return uint(id), nil
+}
+
+// todoEdit edit 命令中用户指定的字段，nil 表示不修改
+type todoEdit struct {
+	title       *string
+	description *string
+	categoryID  *uint
+	priority    *int
+}
+
+func (e *todoEdit) empty() bool {
+	return e.title == nil && e.description == nil && e.categoryID == nil && e.priority == nil
+}
+
+// apply 将修改应用到 base 上，生成以 base 的版本为基础的编辑请求
+func (e *todoEdit) apply(base *client.Todo) *client.UpdateTodoInput {
+	input := &client.UpdateTodoInput{
+		Title:       base.Title,
+		Description: base.Description,
+		CategoryID:  base.CategoryID,
+		Priority:    base.Priority,
+		Version:     base.Version,
+	}
+	if e.title != nil {
+		input.Title = *e.title
+	}
+	if e.description != nil {
+		input.Description = *e.description
+	}
+	if e.categoryID != nil {
+		input.CategoryID = *e.categoryID
+	}
+	if e.priority != nil {
+		input.Priority = *e.priority
+	}
+	return input
+}
+
+// toTodo 编辑请求对应的待办事项，用于与服务端的最新数据比较
+func toTodo(id uint, input *client.UpdateTodoInput) *client.Todo {
+	return &client.Todo{
+		ID:          id,
+		Title:       input.Title,
+		Description: input.Description,
+		CategoryID:  input.CategoryID,
+		Priority:    input.Priority,
+		Version:     input.Version,
+	}
+}
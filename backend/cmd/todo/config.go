@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultServer 未配置时使用的服务地址
+const defaultServer = "http://localhost:8080"
+
+// Config 命令行工具的配置，保存为 JSON 文件
+// 环境变量 TODO_SERVER、TODO_TOKEN 优先于配置文件
+type Config struct {
+	Server string `json:"server"`
+	Token  string `json:"token,omitempty"`  // 访问令牌，以 Bearer 方式发送
+	Output string `json:"output,omitempty"` // 默认输出格式：table 或 json
+	Lang   string `json:"lang,omitempty"`   // 错误提示的语言，如 zh-CN、en
+
+	path string
+}
+
+// defaultConfigPath 配置文件默认路径：$TODO_CONFIG，否则为用户配置目录下的 todo/config.json
+func defaultConfigPath() string {
+	if path := os.Getenv("TODO_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "todo.json"
+	}
+	return filepath.Join(dir, "todo", "config.json")
+}
+
+// LoadConfig 读取配置文件并应用默认值和环境变量，文件不存在时使用默认配置
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	if cfg.Output == "" {
+		cfg.Output = "table"
+	}
+	if server := os.Getenv("TODO_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	if token := os.Getenv("TODO_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	return cfg, nil
+}
+
+// readConfigFile 只读取配置文件中的内容，文件不存在时返回空配置
+func readConfigFile(path string) (*Config, error) {
+	cfg := &Config{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("配置文件 %s 格式错误: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save 保存配置文件；文件中有访问令牌，只允许当前用户读写
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o600)
+}
+
+// set 修改一项配置
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "server":
+		c.Server = strings.TrimRight(value, "/")
+	case "token":
+		c.Token = value
+	case "output":
+		if value != "table" && value != "json" {
+			return fmt.Errorf("output 只能是 table 或 json")
+		}
+		c.Output = value
+	case "lang":
+		c.Lang = value
+	default:
+		return fmt.Errorf("未知配置项 %q，可选: server, token, output, lang", key)
+	}
+	return nil
+}
+
+// runConfig todo config：查看配置；todo config set <key> <value>：修改配置；todo config path：配置文件路径
+func runConfig(a *app, args []string) error {
+	flags, _ := a.newFlagSet()
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(args) == 0:
+		token := "(未设置)"
+		if a.cfg.Token != "" {
+			token = maskToken(a.cfg.Token)
+		}
+		fmt.Fprintf(a.stdout, "server: %s\ntoken:  %s\noutput: %s\nlang:   %s\n", a.cfg.Server, token, a.cfg.Output, a.cfg.Lang)
+		return nil
+	case len(args) == 1 && args[0] == "path":
+		fmt.Fprintln(a.stdout, a.cfg.path)
+		return nil
+	case len(args) == 3 && args[0] == "set":
+		// 只修改文件中的内容，不把默认值、环境变量和 --server 的值写进配置文件
+		fileCfg, err := readConfigFile(a.cfg.path)
+		if err != nil {
+			return err
+		}
+		if err := fileCfg.set(args[1], args[2]); err != nil {
+			return err
+		}
+		if err := fileCfg.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "已保存到 %s\n", fileCfg.path)
+		if os.Getenv("TODO_SERVER") != "" || os.Getenv("TODO_TOKEN") != "" {
+			fmt.Fprintln(a.stderr, "提示: 环境变量 TODO_SERVER / TODO_TOKEN 优先于配置文件")
+		}
+		return nil
+	default:
+		flags.Usage()
+		return errUsage
+	}
+}
+
+// maskToken 只显示令牌的前 4 位
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return token[:4] + strings.Repeat("*", 8)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"backend/client"
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// 版本冲突的处理方式
+const (
+	resolveAsk       = "ask"       // 显示差异并询问
+	resolveRetry     = "retry"     // 把自己修改的字段应用到最新数据上再提交
+	resolveOverwrite = "overwrite" // 以自己的版本为准，覆盖其他人的修改
+	resolveAbort     = "abort"     // 放弃
+)
+
+// maxConflictAttempts 自动处理冲突的最大次数，避免与其他客户端反复冲突
+const maxConflictAttempts = 3
+
+// errConflictAborted 用户放弃了有冲突的修改
+var errConflictAborted = errors.New("版本冲突，已放弃修改")
+
+func checkConflictPolicy(policy string) error {
+	switch policy {
+	case resolveAsk, resolveRetry, resolveOverwrite, resolveAbort:
+		return nil
+	}
+	return fmt.Errorf("--on-conflict 只能是 ask、retry、overwrite 或 abort")
+}
+
+// resolveConflict 显示自己的修改与服务端最新数据的差异，按 policy 决定如何处理
+// 返回 resolveRetry 或 resolveOverwrite；放弃时返回 errConflictAborted
+func (a *app) resolveConflict(policy string, attempt int, mine, latest *client.Todo) (string, error) {
+	if latest == nil {
+		return "", errors.New("版本冲突，但服务端没有返回最新数据")
+	}
+
+	fmt.Fprintf(a.stderr, "版本冲突：待办事项 %d 已被其他人修改，服务端最新版本为 %d\n", latest.ID, latest.Version)
+	a.printDiff(mine, latest)
+
+	if policy != resolveAsk {
+		if policy == resolveAbort || attempt >= maxConflictAttempts {
+			return "", errConflictAborted
+		}
+		fmt.Fprintf(a.stderr, "按 --on-conflict=%s 处理\n", policy)
+		return policy, nil
+	}
+	if !a.interactive() {
+		return "", fmt.Errorf("%w（非交互模式下可使用 --on-conflict retry 或 overwrite）", errConflictAborted)
+	}
+
+	for {
+		fmt.Fprint(a.stderr, "[r] 重试：把你的修改应用到最新数据上  [o] 覆盖：以你的版本为准  [a] 放弃 (r/o/A): ")
+		answer, err := a.readLine()
+		if err != nil {
+			return "", errConflictAborted
+		}
+		switch strings.ToLower(answer) {
+		case "r", "retry":
+			return resolveRetry, nil
+		case "o", "overwrite":
+			return resolveOverwrite, nil
+		case "", "a", "abort":
+			return "", errConflictAborted
+		}
+	}
+}
+
+// printDiff 以表格列出与服务端不同的字段
+func (a *app) printDiff(mine, latest *client.Todo) {
+	type row struct{ field, mine, latest string }
+	rows := []row{
+		{"标题", mine.Title, latest.Title},
+		{"描述", mine.Description, latest.Description},
+		{"分类", a.categoryName(mine.CategoryID), a.categoryName(latest.CategoryID)},
+		{"优先级", fmt.Sprint(mine.Priority), fmt.Sprint(latest.Priority)},
+		{"状态", status(mine.Completed), status(latest.Completed)},
+	}
+
+	tw := tabwriter.NewWriter(a.stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "  字段\t你的修改（基于版本 %d）\t服务端（版本 %d）\n", mine.Version, latest.Version)
+	for _, r := range rows {
+		if r.mine != r.latest {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", r.field, r.mine, r.latest)
+		}
+	}
+	tw.Flush()
+}
+
+// interactive 标准输入是否为终端；输入来自管道或文件时不询问
+func (a *app) interactive() bool {
+	f, ok := a.stdin.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readLine 读取一行输入
+func (a *app) readLine() (string, error) {
+	if a.input == nil {
+		a.input = bufio.NewReader(a.stdin)
+	}
+	line, err := a.input.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,200 @@
+// todo 待办事项命令行客户端，通过 REST 接口（v2）管理待办事项
+//
+//	todo add "写周报" --category work --priority 3
+//	todo ls --category work --sort priority
+//	todo done 12
+//	todo watch
+//
+// 服务地址和访问令牌保存在配置文件中，见 todo config
+package main
+
+import (
+	"backend/client"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// command 一个子命令
+type command struct {
+	name    string
+	usage   string // 参数说明
+	summary string
+	run     func(app *app, args []string) error
+}
+
+var commands = []*command{
+	{"add", "<title> [--desc 描述] [--category 分类] [--priority 0-5]", "创建待办事项", runAdd},
+	{"ls", "[--category 分类] [--sort created_at|priority] [--page n] [--page-size n]", "列出待办事项", runList},
+	{"show", "<id>", "查看待办事项", runShow},
+	{"edit", "<id> [--title 标题] [--desc 描述] [--category 分类] [--priority 0-5] [--version n]", "编辑待办事项", runEdit},
+	{"done", "<id> [--version n]", "标记为已完成", runDone},
+	{"undone", "<id> [--version n]", "标记为未完成", runUndone},
+	{"rm", "<id>", "删除待办事项", runRemove},
+	{"watch", "[--category 分类] [--id n] [--since 事件ID]", "实时查看数据变更", runWatch},
+	{"config", "[set <key> <value> | path]", "查看或修改配置（server、token、output、lang）", runConfig},
+}
+
+// errUsage 参数错误，已经打印了用法
+var errUsage = errors.New("usage error")
+
+// app 一次命令执行的上下文
+type app struct {
+	ctx    context.Context
+	cfg    *Config
+	client *client.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	input  *bufio.Reader // 按行读取 stdin，第一次询问时创建
+	cmd    *command      // 正在执行的子命令
+
+	// 分类 ID 与名称的对应关系，第一次使用时从服务端获取
+	categories []client.Category
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
+
+// run 解析全局参数和子命令并执行
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("todo", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	configPath := global.String("config", "", "配置文件路径（默认 "+defaultConfigPath()+"）")
+	server := global.String("server", "", "服务地址，覆盖配置文件")
+	global.Usage = func() { printUsage(stderr) }
+	if err := global.Parse(args); err != nil {
+		return errUsage
+	}
+	if global.NArg() == 0 {
+		printUsage(stderr)
+		return errUsage
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == global.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "未知命令: %s\n\n", global.Arg(0))
+		printUsage(stderr)
+		return errUsage
+	}
+
+	if *configPath == "" {
+		*configPath = defaultConfigPath()
+	}
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+
+	c := client.New(cfg.Server, cfg.Token)
+	c.Lang = cfg.Lang
+	a := &app{ctx: ctx, cfg: cfg, client: c, stdin: stdin, stdout: stdout, stderr: stderr, cmd: cmd}
+	return cmd.run(a, global.Args()[1:])
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: todo [--server 地址] [--config 路径] <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-7s %s\n", c.name, c.summary)
+		fmt.Fprintf(w, "          todo %s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "所有命令都支持 -o table|json 指定输出格式")
+}
+
+// newFlagSet 创建当前子命令的参数集合，带有通用的 -o 参数
+func (a *app) newFlagSet() (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(a.cmd.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	output := fs.String("o", a.cfg.Output, "输出格式：table 或 json")
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "用法: todo %s %s\n", a.cmd.name, a.cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs, output
+}
+
+// parseArgs 解析参数，允许参数和位置参数交错（如 todo show 3 -o json）
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// categoryID 将分类名称转换为 ID，名称不区分大小写
+func (a *app) categoryID(name string) (uint, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if err := a.loadCategories(); err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, c := range a.categories {
+		if strings.EqualFold(c.Name, name) {
+			return c.ID, nil
+		}
+		names = append(names, c.Name)
+	}
+	return 0, fmt.Errorf("未知分类 %q，可选: %s", name, strings.Join(names, ", "))
+}
+
+// categoryName 将分类 ID 转换为名称，获取分类失败时显示 ID
+func (a *app) categoryName(id uint) string {
+	if a.loadCategories() == nil {
+		for _, c := range a.categories {
+			if c.ID == id {
+				return c.Name
+			}
+		}
+	}
+	return fmt.Sprint(id)
+}
+
+func (a *app) loadCategories() error {
+	if a.categories != nil {
+		return nil
+	}
+	categories, err := a.client.Categories(a.ctx)
+	if err != nil {
+		return err
+	}
+	a.categories = categories
+	return nil
+}
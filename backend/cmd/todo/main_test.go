@@ -0,0 +1,91 @@
+package main
+
+import (
+	"backend/client"
+	"backend/config"
+	"backend/router"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var server *httptest.Server
+
+func TestMain(m *testing.M) {
+	// 初始化数据库连接
+	if err := config.InitDB(); err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		return
+	}
+	server = httptest.NewServer(router.SetupRouter())
+	defer server.Close()
+	m.Run()
+}
+
+// todo 执行一条命令，stdin 为交互输入，返回标准输出和标准错误
+func todo(t *testing.T, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"--server", server.URL, "--config", filepath.Join(t.TempDir(), "config.json")}, args...)
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+// TestEditConflict 测试编辑时的版本冲突处理
+func TestEditConflict(t *testing.T) {
+	t.Run("乐观锁：冲突时显示差异，选择重试后只应用自己修改的字段", func(t *testing.T) {
+		out, _, err := todo(t, "", "add", "CLI 冲突测试", "--category", "work", "-o", "json")
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		var created client.Todo
+		json.Unmarshal([]byte(out), &created)
+		defer todo(t, "", "rm", fmt.Sprint(created.ID))
+
+		// 其他人修改了标题
+		if _, _, err := todo(t, "", "edit", fmt.Sprint(created.ID), "--title", "别人修改的标题"); err != nil {
+			t.Fatalf("第一次编辑失败: %v", err)
+		}
+
+		// 基于旧版本修改优先级，冲突后选择重试
+		out, stderr, err := todo(t, "r\n", "edit", fmt.Sprint(created.ID), "--priority", "4", "--version", "0", "-o", "json")
+		if err != nil {
+			t.Fatalf("重试失败: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stderr, "版本冲突") {
+			t.Errorf("应该提示版本冲突，实际: %s", stderr)
+		}
+		var updated client.Todo
+		json.Unmarshal([]byte(out), &updated)
+		if updated.Title != "别人修改的标题" || updated.Priority != 4 || updated.Version != 2 {
+			t.Errorf("应该保留别人的标题并应用自己的优先级，实际: %+v", updated)
+		}
+
+		t.Logf("✅ 冲突提示:\n%s", stderr)
+	})
+
+	t.Run("乐观锁：选择放弃时返回错误", func(t *testing.T) {
+		out, _, err := todo(t, "", "add", "CLI 放弃测试", "-o", "json")
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		var created client.Todo
+		json.Unmarshal([]byte(out), &created)
+		defer todo(t, "", "rm", fmt.Sprint(created.ID))
+
+		if _, _, err := todo(t, "", "done", fmt.Sprint(created.ID)); err != nil {
+			t.Fatalf("更新状态失败: %v", err)
+		}
+		_, _, err = todo(t, "a\n", "undone", fmt.Sprint(created.ID), "--version", "0")
+		if err != errConflictAborted {
+			t.Errorf("应该返回 errConflictAborted，实际: %v", err)
+		}
+
+		t.Logf("✅ 放弃修改: %v", err)
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"backend/client"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// 表格和 JSON 两种输出格式；JSON 与 v2 接口的数据结构相同，便于配合 jq 使用
+
+// checkOutput 校验输出格式
+func checkOutput(output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("-o 只能是 table 或 json")
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printJSONLine 输出不缩进的一行 JSON
+func printJSONLine(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// printTodos 以表格列出待办事项
+func (a *app) printTodos(todos []client.Todo) {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\t状态\t优先级\t分类\t标题\t版本\t更新时间")
+	for _, t := range todos {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%d\t%s\n",
+			t.ID, checkbox(t.Completed), t.Priority, a.categoryName(t.CategoryID), t.Title, t.Version, formatTime(t.UpdatedAt))
+	}
+	tw.Flush()
+}
+
+// printTodo 显示单个待办事项的所有字段
+func (a *app) printTodo(t *client.Todo) {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", t.ID)
+	fmt.Fprintf(tw, "标题:\t%s\n", t.Title)
+	fmt.Fprintf(tw, "描述:\t%s\n", t.Description)
+	fmt.Fprintf(tw, "分类:\t%s\n", a.categoryName(t.CategoryID))
+	fmt.Fprintf(tw, "优先级:\t%d\n", t.Priority)
+	fmt.Fprintf(tw, "状态:\t%s\n", status(t.Completed))
+	fmt.Fprintf(tw, "版本:\t%d\n", t.Version)
+	fmt.Fprintf(tw, "创建时间:\t%s\n", formatTime(t.CreatedAt))
+	fmt.Fprintf(tw, "更新时间:\t%s\n", formatTime(t.UpdatedAt))
+	tw.Flush()
+}
+
+// output 按格式输出单个待办事项；表格格式下先输出一行提示
+func (a *app) output(format, message string, t *client.Todo) error {
+	if format == "json" {
+		return printJSON(a.stdout, t)
+	}
+	if message != "" {
+		fmt.Fprintln(a.stdout, message)
+	}
+	a.printTodo(t)
+	return nil
+}
+
+func checkbox(completed bool) string {
+	if completed {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func status(completed bool) string {
+	if completed {
+		return "已完成"
+	}
+	return "未完成"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"backend/client"
+	"fmt"
+	"strings"
+)
+
+// eventLabels 事件类型在表格输出中的名称
+var eventLabels = map[string]string{
+	"todo.created": "创建",
+	"todo.updated": "更新",
+	"todo.deleted": "删除",
+}
+
+// runWatch todo watch [--category] [--id] [--since]
+// 持续输出数据变更，断线后自动从最后收到的事件继续，Ctrl+C 退出
+func runWatch(a *app, args []string) error {
+	fs, output := a.newFlagSet()
+	category := fs.String("category", "", "只显示该分类的变更")
+	todoID := fs.Uint("id", 0, "只显示该待办事项的变更")
+	since := fs.String("since", "", "从该事件 ID 之后开始（上次 watch 输出的最后一个事件 ID）")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	if *category != "" {
+		// 校验分类名称；SSE 接口按名称筛选
+		if _, err := a.categoryID(*category); err != nil {
+			return err
+		}
+		*category = strings.ToLower(*category)
+	}
+
+	if *output == "table" {
+		fmt.Fprintln(a.stderr, "等待数据变更，按 Ctrl+C 退出")
+	}
+	return a.client.Watch(a.ctx, client.WatchOptions{
+		Category:    *category,
+		TodoID:      *todoID,
+		LastEventID: *since,
+	}, func(e client.Event) error {
+		if *output == "json" {
+			// 每个事件一行，便于逐行处理
+			return printEventJSON(a, e)
+		}
+		printEvent(a, e)
+		return nil
+	})
+}
+
+func printEventJSON(a *app, e client.Event) error {
+	return printJSONLine(a.stdout, struct {
+		ID string `json:"id"`
+		client.Event
+	}{e.ID, e})
+}
+
+// printEvent 以一行文本输出事件
+func printEvent(a *app, e client.Event) {
+	if e.Type == client.EventReset {
+		fmt.Fprintln(a.stdout, "-- 无法补齐断开期间的变更，请用 todo ls 重新查看 --")
+		return
+	}
+	label := eventLabels[e.Type]
+	if label == "" {
+		label = e.Type
+	}
+	line := fmt.Sprintf("%s  %s  #%d v%d", e.Time.Local().Format("15:04:05"), label, e.TodoID, e.Version)
+	if e.Data != nil {
+		line += fmt.Sprintf("  %s [%s] %s", checkbox(e.Data.Completed), e.Data.Category, e.Data.Title)
+	}
+	fmt.Fprintf(a.stdout, "%s  (%s)\n", line, e.ID)
+}
@@ -0,0 +1,193 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second  // 单次写超时
+	pongWait       = 60 * time.Second  // 超过该时间没有收到任何消息（含 pong）即断开
+	pingPeriod     = pongWait * 9 / 10 // 服务端发送 ping 的间隔，需小于 pongWait
+	maxMessageSize = 64 * 1024         // 单条消息最大字节数
+	sendBuffer     = 64                // 发送队列长度，堆积过多说明客户端过慢，直接断开
+	closeGrace     = 2 * time.Second   // 关闭时等待写协程发送 close 帧的时间
+)
+
+// Client 一个 WebSocket 连接
+type Client struct {
+	ctx  context.Context // 建立连接的请求的上下文（不随请求结束而取消），带有请求 ID
+	hub  *Hub
+	conn *websocket.Conn
+	id   string
+	user string // 用于 presence 展示的用户名，为空时注册后设为 anonymous-连接 ID
+	lang string // 错误信息使用的语言
+
+	// 订阅关系，由 hub.mu 保护
+	all   bool
+	todos map[uint]struct{}
+
+	out       chan []byte
+	closeOnce sync.Once
+	closing   chan struct{} // 关闭信号
+	closeMsg  []byte        // 关闭前最后发送的 close 帧
+	writeDone chan struct{}
+}
+
+// Serve 接管一个已升级的 WebSocket 连接，阻塞直到连接断开
+// user 为空时（未登录）使用 anonymous-连接 ID，同时在线的匿名连接在编辑状态中可以区分
+func (h *Hub) Serve(ctx context.Context, conn *websocket.Conn, user, lang string) {
+	c := &Client{
+		ctx:       context.WithoutCancel(ctx),
+		hub:       h,
+		conn:      conn,
+		user:      user,
+		lang:      lang,
+		todos:     make(map[uint]struct{}),
+		out:       make(chan []byte, sendBuffer),
+		closing:   make(chan struct{}),
+		writeDone: make(chan struct{}),
+	}
+
+	if !h.register(c) {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(writeWait))
+		_ = conn.Close()
+		return
+	}
+
+	go c.writePump()
+	c.send(OutboundMessage{Type: MsgWelcome, ClientID: c.id})
+	c.readPump()
+}
+
+// subscribed 是否订阅了某个待办事项的消息，调用方需持有 hub.mu
+func (c *Client) subscribed(todoID uint) bool {
+	if c.all {
+		return true
+	}
+	_, ok := c.todos[todoID]
+	return ok
+}
+
+// send 将消息放入发送队列，队列已满时断开连接
+func (c *Client) send(msg OutboundMessage) {
+	data := encode(msg)
+	if data == nil {
+		return
+	}
+	select {
+	case <-c.closing:
+	case c.out <- data:
+	default:
+		slog.WarnContext(c.ctx, "client too slow, disconnecting", "component", "collab", "client", c.id)
+		c.close(websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "send buffer overflow"))
+	}
+}
+
+// close 触发关闭，只生效一次
+func (c *Client) close(closeMsg []byte) {
+	c.closeOnce.Do(func() {
+		c.closeMsg = closeMsg
+		close(c.closing)
+	})
+}
+
+// shutdown 服务关闭时调用：通知客户端后断开，并等待写协程退出
+func (c *Client) shutdown() {
+	c.send(OutboundMessage{Type: MsgShutdown})
+	c.close(websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+	select {
+	case <-c.writeDone:
+	case <-time.After(closeGrace):
+		_ = c.conn.Close()
+	}
+}
+
+// readPump 读取并处理客户端消息，连接断开或超时后清理
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.close(nil)
+		<-c.writeDone
+		_ = c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				slog.WarnContext(c.ctx, "client read error", "component", "collab", "client", c.id, "error", err)
+			}
+			return
+		}
+		// 任何消息都视为存活
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var msg InboundMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.send(OutboundMessage{Type: MsgError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+		c.handle(&msg)
+	}
+}
+
+// writePump 串行写出队列中的消息，并定期发送 ping
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		close(c.writeDone)
+	}()
+
+	for {
+		select {
+		case data := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close(nil)
+				_ = c.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close(nil)
+				_ = c.conn.Close()
+				return
+			}
+		case <-c.closing:
+			// 尽量把已排队的消息发完，再发送 close 帧
+		drain:
+			for {
+				select {
+				case data := <-c.out:
+					_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+					if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+						_ = c.conn.Close()
+						return
+					}
+				default:
+					break drain
+				}
+			}
+			if c.closeMsg != nil {
+				_ = c.conn.WriteControl(websocket.CloseMessage, c.closeMsg, time.Now().Add(writeWait))
+			}
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package collab
+
+import (
+	customerrors "backend/errors"
+	"backend/i18n"
+	"backend/models"
+	"backend/ratelimit"
+	"backend/services"
+	"encoding/json"
+	"errors"
+)
+
+// handle 处理一条客户端消息
+func (c *Client) handle(msg *InboundMessage) {
+	switch msg.Type {
+	case MsgPing:
+		c.send(OutboundMessage{Type: MsgPong, RequestID: msg.RequestID})
+	case MsgSubscribe, MsgUnsubscribe:
+		if msg.Topic != TopicTodos && !(msg.Topic == TopicTodo && msg.TodoID != 0) {
+			c.send(OutboundMessage{Type: MsgError, RequestID: msg.RequestID, Error: "invalid topic: must be todos, or todo with todo_id"})
+			return
+		}
+		c.hub.subscribe(c, msg.Topic, msg.TodoID, msg.Type == MsgSubscribe)
+	case MsgEditing, MsgIdle:
+		if msg.TodoID == 0 {
+			c.send(OutboundMessage{Type: MsgError, RequestID: msg.RequestID, Error: "todo_id is required"})
+			return
+		}
+		c.hub.setEditing(c, msg.TodoID, msg.Type == MsgEditing)
+	case MsgMutate:
+		c.send(c.mutate(msg))
+	default:
+		c.send(OutboundMessage{Type: MsgError, RequestID: msg.RequestID, Error: "unknown message type: " + msg.Type})
+	}
+}
+
+// mutate 通过 TodoService 执行修改，与 REST 接口一样做参数校验和版本检查
+// 修改成功后的数据变更事件由事件中心统一广播，这里只回复结果
+func (c *Client) mutate(msg *InboundMessage) OutboundMessage {
+	result := OutboundMessage{Type: MsgResult, RequestID: msg.RequestID, TodoID: msg.TodoID}
+	service := c.hub.service
+
+	var (
+		data interface{}
+		err  error
+	)
+	// 连接建立后的每条修改消息按一个写请求计数，否则连上之后的修改不受限流约束
+	if err = ratelimit.Charge(c.ctx, ratelimit.PolicyWrite); err == nil {
+		switch msg.Op {
+		case OpCreate:
+			var input models.CreateTodoInput
+			if err = decode(msg.Data, &input); err == nil {
+				data, err = service.CreateTodo(c.ctx, &input)
+			}
+		case OpUpdate:
+			var input models.UpdateTodoInput
+			if err = decode(msg.Data, &input); err == nil {
+				data, err = service.UpdateTodo(c.ctx, msg.TodoID, &input)
+			}
+		case OpStatus:
+			var input models.UpdateStatusInput
+			if err = decode(msg.Data, &input); err == nil {
+				data, err = service.UpdateTodoStatus(c.ctx, msg.TodoID, &input)
+			}
+		case OpDelete:
+			err = service.DeleteTodo(c.ctx, msg.TodoID)
+		default:
+			err = customerrors.ErrInvalidRequest.WithMessage("unknown op: %s", msg.Op).WithDetails("param", "op")
+		}
+	}
+
+	if err != nil {
+		result.Error = i18n.Detail(c.lang, err)
+		result.ErrorCode = customerrors.CodeOf(err)
+		var conflictErr *services.VersionConflictError
+		if errors.As(err, &conflictErr) {
+			result.Conflict = &Conflict{
+				CurrentVersion:  conflictErr.CurrentVersion,
+				ProvidedVersion: conflictErr.ProvidedVersion,
+				LatestData:      conflictErr.LatestData,
+			}
+		}
+		return result
+	}
+
+	// 修改成功后该连接不再处于编辑状态
+	if msg.Op == OpUpdate || msg.Op == OpDelete {
+		c.hub.setEditing(c, msg.TodoID, false)
+	}
+
+	result.OK = true
+	result.Data = data
+	return result
+}
+
+// decode 解析修改数据
+func decode(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return customerrors.ErrInvalidRequest.WithMessage("data is required").WithDetails("param", "data")
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return customerrors.ErrInvalidRequest.WithMessage("invalid data: %s", err.Error()).WithDetails("param", "data")
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package collab
+
+import (
+	"backend/events"
+	"backend/services"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hub 协作中心：管理所有 WebSocket 连接、订阅关系和编辑状态（presence）
+type Hub struct {
+	service *services.TodoService
+	broker  *events.Broker
+
+	mu       sync.Mutex
+	clients  map[*Client]struct{}
+	presence map[uint]map[*Client]time.Time // todoID -> 正在编辑的连接及开始时间
+	closed   bool
+	conns    sync.WaitGroup // 尚未清理完毕的连接
+
+	nextID atomic.Uint64
+	sub    *events.Subscription
+	done   chan struct{}
+}
+
+// DefaultHub 全局协作中心
+var DefaultHub = NewHub(services.NewTodoService(), events.DefaultBroker)
+
+// NewHub 创建协作中心并开始转发数据变更事件
+func NewHub(service *services.TodoService, broker *events.Broker) *Hub {
+	h := &Hub{
+		service:  service,
+		broker:   broker,
+		clients:  make(map[*Client]struct{}),
+		presence: make(map[uint]map[*Client]time.Time),
+		done:     make(chan struct{}),
+	}
+	h.sub, _, _ = broker.Subscribe(0, nil)
+	go h.forwardEvents()
+	return h
+}
+
+// forwardEvents 把事件中心的数据变更转发给订阅了对应主题的连接
+func (h *Hub) forwardEvents() {
+	defer close(h.done)
+	for {
+		for e := range h.sub.C {
+			h.dispatch(e)
+		}
+
+		// 通道关闭：要么是 Close 主动取消订阅，要么是转发过慢被事件中心断开，后者需要重新订阅
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return
+		}
+		h.sub, _, _ = h.broker.Subscribe(0, nil)
+		h.mu.Unlock()
+		slog.Warn("event subscription dropped, resubscribed", "component", "collab")
+	}
+}
+
+// dispatch 转发单个事件
+func (h *Hub) dispatch(e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	msg := OutboundMessage{Type: MsgEvent, TodoID: e.TodoID, Event: &e}
+	for c := range h.clients {
+		if c.subscribed(e.TodoID) {
+			c.send(msg)
+		}
+	}
+	// 被删除的待办事项不再有编辑者
+	if e.Type == events.TodoDeleted {
+		delete(h.presence, e.TodoID)
+	}
+}
+
+// subscribe 修改连接的订阅关系，订阅后立即推送当前的编辑者
+func (h *Hub) subscribe(c *Client, topic string, todoID uint, on bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch topic {
+	case TopicTodos:
+		c.all = on
+	case TopicTodo:
+		if on {
+			c.todos[todoID] = struct{}{}
+		} else {
+			delete(c.todos, todoID)
+		}
+	}
+
+	if !on {
+		return
+	}
+	for id := range h.presence {
+		if c.subscribed(id) {
+			c.send(h.presenceMessageLocked(id))
+		}
+	}
+}
+
+// register 登记新连接，服务已关闭时返回 false
+func (h *Hub) register(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	c.id = strconv.FormatUint(h.nextID.Add(1), 10)
+	if c.user == "" {
+		c.user = "anonymous-" + c.id
+	}
+	h.clients[c] = struct{}{}
+	h.conns.Add(1)
+	return true
+}
+
+// unregister 移除连接，并清理它的编辑状态
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	defer h.conns.Done()
+	for todoID, editors := range h.presence {
+		if _, ok := editors[c]; ok {
+			delete(editors, c)
+			h.broadcastPresenceLocked(todoID)
+		}
+	}
+}
+
+// setEditing 更新某个连接对待办事项的编辑状态并广播
+func (h *Hub) setEditing(c *Client, todoID uint, editing bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	editors := h.presence[todoID]
+	if editing {
+		if editors == nil {
+			editors = make(map[*Client]time.Time)
+			h.presence[todoID] = editors
+		}
+		if _, ok := editors[c]; ok {
+			return
+		}
+		editors[c] = time.Now()
+	} else {
+		if _, ok := editors[c]; !ok {
+			return
+		}
+		delete(editors, c)
+	}
+	h.broadcastPresenceLocked(todoID)
+}
+
+// broadcastPresenceLocked 向订阅者广播编辑者列表，调用方需持有锁
+func (h *Hub) broadcastPresenceLocked(todoID uint) {
+	msg := h.presenceMessageLocked(todoID)
+	if len(h.presence[todoID]) == 0 {
+		delete(h.presence, todoID)
+	}
+	for c := range h.clients {
+		if c.subscribed(todoID) {
+			c.send(msg)
+		}
+	}
+}
+
+// presenceMessageLocked 构造编辑者列表消息，按开始编辑时间排序
+func (h *Hub) presenceMessageLocked(todoID uint) OutboundMessage {
+	editors := make([]Editor, 0, len(h.presence[todoID]))
+	for c, since := range h.presence[todoID] {
+		editors = append(editors, Editor{ClientID: c.id, User: c.user, Since: since})
+	}
+	sort.Slice(editors, func(i, j int) bool { return editors[i].Since.Before(editors[j].Since) })
+	return OutboundMessage{Type: MsgPresence, TodoID: todoID, Editors: editors}
+}
+
+// Count 当前连接数
+func (h *Hub) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close 关闭协作中心：通知并断开所有连接，停止转发事件
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	// 并行断开，每个连接最多等待 closeGrace；读协程退出时完成清理
+	for _, c := range clients {
+		go c.shutdown()
+	}
+	h.conns.Wait()
+	h.mu.Lock()
+	sub := h.sub
+	h.mu.Unlock()
+	sub.Close()
+	<-h.done
+
+	slog.Info("hub closed", "component", "collab", "connections", len(clients))
+}
+
+// encode 序列化消息，失败时记录日志
+func encode(msg OutboundMessage) []byte {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("failed to encode message", "component", "collab", "type", msg.Type, "error", err)
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,163 @@
+package collab
+
+import (
+	"backend/events"
+	"backend/i18n"
+	"backend/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer 启动一个只挂载协作通道的测试服务器
+func newTestServer(t *testing.T) (*Hub, *events.Broker, string) {
+	broker := events.NewBroker(10)
+	hub := NewHub(services.NewTodoService(), broker)
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		hub.Serve(r.Context(), conn, r.URL.Query().Get("user"), i18n.FromRequest(r))
+	}))
+	t.Cleanup(func() {
+		hub.Close()
+		srv.Close()
+	})
+
+	return hub, broker, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// dial 建立连接并读掉 welcome 消息
+func dial(t *testing.T, url, user string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?user="+user, nil)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if msg := read(t, conn); msg.Type != MsgWelcome {
+		t.Fatalf("第一条消息应该是 welcome，实际: %s", msg.Type)
+	}
+	return conn
+}
+
+// read 读取下一条消息
+func read(t *testing.T, conn *websocket.Conn) OutboundMessage {
+	var msg OutboundMessage
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("读取消息失败: %v", err)
+	}
+	return msg
+}
+
+// TestPresence 测试编辑状态广播
+func TestPresence(t *testing.T) {
+	_, _, url := newTestServer(t)
+
+	alice := dial(t, url, "alice")
+	defer alice.Close()
+	bob := dial(t, url, "bob")
+	defer bob.Close()
+
+	// bob 订阅 1 号待办事项
+	_ = bob.WriteJSON(InboundMessage{Type: MsgSubscribe, Topic: TopicTodo, TodoID: 1})
+	_ = bob.WriteJSON(InboundMessage{Type: MsgPing, RequestID: "sync"})
+	if msg := read(t, bob); msg.Type != MsgPong {
+		t.Fatalf("应该收到 pong，实际: %s", msg.Type)
+	}
+
+	// alice 开始编辑，bob 收到 presence
+	_ = alice.WriteJSON(InboundMessage{Type: MsgEditing, TodoID: 1})
+	msg := read(t, bob)
+	if msg.Type != MsgPresence || len(msg.Editors) != 1 || msg.Editors[0].User != "alice" {
+		t.Fatalf("presence 消息不正确: %+v", msg)
+	}
+	t.Logf("✅ bob 看到 %s 正在编辑 #%d", msg.Editors[0].User, msg.TodoID)
+
+	// alice 断开后编辑状态被清理
+	alice.Close()
+	msg = read(t, bob)
+	if msg.Type != MsgPresence || len(msg.Editors) != 0 {
+		t.Fatalf("断开后应该广播空的编辑者列表: %+v", msg)
+	}
+	t.Log("✅ 断开连接后编辑状态被清理")
+}
+
+// TestAnonymousPresence 测试未登录的连接在编辑状态中可以区分
+func TestAnonymousPresence(t *testing.T) {
+	_, _, url := newTestServer(t)
+
+	watcher := dial(t, url, "alice")
+	defer watcher.Close()
+	_ = watcher.WriteJSON(InboundMessage{Type: MsgSubscribe, Topic: TopicTodo, TodoID: 1})
+	_ = watcher.WriteJSON(InboundMessage{Type: MsgPing, RequestID: "sync"})
+	if msg := read(t, watcher); msg.Type != MsgPong {
+		t.Fatalf("应该收到 pong，实际: %s", msg.Type)
+	}
+
+	var msg OutboundMessage
+	for i := 0; i < 2; i++ {
+		conn := dial(t, url, "")
+		defer conn.Close()
+		_ = conn.WriteJSON(InboundMessage{Type: MsgEditing, TodoID: 1})
+		msg = read(t, watcher)
+	}
+	if msg.Type != MsgPresence || len(msg.Editors) != 2 {
+		t.Fatalf("应该有两个编辑者: %+v", msg)
+	}
+	first, second := msg.Editors[0].User, msg.Editors[1].User
+	if first == second || first != "anonymous-"+msg.Editors[0].ClientID || second != "anonymous-"+msg.Editors[1].ClientID {
+		t.Fatalf("匿名连接应该显示为 anonymous-连接 ID，实际: %s、%s", first, second)
+	}
+	t.Logf("✅ 匿名编辑者: %s、%s", first, second)
+}
+
+// TestEventForwarding 测试数据变更事件转发
+func TestEventForwarding(t *testing.T) {
+	_, broker, url := newTestServer(t)
+
+	conn := dial(t, url, "alice")
+	defer conn.Close()
+
+	_ = conn.WriteJSON(InboundMessage{Type: MsgSubscribe, Topic: TopicTodos})
+	_ = conn.WriteJSON(InboundMessage{Type: MsgPing})
+	read(t, conn)
+
+	broker.Publish(events.Event{Type: events.TodoUpdated, TodoID: 7, Version: 3})
+	msg := read(t, conn)
+	if msg.Type != MsgEvent || msg.Event == nil || msg.Event.TodoID != 7 || msg.Event.Version != 3 {
+		t.Fatalf("事件消息不正确: %+v", msg)
+	}
+
+	t.Logf("✅ 收到事件: %s #%d v%d", msg.Event.Type, msg.Event.TodoID, msg.Event.Version)
+}
+
+// TestShutdown 测试关闭时断开所有连接
+func TestShutdown(t *testing.T) {
+	hub, _, url := newTestServer(t)
+
+	conn := dial(t, url, "alice")
+	defer conn.Close()
+
+	hub.Close()
+
+	if msg := read(t, conn); msg.Type != MsgShutdown {
+		t.Fatalf("应该收到 shutdown 消息，实际: %s", msg.Type)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("应该收到 going away 关闭帧，实际: %v", err)
+	}
+	if hub.Count() != 0 {
+		t.Errorf("关闭后连接数应该为 0，实际 %d", hub.Count())
+	}
+
+	t.Log("✅ 关闭时所有连接被断开")
+}
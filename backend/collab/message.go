@@ -0,0 +1,82 @@
+package collab
+
+import (
+	"backend/events"
+	"backend/models"
+	"encoding/json"
+	"time"
+)
+
+// 客户端 -> 服务端消息类型
+const (
+	MsgSubscribe   = "subscribe"   // 订阅列表或单个待办事项
+	MsgUnsubscribe = "unsubscribe" // 取消订阅
+	MsgEditing     = "editing"     // 开始编辑某个待办事项
+	MsgIdle        = "idle"        // 结束编辑
+	MsgMutate      = "mutate"      // 提交修改
+	MsgPing        = "ping"        // 应用层心跳（浏览器无法发送 WebSocket ping 帧）
+)
+
+// 服务端 -> 客户端消息类型
+const (
+	MsgWelcome  = "welcome"  // 连接建立
+	MsgPresence = "presence" // 某个待办事项的编辑者列表变化
+	MsgEvent    = "event"    // 数据变更事件
+	MsgResult   = "result"   // 修改结果
+	MsgError    = "error"    // 消息格式等错误
+	MsgPong     = "pong"
+	MsgShutdown = "shutdown" // 服务即将关闭
+)
+
+// 订阅主题
+const (
+	TopicTodos = "todos" // 全部待办事项
+	TopicTodo  = "todo"  // 单个待办事项，需配合 todo_id
+)
+
+// 修改操作
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpStatus = "status"
+	OpDelete = "delete"
+)
+
+// InboundMessage 客户端发来的消息
+type InboundMessage struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id,omitempty"` // 客户端生成，用于匹配 result
+	Topic     string          `json:"topic,omitempty"`
+	TodoID    uint            `json:"todo_id,omitempty"`
+	Op        string          `json:"op,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"` // 对应 CreateTodoInput / UpdateTodoInput / UpdateStatusInput
+}
+
+// OutboundMessage 服务端发出的消息
+type OutboundMessage struct {
+	Type      string        `json:"type"`
+	RequestID string        `json:"request_id,omitempty"`
+	ClientID  string        `json:"client_id,omitempty"`
+	TodoID    uint          `json:"todo_id,omitempty"`
+	Editors   []Editor      `json:"editors,omitempty"`
+	Event     *events.Event `json:"event,omitempty"`
+	OK        bool          `json:"ok,omitempty"`
+	Data      interface{}   `json:"data,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	ErrorCode string        `json:"error_code,omitempty"` // 错误码，如 VERSION_CONFLICT
+	Conflict  *Conflict     `json:"conflict,omitempty"`
+}
+
+// Editor 正在编辑某个待办事项的用户
+type Editor struct {
+	ClientID string    `json:"client_id"`
+	User     string    `json:"user"`
+	Since    time.Time `json:"since"`
+}
+
+// Conflict 版本冲突详情，与 REST 接口的 VersionConflictResponse 字段一致
+type Conflict struct {
+	CurrentVersion  int          `json:"current_version"`
+	ProvidedVersion int          `json:"provided_version"`
+	LatestData      *models.Todo `json:"latest_data"`
+}
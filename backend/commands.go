@@ -0,0 +1,365 @@
+package main
+
+import (
+	"backend/admin"
+	"backend/config"
+	"backend/migrations"
+	"backend/models"
+	"backend/services"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 管理命令：与 serve 共用 config 中的数据库配置（TODO_DB_* 环境变量），
+// 结果输出到标准输出，提示和错误输出到标准错误，便于在脚本中使用
+
+// newAdminFlagSet 创建管理命令的参数解析器，附带 --verbose 参数
+func newAdminFlagSet(name, usage string) (*flag.FlagSet, *bool) {
+	fs := newFlagSet(name, usage)
+	verbose := fs.Bool("verbose", false, "打印执行的 SQL")
+	return fs, verbose
+}
+
+// connectDB 连接数据库，管理命令默认不打印 SQL 日志，以免混入命令输出
+func connectDB(verbose bool) error {
+	cfg := config.GetDatabaseConfig()
+	cfg.SQLLog = verbose
+	return config.Connect(cfg)
+}
+
+// runMigrate migrate up [n] | down [n] | status
+func runMigrate(args []string) error {
+	fs, verbose := newAdminFlagSet("migrate", "up [n] | down [n] | status")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		return usagef(fs, "expected a subcommand")
+	}
+	action := fs.Arg(0)
+
+	// up 默认执行全部，down 默认回滚一个
+	steps := 0
+	if action == "down" {
+		steps = 1
+	}
+	if fs.NArg() == 2 {
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n <= 0 || action == "status" {
+			return usagef(fs, "invalid step count %q", fs.Arg(1))
+		}
+		steps = n
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+
+	var (
+		done []migrations.Migration
+		err  error
+	)
+	switch action {
+	case "up":
+		done, err = migrations.Up(config.DB, steps)
+	case "down":
+		done, err = migrations.Down(config.DB, steps)
+	case "status":
+		return printMigrationStatus()
+	default:
+		return usagef(fs, "unknown subcommand %q", action)
+	}
+
+	for _, m := range done {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Fprintln(os.Stderr, "nothing to do")
+	}
+	return nil
+}
+
+// printMigrationStatus 打印每个迁移的执行情况
+func printMigrationStatus() error {
+	statuses, err := migrations.List(config.DB)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d  %-24s %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
+
+// runSeed 生成演示数据
+func runSeed(args []string) error {
+	fs, verbose := newAdminFlagSet("seed", "[--count n] [--seed n]")
+	count := fs.Int("count", 30, "生成的条数")
+	seed := fs.Int64("seed", 0, "随机种子，相同的种子生成相同的数据，0 表示随机")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return usagef(fs, "--count must be positive")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+	todos, err := admin.Seed(context.Background(), services.NewTodoService(), *count, mathrand.New(mathrand.NewSource(*seed)))
+	fmt.Printf("created %d todos\n", len(todos))
+	return err
+}
+
+// runExport 导出待办事项
+func runExport(args []string) error {
+	fs, verbose := newAdminFlagSet("export", "[--file path]")
+	file := fs.String("file", "", "输出文件，默认输出到标准输出")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef(fs, "unexpected argument %q", fs.Arg(0))
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *file != "" && *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	n, err := admin.ExportTodos(context.Background(), w)
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %d todos\n", n)
+	return nil
+}
+
+// runImport 导入 export 导出的文件，有记录导入失败时退出码为 1
+func runImport(args []string) error {
+	fs, verbose := newAdminFlagSet("import", "[--file path]")
+	file := fs.String("file", "", "导入的文件，默认从标准输入读取")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef(fs, "unexpected argument %q", fs.Arg(0))
+	}
+
+	in := os.Stdin
+	if *file != "" && *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+	result, err := admin.ImportTodos(context.Background(), bufio.NewReader(in), services.NewTodoService())
+	if err != nil {
+		return err
+	}
+	for _, f := range result.Failed {
+		fmt.Fprintf(os.Stderr, "todos[%d] %q: %s\n", f.Index, f.Title, f.Error)
+	}
+	fmt.Printf("imported %d todos\n", result.Imported)
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d todos failed to import", len(result.Failed))
+	}
+	return nil
+}
+
+// runPurgeTrash 彻底删除回收站中超过保留期的待办事项
+func runPurgeTrash(args []string) error {
+	fs, verbose := newAdminFlagSet("purge-trash", "[--older-than 30d] [--dry-run]")
+	olderThan := fs.String("older-than", "30d", "保留期，支持 d（天）以及 h、m 等 Go 时长单位")
+	dryRun := fs.Bool("dry-run", false, "只统计将被删除的条数，不实际删除")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	retention, err := parseRetention(*olderThan)
+	if err != nil {
+		return usagef(fs, "invalid --older-than %q", *olderThan)
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+	before := time.Now().Add(-retention)
+	if *dryRun {
+		n, err := models.CountDeleted(before)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("would purge %d todos deleted before %s\n", n, before.Format(time.RFC3339))
+		return nil
+	}
+	n, err := models.PurgeDeleted(before)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("purged %d todos deleted before %s\n", n, before.Format(time.RFC3339))
+	return nil
+}
+
+// parseRetention 解析保留期，在 time.ParseDuration 的基础上支持以天为单位，如 30d
+func parseRetention(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative retention")
+	}
+	return d, nil
+}
+
+// runCheckDB 检查数据库，连接失败或有检查项未通过时退出码为 3
+func runCheckDB(args []string) error {
+	fs, verbose := newAdminFlagSet("check-db", "[--json]")
+	asJSON := fs.Bool("json", false, "以 JSON 输出检查结果")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var checks []admin.Check
+	if err := connectDB(*verbose); err != nil {
+		checks = []admin.Check{{Name: "connection", Detail: err.Error()}}
+	} else {
+		checks = admin.CheckDB(config.DB)
+	}
+
+	ok := true
+	for _, c := range checks {
+		ok = ok && c.OK
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]interface{}{"ok": ok, "checks": checks}); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range checks {
+			result := "ok"
+			if !c.OK {
+				result = "FAIL"
+			}
+			if c.Detail != "" {
+				result += "  " + c.Detail
+			}
+			fmt.Printf("%-28s %s\n", c.Name, result)
+		}
+	}
+	if !ok {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// runCreateUser 创建用户
+// 密码通过 --password-stdin 从标准输入读取（第一行），不通过参数传递以免出现在进程列表和 shell 历史中；
+// 未指定时生成随机密码并打印
+func runCreateUser(args []string) error {
+	fs, verbose := newAdminFlagSet("create-user", "--username name [--role user|admin] [--password-stdin]")
+	username := fs.String("username", "", "用户名")
+	role := fs.String("role", models.RoleUser, "角色：user 或 admin")
+	passwordStdin := fs.Bool("password-stdin", false, "从标准输入读取密码")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return usagef(fs, "--username is required")
+	}
+
+	password, generated := "", false
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	} else {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+		generated = true
+	}
+
+	if err := connectDB(*verbose); err != nil {
+		return err
+	}
+	user, err := services.NewUserService().CreateUser(context.Background(), &models.CreateUserInput{
+		Username: *username,
+		Password: password,
+		Role:     *role,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %s (id %d, role %s)\n", user.Username, user.ID, user.Role)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+	return nil
+}
+
+// generatePassword 生成 16 字节的随机密码
+func generatePassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// v1 接口的弃用日期（v2 发布日）和默认下线日期
+var (
+	v1DeprecatedAt  = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	defaultV1Sunset = time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// APIConfig 接口版本配置
+type APIConfig struct {
+	V1DeprecatedAt time.Time // v1 接口开始弃用的时间，通过 Deprecation 响应头告知客户端
+	V1Sunset       time.Time // v1 接口计划下线的时间，通过 Sunset 响应头告知客户端
+}
+
+// GetAPIConfig 从环境变量读取接口版本配置
+// TODO_API_V1_SUNSET=2027-04-30 可以推迟或提前 v1 的下线日期
+func GetAPIConfig() *APIConfig {
+	cfg := &APIConfig{V1DeprecatedAt: v1DeprecatedAt, V1Sunset: defaultV1Sunset}
+	if s := os.Getenv("TODO_API_V1_SUNSET"); s != "" {
+		sunset, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			slog.Warn("invalid TODO_API_V1_SUNSET", "value", s, "default", defaultV1Sunset.Format(time.DateOnly))
+		} else {
+			cfg.V1Sunset = sunset
+		}
+	}
+	return cfg
+}
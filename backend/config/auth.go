@@ -0,0 +1,77 @@
+package config
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 数据接口的登录要求（TODO_REQUIRE_AUTH）
+const (
+	AuthRequiredOff    = "off"    // 不要求登录（前端还没有登录页面，目前的默认值）
+	AuthRequiredWrites = "writes" // 修改请求（GET、HEAD 以外）和 WebSocket 协作通道要求登录
+	AuthRequiredAll    = "all"    // 所有数据接口都要求登录
+)
+
+// AuthConfig 登录会话配置
+type AuthConfig struct {
+	Required     string        // 数据接口的登录要求：off、writes 或 all
+	SessionTTL   time.Duration // 会话有效期，Cookie 和 Bearer 令牌相同
+	CookieName   string        // 会话 Cookie 的名称
+	CookieSecure bool          // 会话 Cookie 只通过 HTTPS 发送；浏览器把 localhost 视为安全来源，本地开发也能使用
+	SameSite     http.SameSite // 会话 Cookie 的 SameSite 属性，Lax 或 Strict
+}
+
+// GetAuthConfig 从环境变量读取登录会话配置
+// TODO_SESSION_TTL=24h 会话有效期，TODO_SESSION_COOKIE=todo_session Cookie 名称，
+// TODO_COOKIE_SECURE=false 允许通过 HTTP 发送 Cookie，TODO_COOKIE_SAMESITE=lax|strict，
+// TODO_REQUIRE_AUTH=off|writes|all 数据接口的登录要求
+func GetAuthConfig() *AuthConfig {
+	cfg := &AuthConfig{
+		Required:     AuthRequiredOff,
+		SessionTTL:   24 * time.Hour,
+		CookieName:   "todo_session",
+		CookieSecure: true,
+		SameSite:     http.SameSiteLaxMode,
+	}
+	if s := os.Getenv("TODO_SESSION_TTL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			slog.Warn("invalid TODO_SESSION_TTL", "value", s, "default", cfg.SessionTTL)
+		} else {
+			cfg.SessionTTL = d
+		}
+	}
+	if name := os.Getenv("TODO_SESSION_COOKIE"); name != "" {
+		cfg.CookieName = name
+	}
+	if s := os.Getenv("TODO_COOKIE_SECURE"); s != "" {
+		secure, err := strconv.ParseBool(s)
+		if err != nil {
+			slog.Warn("invalid TODO_COOKIE_SECURE, using true", "value", s)
+		} else {
+			cfg.CookieSecure = secure
+		}
+	}
+	// SameSite=None 会让跨站请求也带上 Cookie，不提供这个选项
+	switch s := strings.ToLower(os.Getenv("TODO_COOKIE_SAMESITE")); s {
+	case "", "lax":
+	case "strict":
+		cfg.SameSite = http.SameSiteStrictMode
+	default:
+		slog.Warn("invalid TODO_COOKIE_SAMESITE, using lax", "value", s)
+	}
+	switch s := strings.ToLower(os.Getenv("TODO_REQUIRE_AUTH")); s {
+	case "":
+	case AuthRequiredOff, AuthRequiredWrites, AuthRequiredAll:
+		cfg.Required = s
+	default:
+		// 拼写错误时按最严格的要求处理，不会因此意外对外开放
+		slog.Warn("invalid TODO_REQUIRE_AUTH, requiring login for all requests", "value", s)
+		cfg.Required = AuthRequiredAll
+	}
+	return cfg
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	customerrors "backend/errors"
+	"backend/logging"
+	"backend/tracing"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -19,6 +22,7 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+	SQLLog   bool // 是否记录 SQL 日志（错误和慢查询，debug 级别下记录每条 SQL），管理命令默认关闭
 }
 
 // GetDefaultConfig 获取默认数据库配置
@@ -29,12 +33,39 @@ func GetDefaultConfig() *DatabaseConfig {
 		User:     "root",
 		Password: "",
 		DBName:   "todo_app",
+		SQLLog:   true,
 	}
 }
 
-// InitDB 初始化数据库连接
-func InitDB() error {
+// GetDatabaseConfig 在默认配置上应用环境变量
+// TODO_DB_HOST、TODO_DB_PORT、TODO_DB_USER、TODO_DB_PASSWORD、TODO_DB_NAME 覆盖对应的连接参数，
+// TODO_DB_LOG=off 关闭 SQL 日志
+func GetDatabaseConfig() *DatabaseConfig {
 	config := GetDefaultConfig()
+	for env, field := range map[string]*string{
+		"TODO_DB_HOST":     &config.Host,
+		"TODO_DB_PORT":     &config.Port,
+		"TODO_DB_USER":     &config.User,
+		"TODO_DB_PASSWORD": &config.Password,
+		"TODO_DB_NAME":     &config.DBName,
+	} {
+		if v, ok := os.LookupEnv(env); ok {
+			*field = v
+		}
+	}
+	if os.Getenv("TODO_DB_LOG") == "off" {
+		config.SQLLog = false
+	}
+	return config
+}
+
+// InitDB 按环境变量中的配置初始化数据库连接
+func InitDB() error {
+	return Connect(GetDatabaseConfig())
+}
+
+// Connect 使用指定配置初始化数据库连接
+func Connect(config *DatabaseConfig) error {
 
 	// 构建 DSN (Data Source Name)
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
@@ -47,18 +78,26 @@ func InitDB() error {
 
 	// 连接数据库
 	var err error
+	sqlLogger := logger.Default.LogMode(logger.Silent)
+	if config.SQLLog {
+		sqlLogger = logging.NewGormLogger(GetLogConfig().SlowQueryThreshold)
+	}
 	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // 开启 SQL 日志
+		Logger: sqlLogger,
 	})
 
 	if err != nil {
-		return fmt.Errorf("%w: %v", customerrors.ErrDatabaseConnection, err)
+		return customerrors.ErrDatabaseConnection.Wrap(err)
+	}
+	// 每条 SQL 一个 span，未开启链路追踪时为空操作
+	if err := DB.Use(tracing.NewGormPlugin()); err != nil {
+		return customerrors.ErrDatabaseInit.Wrap(err)
 	}
 
 	// 获取底层的 sql.DB 对象，用于配置连接池
 	sqlDB, err := DB.DB()
 	if err != nil {
-		return fmt.Errorf("%w: %v", customerrors.ErrDatabaseInit, err)
+		return customerrors.ErrDatabaseInit.Wrap(err)
 	}
 
 	// 设置连接池参数
@@ -66,7 +105,9 @@ func InitDB() error {
 	sqlDB.SetMaxOpenConns(100)     // 最大打开连接数
 	sqlDB.SetConnMaxLifetime(3600) // 连接最大生命周期（秒）
 
-	log.Println("Database connected successfully!")
+	if config.SQLLog {
+		slog.Info("Database connected successfully!", "host", config.Host, "database", config.DBName)
+	}
 	return nil
 }
 
@@ -74,3 +115,15 @@ func InitDB() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// Close 关闭数据库连接池，等待进行中的查询完成
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 跨域策略配置
+type CORSConfig struct {
+	AllowedOrigins   []string      // 允许的来源，如 https://todo.example.com；支持通配的子域名 https://*.example.com，* 表示任意来源
+	AllowedMethods   []string      // 预检请求允许的方法
+	AllowedHeaders   []string      // 预检请求允许的请求头
+	ExposedHeaders   []string      // 允许前端脚本读取的响应头
+	AllowCredentials bool          // 是否允许携带 Cookie 等凭据；来源为 * 时不允许
+	MaxAge           time.Duration // 浏览器缓存预检结果的时间
+}
+
+// GetCORSConfig 从环境变量读取跨域策略
+// TODO_CORS_ORIGINS、TODO_CORS_METHODS、TODO_CORS_HEADERS、TODO_CORS_EXPOSE_HEADERS 为逗号分隔的列表，
+// TODO_CORS_CREDENTIALS=false 不允许携带凭据，TODO_CORS_MAX_AGE=10m 预检结果的缓存时间
+// 默认只允许前端开发服务器（Vite，5173 端口）直接访问；通过代理或同源部署的前端不需要跨域
+func GetCORSConfig() *CORSConfig {
+	cfg := &CORSConfig{
+		AllowedOrigins: []string{"http://localhost:5173", "http://127.0.0.1:5173"},
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders: []string{
+			"Accept", "Accept-Language", "Authorization", "Cache-Control", "Content-Type",
+			"If-Match", "If-None-Match", "X-CSRF-Token", "X-Request-ID", "X-Requested-With",
+			"traceparent", "tracestate",
+		},
+		ExposedHeaders: []string{
+			"ETag", "X-Request-ID", "Content-Language", "Deprecation", "Sunset", "Link",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+		},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	for env, field := range map[string]*[]string{
+		"TODO_CORS_ORIGINS":        &cfg.AllowedOrigins,
+		"TODO_CORS_METHODS":        &cfg.AllowedMethods,
+		"TODO_CORS_HEADERS":        &cfg.AllowedHeaders,
+		"TODO_CORS_EXPOSE_HEADERS": &cfg.ExposedHeaders,
+	} {
+		if s, ok := os.LookupEnv(env); ok {
+			*field = splitList(s)
+		}
+	}
+	if s := os.Getenv("TODO_CORS_CREDENTIALS"); s != "" {
+		credentials, err := strconv.ParseBool(s)
+		if err != nil {
+			slog.Warn("invalid TODO_CORS_CREDENTIALS, using true", "value", s)
+		} else {
+			cfg.AllowCredentials = credentials
+		}
+	}
+	if s := os.Getenv("TODO_CORS_MAX_AGE"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			slog.Warn("invalid TODO_CORS_MAX_AGE", "value", s, "default", cfg.MaxAge)
+		} else {
+			cfg.MaxAge = d
+		}
+	}
+	// 浏览器不接受 Access-Control-Allow-Origin: * 与凭据同时出现；也不能用回显任意来源代替，否则任何网站都能带着用户的 Cookie 调用接口
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" && cfg.AllowCredentials {
+			slog.Warn("TODO_CORS_ORIGINS allows any origin, credentials disabled")
+			cfg.AllowCredentials = false
+			break
+		}
+	}
+	return cfg
+}
+
+// splitList 解析逗号分隔的列表，去掉空白和空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
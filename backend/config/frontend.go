@@ -0,0 +1,19 @@
+package config
+
+import "os"
+
+// FrontendConfig 前端页面配置
+type FrontendConfig struct {
+	DevURL string // 开发构建中转发页面请求的 Vite 开发服务器地址，为空时不提供页面；内嵌前端的构建不使用
+}
+
+// GetFrontendConfig 从环境变量读取前端页面配置
+// TODO_FRONTEND_DEV_URL 如 http://localhost:5173，默认为空：不转发，未匹配的路径返回 404，
+// 否则没有启动 Vite 时所有页面请求都是 502
+func GetFrontendConfig() *FrontendConfig {
+	cfg := &FrontendConfig{DevURL: os.Getenv("TODO_FRONTEND_DEV_URL")}
+	if cfg.DevURL == "off" {
+		cfg.DevURL = ""
+	}
+	return cfg
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"net"
+	"net/netip"
+	"os"
+)
+
+// defaultGRPCAddr gRPC 服务默认监听地址，与 HTTP 服务（:8080）分开
+// 默认只监听回环地址：未设置访问令牌时不校验身份，不能对外开放
+const defaultGRPCAddr = "127.0.0.1:9090"
+
+// GRPCConfig gRPC 服务配置
+type GRPCConfig struct {
+	Addr  string // 监听地址，为 off 时不启动 gRPC 服务
+	Token string // 访问令牌，客户端在 authorization 元数据中以 Bearer 方式携带；为空时只允许监听回环地址
+}
+
+// GetGRPCConfig 从环境变量读取 gRPC 服务配置
+// TODO_GRPC_ADDR=127.0.0.1:9090 指定监听地址，TODO_GRPC_TOKEN 指定访问令牌
+func GetGRPCConfig() *GRPCConfig {
+	cfg := &GRPCConfig{Addr: defaultGRPCAddr, Token: os.Getenv("TODO_GRPC_TOKEN")}
+	if addr := os.Getenv("TODO_GRPC_ADDR"); addr != "" {
+		cfg.Addr = addr
+	}
+	return cfg
+}
+
+// Loopback 监听地址是否只能从本机访问；":9090" 这样不带主机的地址监听所有网卡，不算
+func (c *GRPCConfig) Loopback() bool {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Format             string        // text 或 json
+	Level              slog.Level    // 最低输出级别，debug 时记录每条 SQL
+	SlowQueryThreshold time.Duration // 超过该耗时的 SQL 以 WARN 级别记录，0 表示不单独记录慢查询
+}
+
+// GetLogConfig 从环境变量读取日志配置
+// TODO_LOG_FORMAT=json 输出 JSON（默认 text），TODO_LOG_LEVEL=debug|info|warn|error（默认 info），
+// TODO_DB_SLOW_THRESHOLD=200ms 慢查询阈值
+func GetLogConfig() *LogConfig {
+	cfg := &LogConfig{Format: "text", Level: slog.LevelInfo, SlowQueryThreshold: 200 * time.Millisecond}
+	if format := strings.ToLower(os.Getenv("TODO_LOG_FORMAT")); format == "json" || format == "text" {
+		cfg.Format = format
+	} else if format != "" {
+		slog.Warn("invalid TODO_LOG_FORMAT, using text", "value", format)
+	}
+	if level := os.Getenv("TODO_LOG_LEVEL"); level != "" {
+		if err := cfg.Level.UnmarshalText([]byte(level)); err != nil {
+			slog.Warn("invalid TODO_LOG_LEVEL, using info", "value", level)
+			cfg.Level = slog.LevelInfo
+		}
+	}
+	if s := os.Getenv("TODO_DB_SLOW_THRESHOLD"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			slog.Warn("invalid TODO_DB_SLOW_THRESHOLD", "value", s, "default", cfg.SlowQueryThreshold)
+		} else {
+			cfg.SlowQueryThreshold = d
+		}
+	}
+	return cfg
+}
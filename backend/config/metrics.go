@@ -0,0 +1,20 @@
+package config
+
+import "os"
+
+// MetricsConfig Prometheus 指标接口配置
+type MetricsConfig struct {
+	Addr  string // 单独的监听地址，如 127.0.0.1:9100；为空时 /metrics 与接口共用 HTTP 服务
+	Token string // 访问令牌，抓取时以 Authorization: Bearer 携带；为空时不校验
+}
+
+// GetMetricsConfig 从环境变量读取指标接口配置
+// TODO_METRICS_ADDR 指定单独的监听地址，TODO_METRICS_TOKEN 指定访问令牌
+func GetMetricsConfig() *MetricsConfig {
+	return &MetricsConfig{Addr: os.Getenv("TODO_METRICS_ADDR"), Token: os.Getenv("TODO_METRICS_TOKEN")}
+}
+
+// Public 指标是否在接口端口上无需令牌即可访问
+func (c *MetricsConfig) Public() bool {
+	return c.Addr == "" && c.Token == ""
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// OutboxConfig 事务发件箱的投递目标配置
+type OutboxConfig struct {
+	LogEnabled bool   // 将事件写入服务日志
+	FilePath   string // 将事件以 JSON Lines 格式追加到文件，为空表示不启用
+}
+
+// GetOutboxConfig 从环境变量读取发件箱配置
+// TODO_OUTBOX_LOG=true 启用日志投递，TODO_OUTBOX_FILE=/path/to/events.jsonl 启用文件投递
+func GetOutboxConfig() *OutboxConfig {
+	logEnabled, _ := strconv.ParseBool(os.Getenv("TODO_OUTBOX_LOG"))
+	return &OutboxConfig{
+		LogEnabled: logEnabled,
+		FilePath:   os.Getenv("TODO_OUTBOX_FILE"),
+	}
+}
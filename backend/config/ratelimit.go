@@ -0,0 +1,71 @@
+package config
+
+import (
+	"backend/ratelimit"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitConfig 请求限流与存储限额配置
+type RateLimitConfig struct {
+	Enabled  bool
+	Read     ratelimit.Limit // GET 等只读请求
+	Write    ratelimit.Limit // 写请求，以及同步上传、GraphQL、WebSocket 中的每个修改
+	Create   ratelimit.Limit // 每次创建待办事项，在 Write 之外单独计数
+	Login    ratelimit.Limit // 登录，限制猜测密码的速度
+	MaxTodos int             // 每个登录用户（匿名创建合计）的未删除待办事项数量上限，0 表示不限制
+}
+
+// GetRateLimitConfig 从环境变量读取限流配置
+// TODO_RATE_LIMIT=off 关闭限流；TODO_RATE_LIMIT_READ、TODO_RATE_LIMIT_WRITE、TODO_RATE_LIMIT_CREATE、TODO_RATE_LIMIT_LOGIN 为"次数/时长"，
+// 如 60/1m，off 表示该类请求不限流；TODO_MAX_TODOS 为每个登录用户的待办事项数量上限，匿名创建合计使用一份，0 表示不限制
+func GetRateLimitConfig() *RateLimitConfig {
+	cfg := &RateLimitConfig{
+		Enabled:  true,
+		Read:     ratelimit.Limit{Requests: 600, Period: time.Minute},
+		Write:    ratelimit.Limit{Requests: 120, Period: time.Minute},
+		Create:   ratelimit.Limit{Requests: 30, Period: time.Minute},
+		Login:    ratelimit.Limit{Requests: 10, Period: time.Minute},
+		MaxTodos: 10000,
+	}
+	if s := os.Getenv("TODO_RATE_LIMIT"); s != "" {
+		enabled, err := strconv.ParseBool(s)
+		if strings.EqualFold(s, "off") {
+			enabled, err = false, nil
+		}
+		if err != nil {
+			slog.Warn("invalid TODO_RATE_LIMIT, rate limiting enabled", "value", s)
+		} else {
+			cfg.Enabled = enabled
+		}
+	}
+	for env, field := range map[string]*ratelimit.Limit{
+		"TODO_RATE_LIMIT_READ":   &cfg.Read,
+		"TODO_RATE_LIMIT_WRITE":  &cfg.Write,
+		"TODO_RATE_LIMIT_CREATE": &cfg.Create,
+		"TODO_RATE_LIMIT_LOGIN":  &cfg.Login,
+	} {
+		s := os.Getenv(env)
+		if s == "" {
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(s)
+		if err != nil {
+			slog.Warn("invalid "+env+", using default", "value", s, "default", field.String())
+			continue
+		}
+		*field = limit
+	}
+	if s := os.Getenv("TODO_MAX_TODOS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			slog.Warn("invalid TODO_MAX_TODOS, using default", "value", s, "default", cfg.MaxTodos)
+		} else {
+			cfg.MaxTodos = n
+		}
+	}
+	return cfg
+}
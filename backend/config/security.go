@@ -0,0 +1,48 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// defaultCSP 默认的内容安全策略：脚本、样式等只能来自本站，不允许被其他网站嵌入
+// 样式允许内联，前端组件库会设置元素的 style 属性
+const defaultCSP = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; " +
+	"font-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// SecurityConfig 安全响应头配置
+type SecurityConfig struct {
+	ContentSecurityPolicy string        // Content-Security-Policy，为空时不发送
+	ReferrerPolicy        string        // Referrer-Policy
+	HSTSMaxAge            time.Duration // Strict-Transport-Security 的 max-age，0 表示不发送
+}
+
+// GetSecurityConfig 从环境变量读取安全响应头配置
+// TODO_CSP 内容安全策略（off 表示不发送），TODO_REFERRER_POLICY 默认 strict-origin-when-cross-origin，
+// TODO_HSTS_MAX_AGE 默认 4320h（180 天），0 表示不发送
+func GetSecurityConfig() *SecurityConfig {
+	cfg := &SecurityConfig{
+		ContentSecurityPolicy: defaultCSP,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		HSTSMaxAge:            180 * 24 * time.Hour,
+	}
+	if csp, ok := os.LookupEnv("TODO_CSP"); ok {
+		if csp == "off" {
+			csp = ""
+		}
+		cfg.ContentSecurityPolicy = csp
+	}
+	if policy := os.Getenv("TODO_REFERRER_POLICY"); policy != "" {
+		cfg.ReferrerPolicy = policy
+	}
+	if s := os.Getenv("TODO_HSTS_MAX_AGE"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			slog.Warn("invalid TODO_HSTS_MAX_AGE", "value", s, "default", cfg.HSTSMaxAge)
+		} else {
+			cfg.HSTSMaxAge = d
+		}
+	}
+	return cfg
+}
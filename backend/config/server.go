@@ -0,0 +1,62 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ServerConfig HTTP 服务与退出流程配置
+type ServerConfig struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration // 读取请求头的超时，防止慢速攻击占用连接
+	ReadTimeout       time.Duration // 读取整个请求（含请求体）的超时
+	WriteTimeout      time.Duration // 写响应的超时；SSE 等长连接在处理函数中单独取消
+	IdleTimeout       time.Duration // keep-alive 空闲连接的保持时间
+	RequestTimeout    time.Duration // 单个请求（含 gRPC 一元调用）的处理时限，超时后数据库查询随上下文中断；0 表示不限制
+	DrainDelay        time.Duration // 退出时先标记为未就绪，等待这段时间让负载均衡摘除实例，期间照常处理请求
+	ShutdownTimeout   time.Duration // 停止接受新连接后等待进行中请求完成的最长时间，超时后强制关闭
+	TrustedProxies    []string      // 可信的反向代理（IP 或 CIDR），只有来自它们的 X-Forwarded-For 才用于确定客户端 IP
+}
+
+// GetServerConfig 从环境变量读取 HTTP 服务配置
+// TODO_HTTP_ADDR 指定监听地址；TODO_HTTP_READ_HEADER_TIMEOUT、TODO_HTTP_READ_TIMEOUT、TODO_HTTP_WRITE_TIMEOUT、
+// TODO_HTTP_IDLE_TIMEOUT、TODO_REQUEST_TIMEOUT、TODO_SHUTDOWN_DRAIN_DELAY、TODO_SHUTDOWN_TIMEOUT 使用 Go 时长格式，如 30s、2m；
+// TODO_TRUSTED_PROXIES 为逗号分隔的 IP 或 CIDR，默认不信任任何代理，客户端 IP 取连接的对端地址
+func GetServerConfig() *ServerConfig {
+	cfg := &ServerConfig{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		RequestTimeout:    10 * time.Second,
+		DrainDelay:        5 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+	}
+	if addr := os.Getenv("TODO_HTTP_ADDR"); addr != "" {
+		cfg.Addr = addr
+	}
+	cfg.TrustedProxies = splitList(os.Getenv("TODO_TRUSTED_PROXIES"))
+	for env, field := range map[string]*time.Duration{
+		"TODO_HTTP_READ_HEADER_TIMEOUT": &cfg.ReadHeaderTimeout,
+		"TODO_HTTP_READ_TIMEOUT":        &cfg.ReadTimeout,
+		"TODO_HTTP_WRITE_TIMEOUT":       &cfg.WriteTimeout,
+		"TODO_HTTP_IDLE_TIMEOUT":        &cfg.IdleTimeout,
+		"TODO_REQUEST_TIMEOUT":          &cfg.RequestTimeout,
+		"TODO_SHUTDOWN_DRAIN_DELAY":     &cfg.DrainDelay,
+		"TODO_SHUTDOWN_TIMEOUT":         &cfg.ShutdownTimeout,
+	} {
+		s := os.Getenv(env)
+		if s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			slog.Warn("invalid "+env+", using default", "value", s, "default", field.String())
+			continue
+		}
+		*field = d
+	}
+	return cfg
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLSConfig HTTPS 配置，CertFile 为空时使用 HTTP
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string        // 校验客户端证书的 CA，为空时不使用双向 TLS
+	ClientAuth     string        // optional：客户端证书可选，提供时必须有效；require：必须提供有效的客户端证书
+	ReloadInterval time.Duration // 检查证书文件变化的间隔
+}
+
+// Enabled 是否启用 HTTPS
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// GetTLSConfig 从环境变量读取 HTTPS 配置
+// TODO_TLS_CERT、TODO_TLS_KEY 为证书和私钥（PEM）的路径；TODO_TLS_CLIENT_CA 为客户端证书的 CA，
+// TODO_TLS_CLIENT_AUTH=optional|require（默认 require）；TODO_TLS_RELOAD_INTERVAL 默认 10s
+func GetTLSConfig() *TLSConfig {
+	cfg := &TLSConfig{
+		CertFile:       os.Getenv("TODO_TLS_CERT"),
+		KeyFile:        os.Getenv("TODO_TLS_KEY"),
+		ClientCAFile:   os.Getenv("TODO_TLS_CLIENT_CA"),
+		ClientAuth:     "require",
+		ReloadInterval: 10 * time.Second,
+	}
+	switch s := strings.ToLower(os.Getenv("TODO_TLS_CLIENT_AUTH")); s {
+	case "":
+	case "optional", "require":
+		cfg.ClientAuth = s
+	default:
+		slog.Warn("invalid TODO_TLS_CLIENT_AUTH, using require", "value", s)
+	}
+	if s := os.Getenv("TODO_TLS_RELOAD_INTERVAL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			slog.Warn("invalid TODO_TLS_RELOAD_INTERVAL", "value", s, "default", cfg.ReloadInterval)
+		} else {
+			cfg.ReloadInterval = d
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Exporter    string  // off、otlp、stdout 或 file
+	File        string  // Exporter 为 file 时写入的文件
+	SampleRatio float64 // 根 span 的采样比例，上游已决定采样的请求沿用上游的决定
+}
+
+// GetTracingConfig 从环境变量读取链路追踪配置
+// TODO_TRACE_EXPORTER=off|otlp|stdout|file（默认 off），TODO_TRACE_FILE=traces.jsonl，TODO_TRACE_SAMPLE_RATIO=0.1（默认 1）；
+// otlp 的地址等使用 OpenTelemetry 的标准环境变量，如 OTEL_EXPORTER_OTLP_ENDPOINT、OTEL_EXPORTER_OTLP_INSECURE
+func GetTracingConfig() *TracingConfig {
+	cfg := &TracingConfig{Exporter: "off", File: os.Getenv("TODO_TRACE_FILE"), SampleRatio: 1}
+	switch exporter := strings.ToLower(os.Getenv("TODO_TRACE_EXPORTER")); exporter {
+	case "":
+	case "off", "otlp", "stdout", "file":
+		cfg.Exporter = exporter
+	default:
+		slog.Warn("invalid TODO_TRACE_EXPORTER, tracing disabled", "value", exporter)
+	}
+	if cfg.Exporter == "file" && cfg.File == "" {
+		cfg.File = "traces.jsonl"
+	}
+	if s := os.Getenv("TODO_TRACE_SAMPLE_RATIO"); s != "" {
+		ratio, err := strconv.ParseFloat(s, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			slog.Warn("invalid TODO_TRACE_SAMPLE_RATIO, using 1", "value", s)
+		} else {
+			cfg.SampleRatio = ratio
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,14 @@
+package config
+
+import "os"
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	AllowedTargets []string // 允许投递到的内网目标：主机名、IP 或 CIDR，如 hooks.internal、10.0.0.0/8
+}
+
+// GetWebhookConfig 从环境变量读取 Webhook 投递配置
+// TODO_WEBHOOK_ALLOWED_TARGETS 为逗号分隔的主机名、IP 或 CIDR，默认为空：不允许投递到回环、链路本地和内网地址
+func GetWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{AllowedTargets: splitList(os.Getenv("TODO_WEBHOOK_ALLOWED_TARGETS"))}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	authConfig  = config.GetAuthConfig()
+	authService = services.NewAuthService(authConfig.SessionTTL)
+)
+
+// LoginInput 登录请求
+type LoginInput struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Mode     string `json:"mode" binding:"omitempty,oneof=cookie token"` // cookie（默认）：浏览器使用会话 Cookie；token：返回 Bearer 令牌
+}
+
+// LoginResponse 登录结果，Token 只在 token 模式下返回，CSRFToken 只在 cookie 模式下返回
+type LoginResponse struct {
+	User      *models.User `json:"user"`
+	Token     string       `json:"token,omitempty"`
+	CSRFToken string       `json:"csrf_token,omitempty"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// Login 登录
+// cookie 模式设置 HttpOnly 的会话 Cookie 和前端可读的 CSRF Cookie，之后的修改请求需要在 X-CSRF-Token 中带上 CSRF 令牌；
+// token 模式返回令牌，客户端放在 Authorization: Bearer 中
+// POST /api/auth/login
+func Login(c *gin.Context) {
+	var input LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	result, err := authService.Login(c.Request.Context(), input.Username, input.Password)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	response := LoginResponse{User: result.User, ExpiresAt: result.ExpiresAt}
+	if input.Mode == "token" {
+		response.Token = result.Token
+	} else {
+		setSessionCookies(c, result.Token, result.CSRFToken, result.ExpiresAt)
+		response.CSRFToken = result.CSRFToken
+	}
+	utils.Success(c, response)
+}
+
+// Logout 退出登录：删除会话并清除 Cookie，未登录时也返回成功
+// POST /api/auth/logout
+func Logout(c *gin.Context) {
+	if token := c.GetString(middleware.SessionTokenKey); token != "" {
+		if err := authService.Logout(c.Request.Context(), token); err != nil {
+			utils.HandleServiceError(c, err)
+			return
+		}
+	}
+	setSessionCookies(c, "", "", time.Time{})
+	utils.SuccessWithMessage(c, "Logged out", nil)
+}
+
+// CurrentUser 当前登录的用户
+// GET /api/auth/me
+func CurrentUser(c *gin.Context) {
+	utils.Success(c, c.MustGet(middleware.UserKey))
+}
+
+// setSessionCookies 设置会话 Cookie 和 CSRF Cookie，token 为空时清除
+func setSessionCookies(c *gin.Context, token, csrfToken string, expiresAt time.Time) {
+	maxAge := int(time.Until(expiresAt).Seconds())
+	if token == "" {
+		maxAge = -1
+	}
+	for _, cookie := range []*http.Cookie{
+		{Name: authConfig.CookieName, Value: token, HttpOnly: true},
+		{Name: middleware.CSRFCookie, Value: csrfToken},
+	} {
+		cookie.Path = "/"
+		cookie.MaxAge = maxAge
+		cookie.Secure = authConfig.CookieSecure
+		cookie.SameSite = authConfig.SameSite
+		http.SetCookie(c.Writer, cookie)
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"backend/collab"
+	"backend/config"
+	"backend/middleware"
+	"backend/models"
+	"backend/utils"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// corsPolicy 跨域策略，与 CORS 中间件使用相同的配置
+var corsPolicy = middleware.NewCORSPolicy(config.GetCORSConfig())
+
+// upgrader WebSocket 升级配置
+// 浏览器的 WebSocket 不受 CORS 限制，必须在这里校验来源，否则任何网站都能带着用户的 Cookie 建立连接：
+// 允许同源和 CORS 白名单中的来源；没有 Origin 的非浏览器客户端不受限制
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || corsPolicy.AllowsRequestOrigin(origin, r.Host)
+	},
+}
+
+// maxGuestNameLength 未登录连接自报名字的最大长度（字符数）
+const maxGuestNameLength = 32
+
+// Collaborate WebSocket 协作通道：订阅变更、广播编辑状态、提交修改
+// GET /api/ws
+// 编辑状态中显示的用户取自登录身份，客户端不能指定；
+// 未登录时可以用 ?name= 自报名字，显示为 guest:名字，否则由 Hub 显示为 anonymous-连接 ID
+func Collaborate(c *gin.Context) {
+	user := guestName(c.Query("name"))
+	if u, ok := c.Get(middleware.UserKey); ok {
+		user = u.(*models.User).Username
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写回了错误响应
+		slog.WarnContext(c.Request.Context(), "websocket upgrade failed", "component", "collab", "error", err)
+		return
+	}
+
+	collab.DefaultHub.Serve(c.Request.Context(), conn, user, utils.Language(c))
+}
+
+// guestName 未登录连接自报的名字，加上 guest: 前缀
+// 用户名不能包含冒号，所以自报的名字不会被当成登录用户；名字为空、过长或含有控制字符时忽略
+func guestName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxGuestNameLength ||
+		strings.IndexFunc(name, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return ""
+	}
+	return "guest:" + name
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	customerrors "backend/errors"
+	"backend/events"
+	"backend/lifecycle"
+	"backend/middleware"
+	"backend/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval SSE 心跳间隔，防止代理因连接空闲而断开
+const heartbeatInterval = 15 * time.Second
+
+// StreamEvents 以 Server-Sent Events 推送数据变更
+// GET /api/events?category=work&todo_id=1&owner=me
+// owner=me 只推送当前登录用户创建的待办事项的变更，未登录时返回 401；
+// 断线重连时浏览器会自动带上 Last-Event-ID 请求头，也可以用 last_event_id 查询参数指定
+func StreamEvents(c *gin.Context) {
+	broker := events.DefaultBroker
+
+	// 筛选条件
+	category := c.Query("category")
+	var ownerID uint
+	switch owner := c.Query("owner"); owner {
+	case "", "all":
+	case "me":
+		id, ok := c.Get(middleware.UserIDKey)
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			utils.Fail(c, customerrors.ErrUnauthenticated)
+			return
+		}
+		ownerID = id.(uint)
+	default:
+		utils.InvalidParam(c, "owner")
+		return
+	}
+	var todoID uint
+	if idStr := c.Query("todo_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			utils.InvalidParam(c, "todo_id")
+			return
+		}
+		todoID = uint(id)
+	}
+	filter := func(e events.Event) bool {
+		if category != "" && category != "all" && e.Category != category {
+			return false
+		}
+		if todoID != 0 && e.TodoID != todoID {
+			return false
+		}
+		if ownerID != 0 && e.OwnerID != ownerID {
+			return false
+		}
+		return true
+	}
+
+	// 断点续传
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("last_event_id")
+	}
+	var lastID uint64
+	resumable := true
+	if cursor != "" {
+		lastID, resumable = broker.ParseCursor(cursor)
+	}
+
+	sub, backlog, complete := broker.Subscribe(lastID, filter)
+	defer sub.Close()
+
+	// 长连接不受 http.Server 的 WriteTimeout 限制，由心跳检测断线
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Status(http.StatusOK)
+
+	// 无法补齐断线期间的事件时，通知客户端重新拉取全量数据
+	if !resumable || !complete {
+		c.Render(-1, sse.Event{
+			Event: "reset",
+			Id:    broker.Cursor(broker.LastID()),
+			Data:  gin.H{"reason": "event log no longer covers the requested position"},
+		})
+	}
+	for _, e := range backlog {
+		writeEvent(c, broker, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				// 被事件中心断开（消费过慢），客户端会带着 Last-Event-ID 重连
+				return false
+			}
+			writeEvent(c, broker, e)
+			return true
+		case <-heartbeat.C:
+			_, _ = w.Write([]byte(": keep-alive\n\n"))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		case <-lifecycle.ShuttingDown():
+			// 服务正在退出，客户端会带着 Last-Event-ID 重连到其他实例
+			return false
+		}
+	})
+}
+
+// writeEvent 写出一条 SSE 消息
+func writeEvent(c *gin.Context, broker *events.Broker, e events.Event) {
+	c.Render(-1, sse.Event{
+		Event: e.Type,
+		Id:    broker.Cursor(e.ID),
+		Data:  e,
+	})
+}
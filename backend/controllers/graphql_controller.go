@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"backend/gql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// graphqlHandler 与 REST 接口共用 todoService
+var graphqlHandler = gql.NewHandler(todoService)
+
+// GraphQL 执行 GraphQL 查询或修改
+// POST /graphql
+func GraphQL(c *gin.Context) {
+	graphqlHandler.ServeHTTP(c.Writer, c.Request)
+}
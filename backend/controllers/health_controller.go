@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var healthService = services.NewHealthService(services.DefaultOutboxDispatcher, services.DefaultWebhookDispatcher)
+
+// HealthStatus 存活检查的响应
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
+// Liveness 存活检查，只表示进程能处理请求，不检查依赖；失败时编排系统应重启实例
+// GET /healthz
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// Readiness 就绪检查：数据库连通性、迁移版本和后台投递协程，每项带耗时和错误
+// 数据库不可用、迁移版本落后或服务开始退出时返回 503，负载均衡据此停止转发新请求
+// GET /readyz
+func Readiness(c *gin.Context) {
+	report := healthService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"backend/i18n"
+	"backend/utils"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemPage 错误类型说明页面，problem+json 中的 type 指向这里
+var problemPage = template.Must(template.New("problem").Parse(`<!doctype html>
+<html lang="{{.Lang}}">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p><code>{{.Code}}</code></p>
+</body>
+</html>
+`))
+
+// ProblemTypeDoc 错误类型说明，按请求的语言显示错误码的概括；不存在的错误码返回 404
+// GET /problems/:type
+func ProblemTypeDoc(c *gin.Context) {
+	code, ok := utils.ProblemCode(c.Param("type"))
+	lang := utils.Language(c)
+	title, found := i18n.Title(lang, code)
+	if !ok || !found {
+		c.String(http.StatusNotFound, "404 page not found")
+		return
+	}
+
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	_ = problemPage.Execute(c.Writer, map[string]string{"Lang": lang, "Title": title, "Code": code})
+}
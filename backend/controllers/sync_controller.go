@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"backend/i18n"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var syncService = services.NewSyncService(todoService)
+
+// PullChanges 拉取增量变更
+// GET /api/sync?since=<token>&limit=500
+// since 为空时返回全量快照；返回的 sync_token 用于下次拉取，has_more 为 true 时应继续拉取
+func PullChanges(c *gin.Context) {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			utils.InvalidParam(c, "limit")
+			return
+		}
+	}
+
+	result, err := syncService.Pull(c.Request.Context(), c.Query("since"), limit)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// PushChanges 上传离线修改
+// POST /api/sync
+// 每条修改携带 base_version，逐条返回 accepted / conflict / not_found / rejected
+func PushChanges(c *gin.Context) {
+	var input models.SyncPushInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	result, err := syncService.Push(c.Request.Context(), &input)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	// 逐条结果的错误信息按请求的语言返回
+	lang := utils.Language(c)
+	for i := range result.Results {
+		if r := &result.Results[i]; r.Err != nil {
+			r.Error = i18n.Detail(lang, r.Err)
+		}
+	}
+
+	utils.Success(c, result)
+}
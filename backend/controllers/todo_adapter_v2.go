@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"strconv"
+	"time"
+)
+
+// v2 接口的请求和响应结构，以及与 Service 层（v1 数据结构）之间的转换
+// 与 v1 的区别：分类使用 ID（category_id）而不是名称；列表分页并包装为 TodoPageV2
+
+// TodoV2 v2 接口返回的待办事项
+type TodoV2 struct {
+	ID          uint      `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CategoryID  uint      `json:"category_id"`
+	Priority    int       `json:"priority"`
+	Completed   bool      `json:"completed"`
+	Version     int       `json:"version"`
+	ChangeSeq   int64     `json:"change_seq"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TodoPageV2 v2 列表接口的分页结构
+type TodoPageV2 struct {
+	Items    []TodoV2 `json:"items"`
+	Total    int64    `json:"total"` // 符合筛选条件的总数
+	Page     int      `json:"page"`
+	PageSize int      `json:"page_size"`
+}
+
+// CreateTodoInputV2 v2 创建待办事项的输入结构，category_id 为空时使用默认分类
+type CreateTodoInputV2 struct {
+	Title       string `json:"title" binding:"required,min=1,max=255"`
+	Description string `json:"description"`
+	CategoryID  uint   `json:"category_id"`
+	Priority    int    `json:"priority" binding:"omitempty,min=0,max=5"`
+}
+
+// UpdateTodoInputV2 v2 编辑待办事项的输入结构
+// 与 v1 不同，priority 可以为 0（v1 的 required 不允许零值）
+type UpdateTodoInputV2 struct {
+	Title       string `json:"title" binding:"required,min=1,max=255"`
+	Description string `json:"description"`
+	CategoryID  uint   `json:"category_id" binding:"required"`
+	Priority    int    `json:"priority" binding:"min=0,max=5"`
+	Version     int    `json:"version" binding:"gte=0"`
+}
+
+// ListTodosQueryV2 v2 列表接口的查询参数
+type ListTodosQueryV2 struct {
+	CategoryID uint   `form:"category_id"`
+	Sort       string `form:"sort"`
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+}
+
+// toTodoV2 将 Service 层返回的待办事项转换为 v2 结构
+func toTodoV2(t *models.Todo) TodoV2 {
+	category, _ := models.CategoryByName(t.Category)
+	return TodoV2{
+		ID:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		CategoryID:  category.ID,
+		Priority:    t.Priority,
+		Completed:   t.Completed,
+		Version:     t.Version,
+		ChangeSeq:   t.ChangeSeq,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// toTodoPageV2 将一页待办事项转换为 v2 分页结构
+func toTodoPageV2(todos []models.Todo, total int64, page, pageSize int) TodoPageV2 {
+	items := make([]TodoV2, len(todos))
+	for i := range todos {
+		items[i] = toTodoV2(&todos[i])
+	}
+	return TodoPageV2{Items: items, Total: total, Page: page, PageSize: pageSize}
+}
+
+// categoryName 将分类 ID 转换为名称；0 表示未指定，返回空字符串
+func categoryName(id uint) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	category, ok := models.CategoryByID(id)
+	if !ok {
+		allowed := make([]string, len(models.Categories))
+		for i, c := range models.Categories {
+			allowed[i] = strconv.FormatUint(uint64(c.ID), 10)
+		}
+		return "", customerrors.ErrInvalidCategoryID(id, allowed)
+	}
+	return category.Name, nil
+}
+
+// toCreateTodoInput 将 v2 创建输入转换为 Service 层的输入
+func (in *CreateTodoInputV2) toCreateTodoInput() (*models.CreateTodoInput, error) {
+	category, err := categoryName(in.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CreateTodoInput{
+		Title:       in.Title,
+		Description: in.Description,
+		Category:    category,
+		Priority:    in.Priority,
+	}, nil
+}
+
+// toUpdateTodoInput 将 v2 编辑输入转换为 Service 层的输入
+func (in *UpdateTodoInputV2) toUpdateTodoInput() (*models.UpdateTodoInput, error) {
+	category, err := categoryName(in.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UpdateTodoInput{
+		Title:       in.Title,
+		Description: in.Description,
+		Category:    category,
+		Priority:    in.Priority,
+		Version:     in.Version,
+	}, nil
+}
@@ -17,12 +17,12 @@ func AddTodo(c *gin.Context) {
 	var input models.CreateTodoInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "Invalid input: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 调用 Service 层创建
-	todo, err := todoService.CreateTodo(&input)
+	todo, err := todoService.CreateTodo(c.Request.Context(), &input)
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
@@ -39,7 +39,7 @@ func GetTodos(c *gin.Context) {
 	sortBy := c.DefaultQuery("sort", "")
 
 	// 调用 Service 层获取列表
-	todos, err := todoService.GetAllTodos(category, sortBy)
+	todos, err := todoService.GetAllTodos(c.Request.Context(), category, sortBy)
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
@@ -55,12 +55,12 @@ func GetTodoByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		utils.BadRequest(c, "Invalid ID format")
+		utils.InvalidParam(c, "id")
 		return
 	}
 
 	// 调用 Service 层查询
-	todo, err := todoService.GetTodoByID(uint(id))
+	todo, err := todoService.GetTodoByID(c.Request.Context(), uint(id))
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
@@ -75,18 +75,18 @@ func UpdateTodo(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		utils.BadRequest(c, "Invalid ID format")
+		utils.InvalidParam(c, "id")
 		return
 	}
 
 	var input models.UpdateTodoInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "Invalid input: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 调用 Service 层更新
-	todo, err := todoService.UpdateTodo(uint(id), &input)
+	todo, err := todoService.UpdateTodo(c.Request.Context(), uint(id), &input)
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
@@ -101,18 +101,18 @@ func UpdateTodoStatus(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		utils.BadRequest(c, "Invalid ID format")
+		utils.InvalidParam(c, "id")
 		return
 	}
 
 	var input models.UpdateStatusInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		utils.BadRequest(c, "Invalid input: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	// 调用 Service 层更新状态
-	todo, err := todoService.UpdateTodoStatus(uint(id), &input)
+	todo, err := todoService.UpdateTodoStatus(c.Request.Context(), uint(id), &input)
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
@@ -128,12 +128,12 @@ func DeleteTodo(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		utils.BadRequest(c, "Invalid ID format")
+		utils.InvalidParam(c, "id")
 		return
 	}
 
 	// 调用 Service 层删除
-	err = todoService.DeleteTodo(uint(id))
+	err = todoService.DeleteTodo(c.Request.Context(), uint(id))
 	if err != nil {
 		utils.HandleServiceError(c, err)
 		return
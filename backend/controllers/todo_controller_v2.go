@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// v2 接口与 v1 共用 todoService，只在这里转换请求和响应的结构
+
+// GetCategoriesV2 获取所有分类
+// GET /api/v2/categories
+func GetCategoriesV2(c *gin.Context) {
+	utils.Success(c, models.Categories)
+}
+
+// AddTodoV2 添加待办事项
+// POST /api/v2/todos
+func AddTodoV2(c *gin.Context) {
+	var input CreateTodoInputV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	createInput, err := input.toCreateTodoInput()
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	todo, err := todoService.CreateTodo(c.Request.Context(), createInput)
+	if err != nil {
+		handleServiceErrorV2(c, err)
+		return
+	}
+
+	utils.Success(c, toTodoV2(todo))
+}
+
+// GetTodosV2 分页获取待办事项列表
+// GET /api/v2/todos?category_id=1&sort=priority&page=1&page_size=20
+func GetTodosV2(c *gin.Context) {
+	query := ListTodosQueryV2{Page: 1, PageSize: services.DefaultPageSize}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	category, err := categoryName(query.CategoryID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	todos, total, err := todoService.ListTodos(c.Request.Context(), category, query.Sort, query.Page, query.PageSize)
+	if err != nil {
+		handleServiceErrorV2(c, err)
+		return
+	}
+
+	utils.Success(c, toTodoPageV2(todos, total, query.Page, query.PageSize))
+}
+
+// GetTodoByIDV2 根据 ID 获取待办事项
+// GET /api/v2/todos/:id
+func GetTodoByIDV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	todo, err := todoService.GetTodoByID(c.Request.Context(), uint(id))
+	if err != nil {
+		handleServiceErrorV2(c, err)
+		return
+	}
+
+	utils.Success(c, toTodoV2(todo))
+}
+
+// UpdateTodoV2 更新待办事项（编辑）
+// PUT /api/v2/todos/:id
+func UpdateTodoV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	var input UpdateTodoInputV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	updateInput, err := input.toUpdateTodoInput()
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	todo, err := todoService.UpdateTodo(c.Request.Context(), uint(id), updateInput)
+	if err != nil {
+		handleServiceErrorV2(c, err)
+		return
+	}
+
+	utils.Success(c, toTodoV2(todo))
+}
+
+// UpdateTodoStatusV2 更新待办事项状态（完成/未完成），请求体与 v1 相同
+// PUT /api/v2/todos/:id/status
+func UpdateTodoStatusV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	var input models.UpdateStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	todo, err := todoService.UpdateTodoStatus(c.Request.Context(), uint(id), &input)
+	if err != nil {
+		handleServiceErrorV2(c, err)
+		return
+	}
+
+	utils.Success(c, toTodoV2(todo))
+}
+
+// handleServiceErrorV2 版本冲突时返回的最新数据同样转换为 v2 结构，其余错误与 v1 相同
+func handleServiceErrorV2(c *gin.Context, err error) {
+	var conflictErr *services.VersionConflictError
+	if errors.As(err, &conflictErr) && conflictErr.LatestData != nil {
+		utils.VersionConflictWithData(c, conflictErr, toTodoV2(conflictErr.LatestData))
+		return
+	}
+	utils.HandleServiceError(c, err)
+}
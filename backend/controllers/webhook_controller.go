@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var webhookService = services.NewWebhookService(services.DefaultWebhookDispatcher)
+
+// AddWebhook 创建 Webhook 订阅
+// POST /api/webhooks
+func AddWebhook(c *gin.Context) {
+	var input models.CreateWebhookInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BindError(c, err)
+		return
+	}
+
+	sub, err := webhookService.CreateSubscription(c.Request.Context(), &input)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.Success(c, sub)
+}
+
+// GetWebhooks 获取所有 Webhook 订阅
+// GET /api/webhooks
+func GetWebhooks(c *gin.Context) {
+	subs, err := webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.Success(c, subs)
+}
+
+// GetWebhookByID 获取单个 Webhook 订阅
+// GET /api/webhooks/:id
+func GetWebhookByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	sub, err := webhookService.GetSubscription(c.Request.Context(), uint(id))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.Success(c, sub)
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+// DELETE /api/webhooks/:id
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	if err := webhookService.DeleteSubscription(c.Request.Context(), uint(id)); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Webhook deleted successfully", nil)
+}
+
+// GetWebhookDeliveries 获取某个订阅最近的投递记录
+// GET /api/webhooks/:id/deliveries?status=dead&limit=50
+func GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	listDeliveries(c, uint(id))
+}
+
+// GetAllWebhookDeliveries 获取所有订阅最近的投递记录
+// GET /api/webhooks/deliveries?status=dead&limit=50
+func GetAllWebhookDeliveries(c *gin.Context) {
+	listDeliveries(c, 0)
+}
+
+// listDeliveries 查询投递记录，subscriptionID 为 0 表示全部
+func listDeliveries(c *gin.Context, subscriptionID uint) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		utils.InvalidParam(c, "limit")
+		return
+	}
+
+	deliveries, err := webhookService.ListDeliveries(c.Request.Context(), subscriptionID, c.Query("status"), limit)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.Success(c, deliveries)
+}
+
+// RedeliverWebhook 重新投递（通常用于死信）
+// POST /api/webhooks/deliveries/:id/redeliver
+func RedeliverWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.InvalidParam(c, "id")
+		return
+	}
+
+	if err := webhookService.Redeliver(c.Request.Context(), uint(id)); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Delivery queued for retry", nil)
+}
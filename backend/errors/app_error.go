@@ -0,0 +1,224 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 错误码：稳定的机器可读标识，客户端应根据它而不是 message 判断错误类型
+const (
+	CodeInvalidRequest          = "INVALID_REQUEST"   // 请求参数格式错误（参数绑定失败）
+	CodeValidationFailed        = "VALIDATION_FAILED" // 多个字段校验失败
+	CodeInvalidID               = "INVALID_ID"
+	CodeTitleRequired           = "TITLE_REQUIRED"
+	CodeTitleTooLong            = "TITLE_TOO_LONG"
+	CodeInvalidCategory         = "INVALID_CATEGORY"
+	CodeInvalidPriority         = "INVALID_PRIORITY"
+	CodeInvalidVersion          = "INVALID_VERSION"
+	CodeInvalidSort             = "INVALID_SORT"
+	CodeInvalidPagination       = "INVALID_PAGINATION"
+	CodeInvalidSyncToken        = "INVALID_SYNC_TOKEN"
+	CodeSyncBatchTooLarge       = "SYNC_BATCH_TOO_LARGE"
+	CodeTodoNotFound            = "TODO_NOT_FOUND"
+	CodeVersionConflict         = "VERSION_CONFLICT"
+	CodeWebhookNotFound         = "WEBHOOK_NOT_FOUND"
+	CodeWebhookURLInvalid       = "WEBHOOK_URL_INVALID"
+	CodeWebhookSecretRequired   = "WEBHOOK_SECRET_REQUIRED"
+	CodeWebhookEventsRequired   = "WEBHOOK_EVENTS_REQUIRED"
+	CodeInvalidWebhookEvent     = "INVALID_WEBHOOK_EVENT"
+	CodeWebhookEventUnsupported = "WEBHOOK_EVENT_UNSUPPORTED" // 需求中列出但尚未支持的事件
+	CodeWebhookTargetForbidden  = "WEBHOOK_TARGET_FORBIDDEN"  // 地址指向回环、链路本地或内网
+	CodeInvalidDeliveryStatus   = "INVALID_DELIVERY_STATUS"
+	CodeDeliveryNotRetryable    = "DELIVERY_NOT_RETRYABLE"
+	CodeInvalidUsername         = "INVALID_USERNAME"
+	CodePasswordTooShort        = "PASSWORD_TOO_SHORT"
+	CodeInvalidRole             = "INVALID_ROLE"
+	CodeUserExists              = "USER_EXISTS"
+	CodeInvalidCredentials      = "INVALID_CREDENTIALS" // 用户名或密码错误
+	CodeUnauthenticated         = "UNAUTHENTICATED"     // 未登录，或令牌无效、已过期
+	CodeCSRFCheckFailed         = "CSRF_CHECK_FAILED"   // 来源不允许，或缺少、不匹配 CSRF 令牌
+	CodeTodoLimitReached        = "TODO_LIMIT_REACHED"  // 待办事项数量达到上限
+	CodeRateLimited             = "RATE_LIMITED"        // 请求过于频繁
+	CodeDatabaseError           = "DATABASE_ERROR"
+	CodeRequestCanceled         = "REQUEST_CANCELED" // 客户端断开连接，请求被取消
+	CodeRequestTimeout          = "REQUEST_TIMEOUT"  // 超过请求的处理时限
+	CodeInternal                = "INTERNAL_ERROR"
+)
+
+// AppError 带错误码的业务错误
+// Code 是稳定的错误码，Status 是对应的 HTTP 状态码，Title 是这类错误的概括（不随具体参数变化），
+// Message 是本次错误的具体描述，Details 是附加信息（如出错的 ID、参数值），Fields 是字段级校验错误，Err 是原始错误
+type AppError struct {
+	Code    string
+	Status  int
+	Title   string
+	Message string
+	Details map[string]interface{}
+	Fields  []FieldError
+	Err     error
+
+	// origin 派生出这个错误的哨兵错误，WithDetails 等方法返回的副本都指向它，用于 errors.Is 判断
+	origin *AppError
+}
+
+// FieldError 字段级校验错误，Field 使用 JSON 字段名，便于前端定位到表单项
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`            // 未通过的规则，如 required、max、oneof
+	Param   string `json:"param,omitempty"` // 规则参数，如 max 的 255
+	Message string `json:"message"`
+}
+
+// New 创建错误，message 同时作为 Title
+func New(code string, status int, message string) *AppError {
+	return &AppError{Code: code, Status: status, Title: message, Message: message}
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 返回原始错误，用于 errors.Is / errors.As 沿错误链查找
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is 比较是否派生自同一个哨兵错误：带了 Details 的副本仍然满足 errors.Is(err, ErrTodoNotFound)，
+// 错误码相同的不同哨兵错误（如 ErrDatabaseConnection 和 ErrDatabaseInit）互不相等，只比较错误码时使用 CodeOf
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.root() == e.root()
+}
+
+func (e *AppError) root() *AppError {
+	if e.origin != nil {
+		return e.origin
+	}
+	return e
+}
+
+// clone 返回浅拷贝，副本与原错误派生自同一个哨兵错误
+func (e *AppError) clone() *AppError {
+	copied := *e
+	copied.origin = e.root()
+	return &copied
+}
+
+// WithDetails 返回附加了一条信息的副本，不修改原错误
+func (e *AppError) WithDetails(key string, value interface{}) *AppError {
+	copied := e.clone()
+	copied.Details = make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		copied.Details[k] = v
+	}
+	copied.Details[key] = value
+	return copied
+}
+
+// WithMessage 返回替换了提示消息的副本
+func (e *AppError) WithMessage(format string, args ...interface{}) *AppError {
+	copied := e.clone()
+	copied.Message = fmt.Sprintf(format, args...)
+	return copied
+}
+
+// WithField 返回附加了一条字段校验错误的副本，message 为空时使用错误本身的提示消息
+func (e *AppError) WithField(field, rule, param, message string) *AppError {
+	if message == "" {
+		message = e.Message
+	}
+	copied := e.clone()
+	copied.Fields = append(append([]FieldError(nil), e.Fields...), FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: message,
+	})
+	return copied
+}
+
+// Wrap 返回包装了原始错误的副本
+func (e *AppError) Wrap(err error) *AppError {
+	copied := e.clone()
+	copied.Err = err
+	return copied
+}
+
+// Validation 合并多个校验错误：只有一个时原样返回（保留具体错误码），多个时合并为 VALIDATION_FAILED，
+// 所有字段错误都放在 Fields 中，前端可以一次标出所有出错的表单项
+func Validation(errs ...*AppError) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	merged := ErrValidationFailed
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+		for _, f := range e.Fields {
+			merged = merged.WithField(f.Field, f.Rule, f.Param, f.Message)
+		}
+	}
+	return merged.WithMessage("%s", strings.Join(messages, "; "))
+}
+
+// AsAppError 从错误链中取出 AppError，没有时将其视为内部错误（上下文取消、超时除外）
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if canceled := contextError(err); canceled != nil {
+		return canceled
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// contextError 上下文被取消或超时导致的错误，转换为 REQUEST_CANCELED 或 REQUEST_TIMEOUT，其他错误返回 nil
+func contextError(err error) *AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrRequestCanceled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRequestTimeout.Wrap(err)
+	}
+	return nil
+}
+
+// FromContext 请求的上下文已经结束时，把处理中得到的错误归为取消或超时：
+// 数据库驱动在查询被中断时返回的不一定是 context 包的错误（如 invalid connection），只看错误本身会被当作 500
+func FromContext(ctx context.Context, err error) error {
+	if ctx == nil || ctx.Err() == nil {
+		return err
+	}
+	if appErr := AsAppError(err); appErr.Status < http.StatusInternalServerError {
+		return err
+	}
+	return contextError(ctx.Err()).Wrap(err)
+}
+
+// CodeOf 获取错误码，没有错误码的错误视为内部错误
+func CodeOf(err error) string {
+	return AsAppError(err).Code
+}
+
+// wrap 为操作失败的错误加上说明；错误链中已有 AppError 时保留它的错误码，否则视为数据库错误
+func wrap(message string, err error) error {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	if canceled := contextError(err); canceled != nil {
+		return canceled.Wrap(fmt.Errorf("%s: %w", message, err))
+	}
+	return &AppError{Code: CodeDatabaseError, Status: http.StatusInternalServerError, Title: message, Message: message, Err: err}
+}
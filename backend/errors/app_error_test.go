@@ -0,0 +1,35 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestAppErrorIs 测试 errors.Is 按哨兵错误判断，而不是按错误码
+func TestAppErrorIs(t *testing.T) {
+	t.Run("派生的副本与原哨兵错误相等", func(t *testing.T) {
+		err := fmt.Errorf("get todo: %w", ErrTodoNotFound.WithDetails("id", 1).WithMessage("todo %d not found", 1))
+		if !errors.Is(err, ErrTodoNotFound) {
+			t.Errorf("副本应该满足 errors.Is(err, ErrTodoNotFound)")
+		}
+		if !errors.Is(ErrTitleRequired.Wrap(errors.New("empty")), ErrTitleRequired) {
+			t.Errorf("Wrap 后应该仍然满足 errors.Is")
+		}
+		t.Logf("✅ 副本与哨兵错误相等")
+	})
+
+	t.Run("错误码相同的不同哨兵错误不相等", func(t *testing.T) {
+		err := ErrDatabaseConnection.Wrap(errors.New("dial tcp: refused"))
+		if errors.Is(err, ErrDatabaseInit) {
+			t.Errorf("连接失败不应该满足 errors.Is(err, ErrDatabaseInit)")
+		}
+		if errors.Is(ErrInvalidPage, ErrInvalidPageSize) {
+			t.Errorf("ErrInvalidPage 不应该等于 ErrInvalidPageSize")
+		}
+		if CodeOf(err) != CodeOf(ErrDatabaseInit) {
+			t.Errorf("错误码应该相同")
+		}
+		t.Logf("✅ 只比较错误码时使用 CodeOf: %s", CodeOf(err))
+	})
+}
@@ -1,63 +1,192 @@
 package errors
 
 import (
-	"errors"
-	"fmt"
+	"net/http"
+	"strings"
 )
 
 // 验证错误
 var (
-	ErrInvalidID       = errors.New("invalid id: id must be greater than 0")
-	ErrTitleRequired   = errors.New("title is required and cannot be empty")
-	ErrTitleTooLong    = errors.New("title cannot exceed 255 characters")
-	ErrInvalidPriority = errors.New("priority must be between 0 and 5")
-	ErrInvalidVersion  = errors.New("invalid version: version must be non-negative")
+	ErrInvalidRequest    = New(CodeInvalidRequest, http.StatusBadRequest, "invalid input")
+	ErrValidationFailed  = New(CodeValidationFailed, http.StatusBadRequest, "validation failed")
+	ErrInvalidID         = New(CodeInvalidID, http.StatusBadRequest, "invalid id: id must be greater than 0")
+	ErrTitleRequired     = New(CodeTitleRequired, http.StatusBadRequest, "title is required and cannot be empty").WithField("title", "required", "", "")
+	ErrTitleTooLong      = New(CodeTitleTooLong, http.StatusBadRequest, "title cannot exceed 255 characters").WithField("title", "max", "255", "")
+	ErrInvalidPriority   = New(CodeInvalidPriority, http.StatusBadRequest, "priority must be between 0 and 5").WithField("priority", "range", "0,5", "")
+	ErrInvalidVersion    = New(CodeInvalidVersion, http.StatusBadRequest, "invalid version: version must be non-negative").WithField("version", "min", "0", "")
+	ErrInvalidPage       = New(CodeInvalidPagination, http.StatusBadRequest, "invalid page: page must be at least 1").WithField("page", "min", "1", "")
+	ErrInvalidPageSize   = New(CodeInvalidPagination, http.StatusBadRequest, "invalid page_size: page_size must be between 1 and 100").WithField("page_size", "range", "1,100", "")
+	ErrInvalidSyncToken  = New(CodeInvalidSyncToken, http.StatusBadRequest, "invalid sync token")
+	ErrSyncBatchTooLarge = New(CodeSyncBatchTooLarge, http.StatusBadRequest, "invalid sync batch: too many changes in one request")
 )
 
 // 业务错误
 var (
-	ErrTodoNotFound    = errors.New("todo not found")
-	ErrVersionConflict = errors.New("version conflict: data has been modified by another user")
+	ErrTodoNotFound    = New(CodeTodoNotFound, http.StatusNotFound, "todo not found")
+	ErrVersionConflict = New(CodeVersionConflict, http.StatusConflict, "version conflict: data has been modified by another user")
+)
+
+// Webhook 错误
+var (
+	ErrWebhookNotFound        = New(CodeWebhookNotFound, http.StatusNotFound, "webhook not found")
+	ErrWebhookURLInvalid      = New(CodeWebhookURLInvalid, http.StatusBadRequest, "invalid webhook url: must be an absolute http or https url").WithField("url", "url", "", "")
+	ErrWebhookSecretRequired  = New(CodeWebhookSecretRequired, http.StatusBadRequest, "webhook secret is required").WithField("secret", "required", "", "")
+	ErrWebhookEventsRequired  = New(CodeWebhookEventsRequired, http.StatusBadRequest, "webhook events are required").WithField("events", "required", "", "")
+	ErrWebhookTargetForbidden = New(CodeWebhookTargetForbidden, http.StatusBadRequest, "webhook url must not point to a loopback, link-local or private address").WithField("url", "public", "", "")
+	ErrDeliveryNotRetryable   = New(CodeDeliveryNotRetryable, http.StatusConflict, "delivery not found or already pending")
+)
+
+// 用户错误
+var (
+	ErrInvalidUsername  = New(CodeInvalidUsername, http.StatusBadRequest, "invalid username: 3-64 characters of letters, digits, '.', '_' or '-'").WithField("username", "format", "", "")
+	ErrPasswordTooShort = New(CodePasswordTooShort, http.StatusBadRequest, "password must be at least 8 characters").WithField("password", "min", "8", "")
+	ErrUserExists       = New(CodeUserExists, http.StatusConflict, "username already exists")
+)
+
+// 认证与 CSRF 错误
+var (
+	ErrInvalidCredentials = New(CodeInvalidCredentials, http.StatusUnauthorized, "invalid username or password")
+	ErrUnauthenticated    = New(CodeUnauthenticated, http.StatusUnauthorized, "authentication required")
+	ErrCSRFCheckFailed    = New(CodeCSRFCheckFailed, http.StatusForbidden, "csrf check failed")
+)
+
+// 限额：请求频率和存储的待办事项数量
+var (
+	ErrTodoLimitReached = New(CodeTodoLimitReached, http.StatusForbidden, "todo limit reached: delete some todos before creating new ones")
+	ErrRateLimited      = New(CodeRateLimited, http.StatusTooManyRequests, "too many requests")
 )
 
 // 数据库错误
 var (
-	ErrDatabaseConnection = errors.New("failed to connect to database")
-	ErrDatabaseInit       = errors.New("failed to initialize database")
+	ErrDatabaseConnection = New(CodeDatabaseError, http.StatusInternalServerError, "failed to connect to database")
+	ErrDatabaseInit       = New(CodeDatabaseError, http.StatusInternalServerError, "failed to initialize database")
+)
+
+// StatusClientClosedRequest 客户端在响应之前断开连接（nginx 的约定，非标准状态码），
+// 响应不会被客户端收到，主要用于访问日志和指标区分于服务端错误
+const StatusClientClosedRequest = 499
+
+// 请求取消与超时：客户端断开或超过处理时限后，进行中的数据库查询随上下文中断
+var (
+	ErrRequestCanceled = New(CodeRequestCanceled, StatusClientClosedRequest, "request canceled by client")
+	ErrRequestTimeout  = New(CodeRequestTimeout, http.StatusGatewayTimeout, "request timed out")
+)
+
+// ErrInternal 未归类的内部错误
+var ErrInternal = New(CodeInternal, http.StatusInternalServerError, "internal server error")
+
+// 允许的取值，同时用于错误提示和 Details
+var (
+	validCategories       = []string{"work", "study", "life"}
+	validSorts            = []string{"priority", "created_at"}
+	validWebhookEvents    = []string{"created", "completed", "deleted"}
+	validDeliveryStatuses = []string{"pending", "succeeded", "dead"}
+	validRoles            = []string{"user", "admin"}
 )
 
 // ErrInvalidCategory 无效分类错误
-func ErrInvalidCategory(category string) error {
-	return fmt.Errorf("invalid category: %s, must be one of: work, study, life", category)
+func ErrInvalidCategory(category string) *AppError {
+	return New(CodeInvalidCategory, http.StatusBadRequest, "invalid category").
+		WithMessage("invalid category: %s, must be one of: %s", category, strings.Join(validCategories, ", ")).
+		WithDetails("category", category).
+		WithDetails("allowed", validCategories).
+		WithField("category", "oneof", strings.Join(validCategories, " "), "")
+}
+
+// ErrInvalidCategoryID 无效分类 ID 错误（v2 接口）
+func ErrInvalidCategoryID(id uint, allowed []string) *AppError {
+	return New(CodeInvalidCategory, http.StatusBadRequest, "invalid category").
+		WithMessage("invalid category_id: %d, must be one of: %s", id, strings.Join(allowed, ", ")).
+		WithDetails("category", id).
+		WithDetails("allowed", allowed).
+		WithField("category_id", "oneof", strings.Join(allowed, " "), "")
+}
+
+// ErrInvalidWebhookEvent 无效的 Webhook 事件类型
+func ErrInvalidWebhookEvent(event string) *AppError {
+	return New(CodeInvalidWebhookEvent, http.StatusBadRequest, "invalid webhook event").
+		WithMessage("invalid webhook event: %s, must be one of: %s", event, strings.Join(validWebhookEvents, ", ")).
+		WithDetails("event", event).
+		WithDetails("allowed", validWebhookEvents).
+		WithField("events", "oneof", strings.Join(validWebhookEvents, " "), "")
+}
+
+// ErrWebhookEventUnsupported 尚未支持的 Webhook 事件类型，如 overdue：待办事项没有截止日期
+func ErrWebhookEventUnsupported(event string) *AppError {
+	return New(CodeWebhookEventUnsupported, http.StatusBadRequest, "webhook event not supported").
+		WithMessage("webhook event %s is not supported yet: todos have no due date", event).
+		WithDetails("event", event).
+		WithField("events", "unsupported", event, "")
+}
+
+// ErrInvalidDeliveryStatus 无效的投递状态
+func ErrInvalidDeliveryStatus(status string) *AppError {
+	return New(CodeInvalidDeliveryStatus, http.StatusBadRequest, "invalid delivery status").
+		WithMessage("invalid delivery status: %s, must be one of: %s", status, strings.Join(validDeliveryStatuses, ", ")).
+		WithDetails("status", status).
+		WithDetails("allowed", validDeliveryStatuses)
+}
+
+// ErrInvalidRole 无效的用户角色
+func ErrInvalidRole(role string) *AppError {
+	return New(CodeInvalidRole, http.StatusBadRequest, "invalid role").
+		WithMessage("invalid role: %s, must be one of: %s", role, strings.Join(validRoles, ", ")).
+		WithDetails("role", role).
+		WithDetails("allowed", validRoles).
+		WithField("role", "oneof", strings.Join(validRoles, " "), "")
+}
+
+// ErrUserExistsWithName 用户名已存在（带用户名）
+func ErrUserExistsWithName(username string) *AppError {
+	return ErrUserExists.
+		WithMessage("username already exists: %s", username).
+		WithDetails("username", username)
 }
 
 // ErrInvalidSort 无效排序参数错误
-func ErrInvalidSort(sortBy string) error {
-	return fmt.Errorf("invalid sort parameter: %s, must be: priority or created_at", sortBy)
+func ErrInvalidSort(sortBy string) *AppError {
+	return New(CodeInvalidSort, http.StatusBadRequest, "invalid sort parameter").
+		WithMessage("invalid sort parameter: %s, must be: %s", sortBy, strings.Join(validSorts, " or ")).
+		WithDetails("sort_by", sortBy).
+		WithDetails("allowed", validSorts)
 }
 
 // ErrTodoNotFoundWithID 待办事项未找到（带ID）
-func ErrTodoNotFoundWithID(id uint) error {
-	return fmt.Errorf("%w: id=%d", ErrTodoNotFound, id)
+func ErrTodoNotFoundWithID(id uint) *AppError {
+	return ErrTodoNotFound.
+		WithMessage("todo not found: id=%d", id).
+		WithDetails("id", id)
 }
 
 // 包装错误函数（保留原始错误链）
 func WrapCreateError(err error) error {
-	return fmt.Errorf("failed to create todo: %w", err)
+	return wrap("failed to create todo", err)
 }
 
 func WrapUpdateError(err error) error {
-	return fmt.Errorf("failed to update todo: %w", err)
+	return wrap("failed to update todo", err)
 }
 
 func WrapDeleteError(err error) error {
-	return fmt.Errorf("failed to delete todo: %w", err)
+	return wrap("failed to delete todo", err)
 }
 
 func WrapQueryError(err error) error {
-	return fmt.Errorf("failed to query todos: %w", err)
+	return wrap("failed to query todos", err)
 }
 
 func WrapGetError(err error) error {
-	return fmt.Errorf("failed to get todo: %w", err)
+	return wrap("failed to get todo", err)
+}
+
+func WrapCreateUserError(err error) error {
+	return wrap("failed to create user", err)
+}
+
+func WrapQueryUserError(err error) error {
+	return wrap("failed to query users", err)
+}
+
+func WrapSessionError(err error) error {
+	return wrap("failed to access sessions", err)
 }
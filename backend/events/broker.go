@@ -0,0 +1,189 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	TodoCreated = "todo.created"
+	TodoUpdated = "todo.updated"
+	TodoDeleted = "todo.deleted"
+)
+
+// DefaultLogSize 内存事件日志默认保留的条数，超出后最旧的事件被丢弃
+const DefaultLogSize = 1000
+
+// subscriberBuffer 每个订阅者的缓冲区大小，消费过慢的订阅者会被断开
+const subscriberBuffer = 64
+
+// Event 数据变更事件
+type Event struct {
+	ID       uint64      `json:"id"`                 // 事件序号，单调递增，用作 SSE 的 id 字段
+	Type     string      `json:"type"`               // 事件类型，见上方常量
+	TodoID   uint        `json:"todo_id"`            // 相关待办事项 ID
+	Version  int         `json:"version"`            // 变更后的版本号
+	Category string      `json:"category"`           // 变更后的分类，用于订阅方筛选
+	OwnerID  uint        `json:"owner_id,omitempty"` // 创建者的用户 ID，匿名创建的为 0，用于按用户筛选
+	Data     interface{} `json:"data"`               // 变更后的完整数据（删除时为空）
+	Time     time.Time   `json:"timestamp"`          // 事件产生时间
+}
+
+// Filter 订阅筛选条件，返回 true 表示该事件需要推送
+type Filter func(e Event) bool
+
+// Broker 事件中心：维护一个有界的内存事件日志，并向所有订阅者广播新事件
+type Broker struct {
+	mu     sync.Mutex
+	log    []Event // 环形缓冲区
+	size   int
+	start  int // 最旧事件在 log 中的下标
+	count  int
+	nextID uint64
+	epoch  string // 启动标识，区分不同进程实例产生的事件序号
+	subs   map[*Subscription]struct{}
+}
+
+// Subscription 一个订阅者
+type Subscription struct {
+	C      <-chan Event // 新事件通道，被断开时关闭
+	ch     chan Event
+	filter Filter
+	broker *Broker
+}
+
+// DefaultBroker 全局事件中心，发件箱投递协程将领域事件转发到这里
+var DefaultBroker = NewBroker(DefaultLogSize)
+
+// NewBroker 创建事件中心，size 为事件日志保留条数
+func NewBroker(size int) *Broker {
+	if size <= 0 {
+		size = DefaultLogSize
+	}
+	return &Broker{
+		log:    make([]Event, size),
+		size:   size,
+		nextID: 1,
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish 记录并广播一个事件，返回分配了序号的事件
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.ID = b.nextID
+	b.nextID++
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	// 写入环形缓冲区，满了就覆盖最旧的事件
+	if b.count < b.size {
+		b.log[(b.start+b.count)%b.size] = e
+		b.count++
+	} else {
+		b.log[b.start] = e
+		b.start = (b.start + 1) % b.size
+	}
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			// 订阅者消费过慢，直接断开，客户端重连时通过 Last-Event-ID 补齐
+			b.removeLocked(sub)
+		}
+	}
+
+	return e
+}
+
+// Subscribe 订阅事件
+// lastID 为客户端最后收到的事件序号（0 表示不需要补发），
+// 返回需要补发的历史事件；当 lastID 之后的事件已被日志淘汰时 complete 为 false，客户端需要全量刷新
+func (b *Broker) Subscribe(lastID uint64, filter Filter) (sub *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, filter: filter, broker: b}
+	b.subs[sub] = struct{}{}
+
+	complete = true
+	if lastID == 0 {
+		return sub, nil, complete
+	}
+
+	switch {
+	case lastID >= b.nextID:
+		// 序号比当前还大，游标不属于本事件中心
+		complete = false
+	case b.count > 0 && lastID+1 < b.log[b.start].ID:
+		// lastID 之后的第一条事件已经被淘汰
+		complete = false
+	}
+
+	for i := 0; i < b.count; i++ {
+		e := b.log[(b.start+i)%b.size]
+		if e.ID <= lastID {
+			continue
+		}
+		if filter != nil && !filter(e) {
+			continue
+		}
+		backlog = append(backlog, e)
+	}
+
+	return sub, backlog, complete
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.removeLocked(s)
+}
+
+// LastID 返回最近一次发布的事件序号
+func (b *Broker) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID - 1
+}
+
+// Cursor 将事件序号编码为客户端可见的游标（SSE 的 id 字段）
+func (b *Broker) Cursor(id uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(id, 10)
+}
+
+// ParseCursor 解析客户端传回的游标
+// 游标来自其他进程实例（如服务重启前）或格式错误时 ok 为 false
+func (b *Broker) ParseCursor(cursor string) (id uint64, ok bool) {
+	epoch, seq, found := strings.Cut(cursor, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// removeLocked 移除订阅者并关闭其通道，调用方需持有锁
+func (b *Broker) removeLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"testing"
+)
+
+// TestPublishAndSubscribe 测试事件广播
+func TestPublishAndSubscribe(t *testing.T) {
+	t.Run("订阅者收到新事件", func(t *testing.T) {
+		b := NewBroker(10)
+		sub, backlog, complete := b.Subscribe(0, nil)
+		defer sub.Close()
+
+		if len(backlog) != 0 || !complete {
+			t.Errorf("新订阅不应有补发事件，backlog=%d complete=%v", len(backlog), complete)
+		}
+
+		b.Publish(Event{Type: TodoCreated, TodoID: 1})
+		e := <-sub.C
+		if e.ID != 1 || e.Type != TodoCreated {
+			t.Errorf("收到的事件不正确: %+v", e)
+		}
+
+		t.Logf("✅ 收到事件: ID=%d, Type=%s", e.ID, e.Type)
+	})
+
+	t.Run("按条件筛选事件", func(t *testing.T) {
+		b := NewBroker(10)
+		sub, _, _ := b.Subscribe(0, func(e Event) bool { return e.Category == "work" })
+		defer sub.Close()
+
+		b.Publish(Event{Type: TodoCreated, TodoID: 1, Category: "life"})
+		b.Publish(Event{Type: TodoCreated, TodoID: 2, Category: "work"})
+
+		e := <-sub.C
+		if e.TodoID != 2 {
+			t.Errorf("应该只收到 work 分类的事件，实际 TodoID=%d", e.TodoID)
+		}
+
+		t.Log("✅ 筛选生效")
+	})
+}
+
+// TestResume 测试 Last-Event-ID 断点续传
+func TestResume(t *testing.T) {
+	t.Run("补发断线期间的事件", func(t *testing.T) {
+		b := NewBroker(10)
+		for i := 1; i <= 5; i++ {
+			b.Publish(Event{Type: TodoUpdated, TodoID: uint(i)})
+		}
+
+		sub, backlog, complete := b.Subscribe(3, nil)
+		defer sub.Close()
+
+		if !complete {
+			t.Error("日志仍覆盖序号 3 之后的事件，应该可以完整补发")
+		}
+		if len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+			t.Errorf("补发事件不正确: %+v", backlog)
+		}
+
+		t.Logf("✅ 补发 %d 条事件", len(backlog))
+	})
+
+	t.Run("日志已淘汰时要求全量刷新", func(t *testing.T) {
+		b := NewBroker(3)
+		for i := 1; i <= 6; i++ {
+			b.Publish(Event{Type: TodoUpdated, TodoID: uint(i)})
+		}
+
+		sub, backlog, complete := b.Subscribe(1, nil)
+		defer sub.Close()
+
+		if complete {
+			t.Error("序号 2、3 已被淘汰，不应该标记为完整")
+		}
+		if len(backlog) != 3 {
+			t.Errorf("应该补发日志中剩余的 3 条事件，实际 %d 条", len(backlog))
+		}
+
+		t.Log("✅ 正确识别日志缺口")
+	})
+
+	t.Run("其他实例的游标无法解析", func(t *testing.T) {
+		b1 := NewBroker(3)
+		b2 := NewBroker(3)
+		b2.epoch = b1.epoch + "x"
+
+		if _, ok := b2.ParseCursor(b1.Cursor(5)); ok {
+			t.Error("不同实例的游标不应该被接受")
+		}
+		if id, ok := b1.ParseCursor(b1.Cursor(5)); !ok || id != 5 {
+			t.Errorf("游标解析错误: id=%d ok=%v", id, ok)
+		}
+
+		t.Log("✅ 游标校验生效")
+	})
+}
+
+// TestSlowSubscriber 测试消费过慢的订阅者被断开
+func TestSlowSubscriber(t *testing.T) {
+	b := NewBroker(DefaultLogSize)
+	sub, _, _ := b.Subscribe(0, nil)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(Event{Type: TodoUpdated})
+	}
+
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("应该收到 %d 条事件后通道关闭，实际 %d 条", subscriberBuffer, count)
+	}
+
+	// 重复关闭不应 panic
+	sub.Close()
+	t.Log("✅ 慢订阅者被断开")
+}
@@ -0,0 +1,82 @@
+package gql
+
+import (
+	customerrors "backend/errors"
+	"backend/i18n"
+	"backend/services"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// invalidID ID 不是数字，与 REST 接口的 utils.InvalidParam(c, "id") 相同
+var invalidID = customerrors.ErrInvalidRequest.WithMessage("Invalid id format").WithDetails("param", "id")
+
+// resolverError 带错误码的 GraphQL 错误，extensions 中的字段与 REST 的 problem+json 对应：
+// code 即 error_code，fields 即 errors，版本冲突时带有 currentVersion、providedVersion 和 latestData
+type resolverError struct {
+	message    string
+	extensions map[string]interface{}
+	err        error
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions 由 graphql-go 写入错误的 extensions 字段
+func (e *resolverError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+func (e *resolverError) Unwrap() error {
+	return e.err
+}
+
+// toGraphQLError 将 Service 层错误转换为带错误码的 GraphQL 错误，提示信息按请求的语言本地化
+func toGraphQLError(ctx context.Context, err error) error {
+	err = customerrors.FromContext(ctx, err)
+	appErr := customerrors.AsAppError(err)
+	switch {
+	case appErr.Code == customerrors.CodeRequestCanceled, appErr.Code == customerrors.CodeRequestTimeout:
+		slog.WarnContext(ctx, "request interrupted", "component", "graphql", "error", err)
+	case appErr.Status >= http.StatusInternalServerError:
+		// 内部错误不把原始错误返回给客户端，只记录日志
+		slog.ErrorContext(ctx, "internal error", "component", "graphql", "error", err)
+	}
+
+	var params map[string]interface{}
+	var conflictErr *services.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		params = conflictErr.LocalizationParams()
+	}
+	localized := i18n.Error(languageFrom(ctx), appErr, params)
+
+	extensions := map[string]interface{}{"code": appErr.Code}
+	if len(localized.Fields) > 0 {
+		extensions["fields"] = localized.Fields
+	}
+	if len(appErr.Details) > 0 {
+		extensions["details"] = appErr.Details
+	}
+	if conflictErr != nil {
+		extensions["currentVersion"] = conflictErr.CurrentVersion
+		extensions["providedVersion"] = conflictErr.ProvidedVersion
+		extensions["latestData"] = conflictErr.LatestData
+	}
+	return &resolverError{message: localized.Detail, extensions: extensions, err: err}
+}
+
+type languageKey struct{}
+
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+func languageFrom(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey{}).(string); ok {
+		return lang
+	}
+	return i18n.DefaultLang
+}
@@ -0,0 +1,90 @@
+package gql
+
+import (
+	customerrors "backend/errors"
+	"backend/i18n"
+	"backend/services"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+// 查询深度和长度的上限，防止构造过深或过大的查询
+// 请求体的上限在查询长度之外给变量留出空间，超过时不再继续读取
+const (
+	maxDepth       = 10
+	maxQueryLength = 16 * 1024
+	maxBodyBytes   = 64 * 1024
+)
+
+// Handler GraphQL 接口
+type Handler struct {
+	schema *graphql.Schema
+	todos  *services.TodoService
+}
+
+// NewHandler 创建 GraphQL 接口，schema 解析失败（与解析器不匹配）时 panic
+func NewHandler(todos *services.TodoService) *Handler {
+	schema := graphql.MustParseSchema(schemaString, &Resolver{todos: todos},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxQueryLength(maxQueryLength),
+	)
+	return &Handler{schema: schema, todos: todos}
+}
+
+// request GraphQL over HTTP 的请求体
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP 执行一次查询；每个请求使用独立的 historyLoader 和请求的语言
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := withLanguage(r.Context(), i18n.FromRequest(r))
+
+	var req request
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// 请求体无法解析时还没有开始执行，返回 4xx，但响应格式与执行错误相同，客户端按 errors[].extensions.code 处理
+		status, appErr := http.StatusBadRequest, customerrors.ErrInvalidRequest.WithMessage("invalid graphql request: %s", err.Error())
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+			appErr = customerrors.ErrInvalidRequest.WithMessage("graphql request body exceeds %d bytes", tooLarge.Limit).
+				WithDetails("limit", tooLarge.Limit)
+		}
+		writeResponse(w, status, &graphql.Response{Errors: []*gqlerrors.QueryError{queryError(toGraphQLError(ctx, appErr))}})
+		return
+	}
+
+	ctx = withLoader(ctx, newHistoryLoader(h.todos))
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	// 与 GraphQL over HTTP 的约定一致，执行期间的错误也返回 200，错误放在 errors 中
+	writeResponse(w, http.StatusOK, response)
+}
+
+func writeResponse(w http.ResponseWriter, status int, response *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// queryError 把 toGraphQLError 的结果转换为响应中的一条错误，用于执行之前的错误
+func queryError(err error) *gqlerrors.QueryError {
+	queryErr := &gqlerrors.QueryError{Message: err.Error(), ResolverError: err}
+	var resolverErr *resolverError
+	if errors.As(err, &resolverErr) {
+		queryErr.Extensions = resolverErr.extensions
+	}
+	return queryErr
+}
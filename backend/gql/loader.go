@@ -0,0 +1,73 @@
+package gql
+
+import (
+	"backend/models"
+	"backend/services"
+	"context"
+	"slices"
+	"sync"
+)
+
+// historyLoader 按请求批量加载变更历史，避免列表中每条待办事项各查一次（N+1）
+// 列表解析器先用 prime 登记本页的所有 ID，第一次 load 时把已登记但未加载的 ID 一次查出；
+// 之后的 load 直接命中缓存。并发的 load 在锁上等待第一次查询完成
+type historyLoader struct {
+	todos *services.TodoService
+
+	mu      sync.Mutex
+	pending []uint
+	loaded  map[uint][]models.TodoHistory
+	queries int // 实际执行的查询次数，用于测试
+}
+
+func newHistoryLoader(todos *services.TodoService) *historyLoader {
+	return &historyLoader{todos: todos, loaded: make(map[uint][]models.TodoHistory)}
+}
+
+// prime 登记之后可能需要加载的 ID
+func (l *historyLoader) prime(ids ...uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := l.loaded[id]; !ok && !slices.Contains(l.pending, id) {
+			l.pending = append(l.pending, id)
+		}
+	}
+}
+
+// load 获取一个待办事项的历史，未加载时连同所有已登记的 ID 一起查询
+func (l *historyLoader) load(ctx context.Context, id uint) ([]models.TodoHistory, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if events, ok := l.loaded[id]; ok {
+		return events, nil
+	}
+
+	ids := l.pending
+	if !slices.Contains(ids, id) {
+		ids = append(ids, id)
+	}
+	histories, err := l.todos.GetTodoHistories(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	l.queries++
+	l.pending = nil
+	for _, id := range ids {
+		// 没有事件的也记为已加载，避免重复查询
+		l.loaded[id] = histories[id]
+	}
+	return l.loaded[id], nil
+}
+
+type loaderKey struct{}
+
+// withLoader 为每个请求创建新的 loader，缓存只在一次请求内有效
+func withLoader(ctx context.Context, loader *historyLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFrom(ctx context.Context) *historyLoader {
+	return ctx.Value(loaderKey{}).(*historyLoader)
+}
@@ -0,0 +1,192 @@
+package gql
+
+import (
+	"backend/models"
+	"backend/ratelimit"
+	"backend/services"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver 根解析器，Query 和 Mutation 的字段都通过 TodoService 完成，与 REST 接口共用校验、乐观锁和事件
+// /graphql 请求本身按读请求限流，每个 mutation 各按一个写请求计数，一个请求中的多个 mutation 不能绕过限流
+type Resolver struct {
+	todos *services.TodoService
+}
+
+// Todo 根据 ID 获取待办事项
+func (r *Resolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	todo, err := r.todos.GetTodoByID(ctx, id)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return newTodoResolver(ctx, todo), nil
+}
+
+// Todos 分页获取待办事项列表
+func (r *Resolver) Todos(ctx context.Context, args struct {
+	Filter   *struct{ Category *string }
+	Sort     string
+	Page     int32
+	PageSize int32
+}) (*todoPageResolver, error) {
+	category := ""
+	if args.Filter != nil && args.Filter.Category != nil {
+		category = fromEnum(*args.Filter.Category)
+	}
+
+	todos, total, err := r.todos.ListTodos(ctx, category, fromEnum(args.Sort), int(args.Page), int(args.PageSize))
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	// 先登记本页所有待办事项，之后任意一条查询 history 时一次查出整页的历史
+	items := make([]*todoResolver, len(todos))
+	ids := make([]uint, len(todos))
+	for i := range todos {
+		items[i] = newTodoResolver(ctx, &todos[i])
+		ids[i] = todos[i].ID
+	}
+	loaderFrom(ctx).prime(ids...)
+
+	return &todoPageResolver{items: items, total: total, page: args.Page, pageSize: args.PageSize}, nil
+}
+
+// CreateTodoInput 创建待办事项的输入
+type CreateTodoInput struct {
+	Title       string
+	Description *string
+	Category    *string
+	Priority    *int32
+}
+
+// CreateTodo 创建待办事项
+func (r *Resolver) CreateTodo(ctx context.Context, args struct{ Input CreateTodoInput }) (*todoResolver, error) {
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyWrite); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	input := &models.CreateTodoInput{Title: args.Input.Title}
+	if args.Input.Description != nil {
+		input.Description = *args.Input.Description
+	}
+	if args.Input.Category != nil {
+		input.Category = fromEnum(*args.Input.Category)
+	}
+	if args.Input.Priority != nil {
+		input.Priority = int(*args.Input.Priority)
+	}
+
+	todo, err := r.todos.CreateTodo(ctx, input)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return newTodoResolver(ctx, todo), nil
+}
+
+// UpdateTodoInput 编辑待办事项的输入
+type UpdateTodoInput struct {
+	Title       string
+	Description *string
+	Category    string
+	Priority    int32
+	Version     int32
+}
+
+// UpdateTodo 编辑待办事项（带乐观锁）
+func (r *Resolver) UpdateTodo(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input UpdateTodoInput
+}) (*todoResolver, error) {
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyWrite); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	input := &models.UpdateTodoInput{
+		Title:    args.Input.Title,
+		Category: fromEnum(args.Input.Category),
+		Priority: int(args.Input.Priority),
+		Version:  int(args.Input.Version),
+	}
+	if args.Input.Description != nil {
+		input.Description = *args.Input.Description
+	}
+
+	todo, err := r.todos.UpdateTodo(ctx, id, input)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return newTodoResolver(ctx, todo), nil
+}
+
+// SetTodoStatus 更新完成状态（带乐观锁）
+func (r *Resolver) SetTodoStatus(ctx context.Context, args struct {
+	ID        graphql.ID
+	Completed bool
+	Version   int32
+}) (*todoResolver, error) {
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyWrite); err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+
+	todo, err := r.todos.UpdateTodoStatus(ctx, id, &models.UpdateStatusInput{Completed: args.Completed, Version: int(args.Version)})
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	return newTodoResolver(ctx, todo), nil
+}
+
+// DeleteTodo 删除待办事项，传入 version 时按乐观锁删除
+func (r *Resolver) DeleteTodo(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+}) (graphql.ID, error) {
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyWrite); err != nil {
+		return "", toGraphQLError(ctx, err)
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return "", toGraphQLError(ctx, err)
+	}
+
+	if args.Version != nil {
+		err = r.todos.DeleteTodoWithVersion(ctx, id, int(*args.Version))
+	} else {
+		err = r.todos.DeleteTodo(ctx, id)
+	}
+	if err != nil {
+		return "", toGraphQLError(ctx, err)
+	}
+	return args.ID, nil
+}
+
+// parseID 将 GraphQL ID 转换为数据库 ID
+func parseID(id graphql.ID) (uint, error) {
+	n, err := strconv.ParseUint(string(id), 10, 32)
+	if err != nil {
+		return 0, invalidID
+	}
+	return uint(n), nil
+}
+
+// toEnum / fromEnum 枚举值与 Service 层取值之间的转换：WORK <-> work，CREATED_AT <-> created_at
+func toEnum(s string) string {
+	return strings.ToUpper(s)
+}
+
+func fromEnum(s string) string {
+	return strings.ToLower(s)
+}
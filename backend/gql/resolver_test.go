@@ -0,0 +1,178 @@
+package gql
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	todoService *services.TodoService
+	handler     *Handler
+)
+
+func TestMain(m *testing.M) {
+	// 初始化数据库连接
+	if err := config.InitDB(); err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		return
+	}
+	todoService = services.NewTodoService()
+	handler = NewHandler(todoService)
+	m.Run()
+}
+
+// exec 执行查询，返回使用的 loader 以便检查查询次数
+func exec(t *testing.T, query string, variables map[string]interface{}) (map[string]interface{}, []map[string]interface{}, *historyLoader) {
+	t.Helper()
+	loader := newHistoryLoader(todoService)
+	ctx := withLanguage(withLoader(context.Background(), loader), "en")
+	response := handler.schema.Exec(ctx, query, "", variables)
+
+	var data map[string]interface{}
+	if len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, &data); err != nil {
+			t.Fatalf("解析结果失败: %v", err)
+		}
+	}
+	var errs []map[string]interface{}
+	raw, _ := json.Marshal(response.Errors)
+	json.Unmarshal(raw, &errs)
+	return data, errs, loader
+}
+
+// TestTodosQuery 测试列表查询与变更历史的批量加载
+func TestTodosQuery(t *testing.T) {
+	t.Run("一页待办事项的变更历史只查询一次", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			todo, err := todoService.CreateTodo(context.Background(), &models.CreateTodoInput{Title: fmt.Sprintf("GraphQL 测试 %d", i)})
+			if err != nil {
+				t.Fatalf("创建失败: %v", err)
+			}
+			defer todoService.DeleteTodo(context.Background(), todo.ID)
+		}
+
+		data, errs, loader := exec(t, `{ todos(pageSize: 3) { total items { id history { type version } } } }`, nil)
+		if len(errs) > 0 {
+			t.Fatalf("查询失败: %v", errs)
+		}
+		items := data["todos"].(map[string]interface{})["items"].([]interface{})
+		if len(items) != 3 {
+			t.Fatalf("应该返回 3 条，实际: %d", len(items))
+		}
+		for _, item := range items {
+			if history := item.(map[string]interface{})["history"].([]interface{}); len(history) == 0 {
+				t.Errorf("待办事项 %v 应该至少有一条 TodoCreated 事件", item.(map[string]interface{})["id"])
+			}
+		}
+		if loader.queries != 1 {
+			t.Errorf("变更历史应该只查询 1 次，实际: %d", loader.queries)
+		}
+
+		t.Logf("✅ %d 条待办事项的变更历史共查询 %d 次", len(items), loader.queries)
+	})
+
+	t.Run("发件箱中的事件被清理后变更历史仍然保留", func(t *testing.T) {
+		todo, err := todoService.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "GraphQL 历史测试"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer todoService.DeleteTodo(context.Background(), todo.ID)
+		if err := config.DB.Where("aggregate_id = ?", todo.ID).Delete(&models.OutboxEvent{}).Error; err != nil {
+			t.Fatalf("清理发件箱失败: %v", err)
+		}
+
+		data, errs, _ := exec(t, `query($id: ID!) { todo(id: $id) { history { type version } } }`, map[string]interface{}{"id": fmt.Sprint(todo.ID)})
+		if len(errs) > 0 {
+			t.Fatalf("查询失败: %v", errs)
+		}
+		history := data["todo"].(map[string]interface{})["history"].([]interface{})
+		if len(history) != 1 || history[0].(map[string]interface{})["type"] != models.EventTodoCreated {
+			t.Fatalf("应该保留 TodoCreated，实际: %v", history)
+		}
+		t.Logf("✅ 变更历史: %v", history)
+	})
+
+	t.Run("数据模型中没有的字段不在 schema 中", func(t *testing.T) {
+		for _, field := range []string{"subtasks { id }", "tags", "assignees"} {
+			data, errs, _ := exec(t, fmt.Sprintf(`{ todos(pageSize: 1) { items { id %s } } }`, field), nil)
+			if len(errs) == 0 || data != nil {
+				t.Fatalf("%s 应该在校验阶段被拒绝，实际: data=%v errors=%v", field, data, errs)
+			}
+		}
+		t.Logf("✅ schema 中没有 subtasks、tags、assignees")
+	})
+}
+
+// TestUpdateTodoConflict 测试版本冲突返回带最新数据的 GraphQL 错误
+func TestUpdateTodoConflict(t *testing.T) {
+	t.Run("乐观锁：版本冲突返回 VERSION_CONFLICT 和最新数据", func(t *testing.T) {
+		todo, err := todoService.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "GraphQL 冲突测试"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer todoService.DeleteTodo(context.Background(), todo.ID)
+
+		mutation := `mutation($id: ID!, $version: Int!) { setTodoStatus(id: $id, completed: true, version: $version) { version } }`
+		vars := map[string]interface{}{"id": fmt.Sprint(todo.ID), "version": 0}
+		if _, errs, _ := exec(t, mutation, vars); len(errs) > 0 {
+			t.Fatalf("第一次更新失败: %v", errs)
+		}
+
+		_, errs, _ := exec(t, mutation, vars)
+		if len(errs) != 1 {
+			t.Fatalf("使用旧版本号应该返回一个错误，实际: %v", errs)
+		}
+		ext := errs[0]["extensions"].(map[string]interface{})
+		if ext["code"] != "VERSION_CONFLICT" {
+			t.Errorf("错误码应该为 VERSION_CONFLICT，实际: %v", ext["code"])
+		}
+		latest, ok := ext["latestData"].(map[string]interface{})
+		if !ok || latest["version"] != float64(1) || latest["completed"] != true {
+			t.Errorf("latestData 应该是最新数据，实际: %v", ext["latestData"])
+		}
+
+		t.Logf("✅ 版本冲突: %v", errs[0]["message"])
+	})
+}
+
+// TestServeHTTP 测试无法解析的请求体返回带错误码的 GraphQL 错误
+func TestServeHTTP(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"不是 JSON", "query { todos { total } }", http.StatusBadRequest},
+		{"超过请求体上限", `{"query": "` + strings.Repeat(" ", maxBodyBytes) + `{ todos { total } }"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tc.body)))
+			if w.Code != tc.status || w.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("应该返回 %d application/json，实际: %d %s", tc.status, w.Code, w.Header().Get("Content-Type"))
+			}
+			var response struct {
+				Errors []struct {
+					Message    string                 `json:"message"`
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Errors) != 1 {
+				t.Fatalf("响应应该是带一条错误的 GraphQL 响应，实际: %s", w.Body.String())
+			}
+			if response.Errors[0].Extensions["code"] != "INVALID_REQUEST" {
+				t.Errorf("错误码应该为 INVALID_REQUEST，实际: %v", response.Errors[0].Extensions)
+			}
+			t.Logf("✅ %d: %s", w.Code, response.Errors[0].Message)
+		})
+	}
+}
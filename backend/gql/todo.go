@@ -0,0 +1,72 @@
+package gql
+
+import (
+	"backend/models"
+	"context"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// todoResolver Todo 类型
+type todoResolver struct {
+	todo   *models.Todo
+	loader *historyLoader
+}
+
+func newTodoResolver(ctx context.Context, todo *models.Todo) *todoResolver {
+	return &todoResolver{todo: todo, loader: loaderFrom(ctx)}
+}
+
+func (r *todoResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(r.todo.ID), 10))
+}
+
+func (r *todoResolver) Title() string       { return r.todo.Title }
+func (r *todoResolver) Description() string { return r.todo.Description }
+func (r *todoResolver) Category() string    { return toEnum(r.todo.Category) }
+func (r *todoResolver) Priority() int32     { return int32(r.todo.Priority) }
+func (r *todoResolver) Completed() bool     { return r.todo.Completed }
+func (r *todoResolver) Version() int32      { return int32(r.todo.Version) }
+
+func (r *todoResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.todo.CreatedAt} }
+func (r *todoResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.todo.UpdatedAt} }
+
+// History 变更历史，通过 historyLoader 批量加载
+func (r *todoResolver) History(ctx context.Context) ([]*todoEventResolver, error) {
+	events, err := r.loader.load(ctx, r.todo.ID)
+	if err != nil {
+		return nil, toGraphQLError(ctx, err)
+	}
+	result := make([]*todoEventResolver, len(events))
+	for i := range events {
+		result[i] = &todoEventResolver{event: &events[i]}
+	}
+	return result, nil
+}
+
+// todoEventResolver TodoEvent 类型
+type todoEventResolver struct {
+	event *models.TodoHistory
+}
+
+func (r *todoEventResolver) Seq() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.event.Seq, 10))
+}
+
+func (r *todoEventResolver) Type() string            { return r.event.EventType }
+func (r *todoEventResolver) Version() int32          { return int32(r.event.Version) }
+func (r *todoEventResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.event.CreatedAt} }
+
+// todoPageResolver TodoPage 类型
+type todoPageResolver struct {
+	items    []*todoResolver
+	total    int64
+	page     int32
+	pageSize int32
+}
+
+func (r *todoPageResolver) Items() []*todoResolver { return r.items }
+func (r *todoPageResolver) Total() int32           { return int32(r.total) }
+func (r *todoPageResolver) Page() int32            { return r.page }
+func (r *todoPageResolver) PageSize() int32        { return r.pageSize }
@@ -0,0 +1,83 @@
+package grpcserver
+
+import (
+	customerrors "backend/errors"
+	"backend/events"
+	"backend/models"
+	todov1 "backend/proto/todo/v1"
+	"strconv"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protobuf 消息与 Service 层数据结构之间的转换
+// 分类枚举的取值与 models.Categories 的 ID 相同，排序枚举对应 sort_by 参数
+
+// toProto 将待办事项转换为 protobuf 消息
+func toProto(t *models.Todo) *todov1.Todo {
+	category, _ := models.CategoryByName(t.Category)
+	return &todov1.Todo{
+		Id:          uint32(t.ID),
+		Title:       t.Title,
+		Description: t.Description,
+		Category:    todov1.Category(category.ID),
+		Priority:    int32(t.Priority),
+		Completed:   t.Completed,
+		Version:     int32(t.Version),
+		ChangeSeq:   t.ChangeSeq,
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+		UpdatedAt:   timestamppb.New(t.UpdatedAt),
+	}
+}
+
+// categoryName 将分类枚举转换为名称；未指定时返回空字符串
+func categoryName(category todov1.Category) (string, error) {
+	if category == todov1.Category_CATEGORY_UNSPECIFIED {
+		return "", nil
+	}
+	c, ok := models.CategoryByID(uint(category))
+	if !ok {
+		allowed := make([]string, len(models.Categories))
+		for i, c := range models.Categories {
+			allowed[i] = todov1.Category(c.ID).String()
+		}
+		return "", customerrors.ErrInvalidCategoryID(uint(category), allowed)
+	}
+	return c.Name, nil
+}
+
+// sortBy 将排序枚举转换为 sort_by 参数，未知的取值原样交给 Service 层校验
+func sortBy(sort todov1.SortOrder) string {
+	switch sort {
+	case todov1.SortOrder_SORT_ORDER_UNSPECIFIED:
+		return ""
+	case todov1.SortOrder_SORT_ORDER_CREATED_AT:
+		return "created_at"
+	case todov1.SortOrder_SORT_ORDER_PRIORITY:
+		return "priority"
+	default:
+		return strconv.Itoa(int(sort))
+	}
+}
+
+// eventTypes 事件类型与枚举的对应关系
+var eventTypes = map[string]todov1.TodoEvent_Type{
+	events.TodoCreated: todov1.TodoEvent_TYPE_CREATED,
+	events.TodoUpdated: todov1.TodoEvent_TYPE_UPDATED,
+	events.TodoDeleted: todov1.TodoEvent_TYPE_DELETED,
+}
+
+// toEventProto 将事件中心的事件转换为 protobuf 消息
+func toEventProto(broker *events.Broker, e events.Event) *todov1.TodoEvent {
+	msg := &todov1.TodoEvent{
+		Id:      broker.Cursor(e.ID),
+		Type:    eventTypes[e.Type],
+		TodoId:  uint32(e.TodoID),
+		Version: int32(e.Version),
+		Time:    timestamppb.New(e.Time),
+	}
+	if todo, ok := e.Data.(*models.Todo); ok && todo != nil {
+		msg.Todo = toProto(todo)
+	}
+	return msg
+}
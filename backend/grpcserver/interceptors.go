@@ -0,0 +1,261 @@
+package grpcserver
+
+import (
+	customerrors "backend/errors"
+	"backend/i18n"
+	"backend/logging"
+	todov1 "backend/proto/todo/v1"
+	"backend/services"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain ErrorInfo 的 domain，reason 为 REST 接口中的 error_code
+const errorDomain = "todo-app"
+
+// ---------- 日志 ----------
+
+// requestIDMetadata 请求 ID 的元数据键，与 HTTP 的 X-Request-ID 对应
+const requestIDMetadata = "x-request-id"
+
+// loggingUnaryInterceptor 沿用或生成请求 ID，并记录每次调用的方法、状态码、耗时和客户端地址，字段与 HTTP 的 Logger 中间件一致
+func loggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// loggingStreamInterceptor 流式调用在结束时记录一次，耗时为整个流的持续时间
+func loggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(ss.Context())
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// withRequestID 沿用 x-request-id 元数据中的请求 ID，没有或不合法时生成一个，并通过响应头返回给客户端
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := ""
+	if values := md.Get(requestIDMetadata); len(values) > 0 {
+		id = values[0]
+	}
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+// contextStream 替换流的上下文，让处理函数拿到带有请求 ID 的上下文
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	clientAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		clientAddr = p.Addr.String()
+	}
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	slog.LogAttrs(ctx, level, "grpc call",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("client_ip", clientAddr),
+	)
+}
+
+// ---------- 认证 ----------
+
+// authUnaryInterceptor 校验 authorization 元数据中的 Bearer 令牌，token 为空时不校验
+func authUnaryInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStreamInterceptor 流式调用的令牌校验，在建立流时校验一次
+func authStreamInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		provided, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// ---------- 处理时限 ----------
+
+// timeoutUnaryInterceptor 为一元调用设置处理时限，context.WithTimeout 保留更早的截止时间
+// 流式调用（WatchTodos）是长连接，不设时限
+func timeoutUnaryInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// ---------- 错误转换 ----------
+
+// errorUnaryInterceptor 将 Service 层错误转换为 gRPC 状态
+func errorUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return resp, nil
+}
+
+// errorStreamInterceptor 流式调用的错误转换
+func errorStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatusError(ss.Context(), err)
+	}
+	return nil
+}
+
+// toStatusError 按 HTTP 状态码映射 gRPC 状态码：
+// 400 → INVALID_ARGUMENT，404 → NOT_FOUND，版本冲突 → ABORTED，其他 409 → FAILED_PRECONDITION，401 → UNAUTHENTICATED，403 → PERMISSION_DENIED，
+// 请求取消 → CANCELED，超时 → DEADLINE_EXCEEDED，数量上限与限流 → RESOURCE_EXHAUSTED，其他 5xx → INTERNAL
+// 提示信息按 accept-language 元数据本地化；details 中带有 ErrorInfo（reason 为错误码）、
+// 字段错误对应的 BadRequest，版本冲突时还有带最新数据的 VersionConflict
+func toStatusError(ctx context.Context, err error) error {
+	// 已经是 gRPC 状态（认证失败、流被断开等）的错误原样返回
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	err = customerrors.FromContext(ctx, err)
+	appErr := customerrors.AsAppError(err)
+	var code codes.Code
+	switch {
+	case appErr.Code == customerrors.CodeRequestCanceled:
+		code = codes.Canceled
+	case appErr.Code == customerrors.CodeRequestTimeout:
+		code = codes.DeadlineExceeded
+	case appErr.Code == customerrors.CodeVersionConflict:
+		code = codes.Aborted
+	case appErr.Code == customerrors.CodeTodoLimitReached, appErr.Code == customerrors.CodeRateLimited:
+		code = codes.ResourceExhausted
+	case appErr.Status == http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case appErr.Status == http.StatusNotFound:
+		code = codes.NotFound
+	case appErr.Status == http.StatusConflict:
+		code = codes.FailedPrecondition
+	case appErr.Status == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case appErr.Status == http.StatusForbidden:
+		code = codes.PermissionDenied
+	default:
+		// 内部错误不把原始错误返回给客户端，只记录日志
+		slog.ErrorContext(ctx, "internal error", "component", "grpc", "error", err)
+		code = codes.Internal
+	}
+
+	var params map[string]interface{}
+	var conflictErr *services.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		params = conflictErr.LocalizationParams()
+	}
+	localized := i18n.Error(languageFrom(ctx), appErr, params)
+
+	info := &errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain}
+	if len(appErr.Details) > 0 {
+		info.Metadata = make(map[string]string, len(appErr.Details))
+		for k, v := range appErr.Details {
+			info.Metadata[k] = fmt.Sprint(v)
+		}
+	}
+	details := []protoadapt.MessageV1{protoadapt.MessageV1Of(info)}
+	if len(localized.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range localized.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+				Reason:      f.Rule,
+			})
+		}
+		details = append(details, protoadapt.MessageV1Of(badRequest))
+	}
+	if conflictErr != nil {
+		conflict := &todov1.VersionConflict{
+			CurrentVersion:  int32(conflictErr.CurrentVersion),
+			ProvidedVersion: int32(conflictErr.ProvidedVersion),
+		}
+		if conflictErr.LatestData != nil {
+			conflict.LatestData = toProto(conflictErr.LatestData)
+		}
+		details = append(details, protoadapt.MessageV1Of(conflict))
+	}
+
+	st, detailErr := status.New(code, localized.Detail).WithDetails(details...)
+	if detailErr != nil {
+		return status.Error(code, localized.Detail)
+	}
+	return st.Err()
+}
+
+// languageFrom 从 accept-language 元数据确定提示信息的语言
+func languageFrom(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("accept-language") {
+		if lang, ok := i18n.ParseAcceptLanguage(header); ok {
+			return lang
+		}
+	}
+	return i18n.DefaultLang
+}
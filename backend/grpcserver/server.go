@@ -0,0 +1,205 @@
+package grpcserver
+
+import (
+	"backend/events"
+	"backend/lifecycle"
+	"backend/models"
+	todov1 "backend/proto/todo/v1"
+	"backend/services"
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server gRPC 接口的 TodoService 实现，业务逻辑全部复用 services.TodoService
+// 错误原样返回，由 errorUnaryInterceptor / errorStreamInterceptor 统一转换为 gRPC 状态码
+type Server struct {
+	todov1.UnimplementedTodoServiceServer
+
+	todos  *services.TodoService
+	broker *events.Broker
+}
+
+// NewServer 创建 TodoService 实现，WatchTodos 订阅 broker 上的数据变更
+func NewServer(todos *services.TodoService, broker *events.Broker) *Server {
+	return &Server{todos: todos, broker: broker}
+}
+
+// New 创建注册了 TodoService 和拦截器的 gRPC 服务
+// token 为空时不校验访问令牌；timeout 为一元调用的处理时限，客户端设置了更早的截止时间时以客户端为准，0 表示不限制
+func New(todos *services.TodoService, broker *events.Broker, token string, timeout time.Duration) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(loggingUnaryInterceptor, authUnaryInterceptor(token), timeoutUnaryInterceptor(timeout), errorUnaryInterceptor),
+		grpc.ChainStreamInterceptor(loggingStreamInterceptor, authStreamInterceptor(token), errorStreamInterceptor),
+	)
+	todov1.RegisterTodoServiceServer(srv, NewServer(todos, broker))
+	return srv
+}
+
+// CreateTodo 创建待办事项
+func (s *Server) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	category, err := categoryName(req.GetCategory())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.CreateTodo(ctx, &models.CreateTodoInput{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Category:    category,
+		Priority:    int(req.GetPriority()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProto(todo), nil
+}
+
+// GetTodo 根据 ID 获取待办事项
+func (s *Server) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	todo, err := s.todos.GetTodoByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return toProto(todo), nil
+}
+
+// ListTodos 分页获取待办事项，page 和 page_size 为 0 时使用默认值
+func (s *Server) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	category, err := categoryName(req.GetCategory())
+	if err != nil {
+		return nil, err
+	}
+	page, pageSize := int(req.GetPage()), int(req.GetPageSize())
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = services.DefaultPageSize
+	}
+
+	todos, total, err := s.todos.ListTodos(ctx, category, sortBy(req.GetSort()), page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	resp := &todov1.ListTodosResponse{
+		Todos:    make([]*todov1.Todo, len(todos)),
+		Total:    total,
+		Page:     int32(page),
+		PageSize: int32(pageSize),
+	}
+	for i := range todos {
+		resp.Todos[i] = toProto(&todos[i])
+	}
+	return resp, nil
+}
+
+// UpdateTodo 编辑待办事项
+func (s *Server) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	category, err := categoryName(req.GetCategory())
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.todos.UpdateTodo(ctx, uint(req.GetId()), &models.UpdateTodoInput{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		Category:    category,
+		Priority:    int(req.GetPriority()),
+		Version:     int(req.GetVersion()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProto(todo), nil
+}
+
+// UpdateTodoStatus 更新完成状态
+func (s *Server) UpdateTodoStatus(ctx context.Context, req *todov1.UpdateTodoStatusRequest) (*todov1.Todo, error) {
+	todo, err := s.todos.UpdateTodoStatus(ctx, uint(req.GetId()), &models.UpdateStatusInput{
+		Completed: req.GetCompleted(),
+		Version:   int(req.GetVersion()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProto(todo), nil
+}
+
+// DeleteTodo 删除待办事项，带 version 时按乐观锁删除
+func (s *Server) DeleteTodo(ctx context.Context, req *todov1.DeleteTodoRequest) (*todov1.DeleteTodoResponse, error) {
+	var err error
+	if req.Version != nil {
+		err = s.todos.DeleteTodoWithVersion(ctx, uint(req.GetId()), int(req.GetVersion()))
+	} else {
+		err = s.todos.DeleteTodo(ctx, uint(req.GetId()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &todov1.DeleteTodoResponse{}, nil
+}
+
+// WatchTodos 推送数据变更，与 SSE 接口（GET /api/events）共用事件中心，断点续传规则相同：
+// last_event_id 之后的事件无法补齐时先发送一条 TYPE_RESET 事件；
+// 消费过慢被事件中心断开时返回 UNAVAILABLE，客户端带上最后收到的事件 ID 重新订阅即可
+func (s *Server) WatchTodos(req *todov1.WatchTodosRequest, stream grpc.ServerStreamingServer[todov1.TodoEvent]) error {
+	category, err := categoryName(req.GetCategory())
+	if err != nil {
+		return err
+	}
+	todoID := uint(req.GetTodoId())
+	filter := func(e events.Event) bool {
+		if category != "" && e.Category != category {
+			return false
+		}
+		if todoID != 0 && e.TodoID != todoID {
+			return false
+		}
+		return true
+	}
+
+	var lastID uint64
+	resumable := true
+	if cursor := req.GetLastEventId(); cursor != "" {
+		lastID, resumable = s.broker.ParseCursor(cursor)
+	}
+
+	sub, backlog, complete := s.broker.Subscribe(lastID, filter)
+	defer sub.Close()
+
+	if !resumable || !complete {
+		reset := &todov1.TodoEvent{
+			Id:   s.broker.Cursor(s.broker.LastID()),
+			Type: todov1.TodoEvent_TYPE_RESET,
+			Time: timestamppb.Now(),
+		}
+		if err := stream.Send(reset); err != nil {
+			return err
+		}
+	}
+	for _, e := range backlog {
+		if err := stream.Send(toEventProto(s.broker, e)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscriber too slow, resubscribe with last_event_id")
+			}
+			if err := stream.Send(toEventProto(s.broker, e)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-lifecycle.ShuttingDown():
+			// 服务正在退出，客户端应带着 last_event_id 重连到其他实例
+			return status.Error(codes.Unavailable, "server shutting down, resubscribe with last_event_id")
+		}
+	}
+}
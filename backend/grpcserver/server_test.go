@@ -0,0 +1,174 @@
+package grpcserver
+
+import (
+	"backend/config"
+	"backend/events"
+	todov1 "backend/proto/todo/v1"
+	"backend/services"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testToken = "test-token"
+
+var (
+	broker *events.Broker
+	client todov1.TodoServiceClient
+)
+
+func TestMain(m *testing.M) {
+	// 初始化数据库连接
+	if err := config.InitDB(); err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		return
+	}
+
+	// 使用内存连接启动 gRPC 服务，事件中心与全局的隔离
+	broker = events.NewBroker(10)
+	lis := bufconn.Listen(1024 * 1024)
+	srv := New(services.NewTodoService(), broker, testToken, 10*time.Second)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		fmt.Printf("Failed to create client: %v\n", err)
+		return
+	}
+	defer conn.Close()
+	client = todov1.NewTodoServiceClient(conn)
+	m.Run()
+}
+
+// authed 带上访问令牌的上下文
+func authed() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testToken)
+}
+
+// TestTodoService 测试 gRPC 接口的读写和错误码映射
+func TestTodoService(t *testing.T) {
+	t.Run("认证：缺少令牌返回 UNAUTHENTICATED", func(t *testing.T) {
+		_, err := client.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: 1})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("状态码应该为 UNAUTHENTICATED，实际: %v", err)
+		}
+		t.Logf("✅ 缺少令牌: %v", err)
+	})
+
+	t.Run("参数错误返回 INVALID_ARGUMENT 和字段错误", func(t *testing.T) {
+		_, err := client.CreateTodo(authed(), &todov1.CreateTodoRequest{Title: ""})
+		st := status.Convert(err)
+		if st.Code() != codes.InvalidArgument {
+			t.Fatalf("状态码应该为 INVALID_ARGUMENT，实际: %v", err)
+		}
+		var fields []string
+		for _, d := range st.Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok {
+				for _, v := range br.GetFieldViolations() {
+					fields = append(fields, v.GetField())
+				}
+			}
+		}
+		if len(fields) != 1 || fields[0] != "title" {
+			t.Errorf("字段错误应该为 [title]，实际: %v", fields)
+		}
+		t.Logf("✅ 参数错误: %s %v", st.Message(), fields)
+	})
+
+	t.Run("不存在的待办事项返回 NOT_FOUND", func(t *testing.T) {
+		_, err := client.GetTodo(authed(), &todov1.GetTodoRequest{Id: 999999})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("状态码应该为 NOT_FOUND，实际: %v", err)
+		}
+		t.Logf("✅ 不存在: %v", err)
+	})
+
+	t.Run("乐观锁：版本冲突返回 ABORTED 和最新数据", func(t *testing.T) {
+		todo, err := client.CreateTodo(authed(), &todov1.CreateTodoRequest{Title: "gRPC 冲突测试", Category: todov1.Category_CATEGORY_WORK})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer client.DeleteTodo(authed(), &todov1.DeleteTodoRequest{Id: todo.GetId()})
+		if todo.GetCategory() != todov1.Category_CATEGORY_WORK {
+			t.Errorf("分类应该为 WORK，实际: %v", todo.GetCategory())
+		}
+
+		req := &todov1.UpdateTodoStatusRequest{Id: todo.GetId(), Completed: true, Version: 0}
+		if _, err := client.UpdateTodoStatus(authed(), req); err != nil {
+			t.Fatalf("第一次更新失败: %v", err)
+		}
+		_, err = client.UpdateTodoStatus(authed(), req)
+		st := status.Convert(err)
+		if st.Code() != codes.Aborted {
+			t.Fatalf("状态码应该为 ABORTED，实际: %v", err)
+		}
+		var conflict *todov1.VersionConflict
+		for _, d := range st.Details() {
+			if c, ok := d.(*todov1.VersionConflict); ok {
+				conflict = c
+			}
+		}
+		if conflict == nil || conflict.GetCurrentVersion() != 1 || !conflict.GetLatestData().GetCompleted() {
+			t.Errorf("details 应该带有最新数据，实际: %v", st.Details())
+		}
+		t.Logf("✅ 版本冲突: %s", st.Message())
+	})
+}
+
+// TestWatchTodos 测试变更推送和断点续传
+func TestWatchTodos(t *testing.T) {
+	t.Run("按分类推送变更，断开后从最后的事件继续", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(authed(), 5*time.Second)
+		defer cancel()
+
+		// 先发布事件再从之前的位置订阅，事件通过补发收到，不依赖订阅建立的时机
+		marker := broker.Publish(events.Event{Type: events.TodoUpdated, TodoID: 1, Version: 1, Category: "life"})
+		start := broker.Cursor(marker.ID)
+		broker.Publish(events.Event{Type: events.TodoCreated, TodoID: 1, Version: 0, Category: "life"})
+		broker.Publish(events.Event{Type: events.TodoCreated, TodoID: 2, Version: 0, Category: "work"})
+
+		stream, err := client.WatchTodos(ctx, &todov1.WatchTodosRequest{Category: todov1.Category_CATEGORY_WORK, LastEventId: start})
+		if err != nil {
+			t.Fatalf("订阅失败: %v", err)
+		}
+		first, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("接收失败: %v", err)
+		}
+		if first.GetTodoId() != 2 || first.GetType() != todov1.TodoEvent_TYPE_CREATED {
+			t.Errorf("应该只收到 work 分类的事件，实际: %v", first)
+		}
+		cancel()
+
+		broker.Publish(events.Event{Type: events.TodoDeleted, TodoID: 2, Version: 1, Category: "work"})
+		resumed, err := client.WatchTodos(authed(), &todov1.WatchTodosRequest{
+			Category:    todov1.Category_CATEGORY_WORK,
+			LastEventId: first.GetId(),
+		})
+		if err != nil {
+			t.Fatalf("重新订阅失败: %v", err)
+		}
+		next, err := resumed.Recv()
+		if err != nil {
+			t.Fatalf("接收失败: %v", err)
+		}
+		if next.GetType() != todov1.TodoEvent_TYPE_DELETED || next.GetVersion() != 1 {
+			t.Errorf("应该补发断开期间的删除事件，实际: %v", next)
+		}
+		t.Logf("✅ 从 %s 继续收到: %v", first.GetId(), next.GetType())
+	})
+}
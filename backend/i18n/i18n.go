@@ -0,0 +1,277 @@
+package i18n
+
+import (
+	customerrors "backend/errors"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 支持的语言
+const (
+	LangEN   = "en"
+	LangZhCN = "zh-CN"
+
+	// DefaultLang 请求没有指定语言时使用英文，与不带 Accept-Language 的旧客户端行为一致
+	DefaultLang = LangEN
+)
+
+// LangParam 用户显式选择语言的查询参数和 Cookie 名，优先于 Accept-Language
+const LangParam = "lang"
+
+// message 一个错误码对应的提示
+type message struct {
+	Title  string `json:"title"`  // 错误类型的概括
+	Detail string `json:"detail"` // 具体描述，可以包含 {name} 形式的参数
+}
+
+// catalog 一种语言的消息目录
+type catalog struct {
+	Errors map[string]message `json:"errors"` // 错误码 -> 提示
+	Fields map[string]string  `json:"fields"` // JSON 字段名 -> 显示名称
+	Rules  map[string]string  `json:"rules"`  // 校验规则 -> 提示模板
+}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs 语言 -> 消息目录，启动时从 locales 目录加载，文件名即语言标签
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]*catalog {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	result := make(map[string]*catalog, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = &c
+	}
+	return result
+}
+
+// Supported 是否支持该语言
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Match 将语言标签匹配到支持的语言：不区分大小写，zh、zh-Hans、zh-TW 等匹配 zh-CN，en-US 匹配 en
+func Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	for lang := range catalogs {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+	primary := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+	for lang := range catalogs {
+		if strings.ToLower(strings.SplitN(lang, "-", 2)[0]) == primary {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// ParseAcceptLanguage 按 q 值从高到低选出第一个支持的语言
+func ParseAcceptLanguage(header string) (string, bool) {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		c := candidate{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		if c.tag != "" && c.tag != "*" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if lang, ok := Match(c.tag); ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// FromRequest 确定请求使用的语言：lang 查询参数 > lang Cookie > Accept-Language > 默认语言
+func FromRequest(r *http.Request) string {
+	if lang, ok := Match(r.URL.Query().Get(LangParam)); ok {
+		return lang
+	}
+	if cookie, err := r.Cookie(LangParam); err == nil {
+		if lang, ok := Match(cookie.Value); ok {
+			return lang
+		}
+	}
+	if lang, ok := ParseAcceptLanguage(r.Header.Get("Accept-Language")); ok {
+		return lang
+	}
+	return DefaultLang
+}
+
+// Localized 本地化后的错误
+type Localized struct {
+	Title  string
+	Detail string
+	Fields []customerrors.FieldError
+}
+
+// Error 按错误码和 Details 生成指定语言的提示；extra 为额外的模板参数（如版本冲突时的当前版本号）
+// 目录中没有的错误码使用 AppError 自带的英文信息；模板参数不全时 detail 退回 title
+func Error(lang string, err *customerrors.AppError, extra map[string]interface{}) Localized {
+	result := Localized{Title: err.Title, Detail: err.Message}
+	if m, ok := lookup(lang, func(c *catalog) (message, bool) { m, ok := c.Errors[err.Code]; return m, ok }); ok {
+		params := make(map[string]interface{}, len(err.Details)+len(extra)+1)
+		for k, v := range err.Details {
+			params[k] = v
+		}
+		for k, v := range extra {
+			params[k] = v
+		}
+		if _, ok := params["count"]; !ok {
+			params["count"] = len(err.Fields)
+		}
+
+		result.Title = m.Title
+		if detail, ok := interpolate(m.Detail, params); ok {
+			result.Detail = detail
+		} else {
+			result.Detail = m.Title
+		}
+	}
+
+	if len(err.Fields) > 0 {
+		result.Fields = make([]customerrors.FieldError, len(err.Fields))
+		for i, f := range err.Fields {
+			f.Message = Field(lang, f)
+			result.Fields[i] = f
+		}
+	}
+	return result
+}
+
+// Title 错误码的本地化概括，目录中没有该错误码时返回 false
+func Title(lang, code string) (string, bool) {
+	m, ok := lookup(lang, func(c *catalog) (message, bool) { m, ok := c.Errors[code]; return m, ok })
+	return m.Title, ok
+}
+
+// paramsProvider 可以为本地化提供额外模板参数的错误，如版本冲突错误提供当前版本号
+type paramsProvider interface {
+	LocalizationParams() map[string]interface{}
+}
+
+// Detail 任意错误的本地化描述，用于 HTTP 以外的场景（同步结果、WebSocket 消息）
+func Detail(lang string, err error) string {
+	var extra map[string]interface{}
+	var p paramsProvider
+	if errors.As(err, &p) {
+		extra = p.LocalizationParams()
+	}
+	return Error(lang, customerrors.AsAppError(err), extra).Detail
+}
+
+// Field 生成字段校验错误的提示，如 "标题不能为空"
+func Field(lang string, f customerrors.FieldError) string {
+	template, ok := lookup(lang, func(c *catalog) (string, bool) {
+		if t, ok := c.Rules[f.Rule]; ok {
+			return t, true
+		}
+		t, ok := c.Rules["default"]
+		return t, ok
+	})
+	if !ok {
+		return f.Message
+	}
+
+	params := map[string]interface{}{
+		"field": FieldName(lang, f.Field),
+		"param": f.Param,
+	}
+	switch f.Rule {
+	case "range":
+		if lo, hi, ok := strings.Cut(f.Param, ","); ok {
+			params["min"], params["max"] = lo, hi
+		}
+	case "oneof":
+		params["param"] = strings.Join(strings.Fields(f.Param), ", ")
+	}
+
+	if text, ok := interpolate(template, params); ok {
+		return text
+	}
+	return f.Message
+}
+
+// FieldName 字段的显示名称，嵌套字段（changes[0].title）按最后一段查找，找不到时使用字段名本身
+func FieldName(lang, field string) string {
+	key := field
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	if name, ok := lookup(lang, func(c *catalog) (string, bool) { n, ok := c.Fields[key]; return n, ok }); ok {
+		return name
+	}
+	return field
+}
+
+// lookup 先在指定语言中查找，找不到时退回默认语言
+func lookup[T any](lang string, get func(*catalog) (T, bool)) (T, bool) {
+	if c, ok := catalogs[lang]; ok {
+		if v, ok := get(c); ok {
+			return v, true
+		}
+	}
+	if c, ok := catalogs[DefaultLang]; ok {
+		return get(c)
+	}
+	var zero T
+	return zero, false
+}
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// interpolate 替换 {name} 形式的参数，切片用逗号连接；有参数缺失时返回 false
+func interpolate(template string, params map[string]interface{}) (string, bool) {
+	complete := true
+	text := placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		v, ok := params[m[1:len(m)-1]]
+		if !ok {
+			complete = false
+			return m
+		}
+		if list, ok := v.([]string); ok {
+			return strings.Join(list, ", ")
+		}
+		return fmt.Sprint(v)
+	})
+	return text, complete
+}
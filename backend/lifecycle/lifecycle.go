@@ -0,0 +1,37 @@
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 服务的生命周期状态
+// 退出分两步：先 StartDraining 标记为未就绪（/readyz 返回 503），负载均衡摘除实例期间照常处理请求；
+// 再 Shutdown 通知 SSE 等长连接结束，让 http.Server.Shutdown 不必等到超时
+
+var (
+	draining     atomic.Bool
+	shutdown     = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// Ready 服务是否就绪（未开始退出）
+func Ready() bool {
+	return !draining.Load()
+}
+
+// StartDraining 标记为未就绪
+func StartDraining() {
+	draining.Store(true)
+}
+
+// Shutdown 通知长连接结束，可以重复调用
+func Shutdown() {
+	StartDraining()
+	shutdownOnce.Do(func() { close(shutdown) })
+}
+
+// ShuttingDown 开始关闭时关闭的通道，长连接的处理函数应在其关闭后尽快返回
+func ShuttingDown() <-chan struct{} {
+	return shutdown
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger 把 GORM 的日志写到 slog
+// 出错的 SQL 记为 ERROR（记录不存在除外），超过慢查询阈值的记为 WARN，其余记为 DEBUG，
+// 所以默认的 info 级别下只输出错误和慢查询；查询带有上下文（DB.WithContext）时日志中有请求 ID
+type GormLogger struct {
+	SlowThreshold time.Duration // 0 表示不单独记录慢查询
+}
+
+// NewGormLogger 创建 GORM 日志
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold}
+}
+
+// LogMode 级别由 slog 控制，这里不做处理
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...), "component", "gorm")
+}
+
+// Trace 每条 SQL 执行后调用
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("component", "gorm"),
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if level == slog.LevelWarn {
+		attrs = append(attrs, slog.Duration("threshold", l.SlowThreshold))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
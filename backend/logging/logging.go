@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"regexp"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 结构化日志
+// 所有日志通过 log/slog 输出，标准库 log 包的输出也会经由 slog.SetDefault 转到这里；
+// 上下文中带有请求 ID 时，每条日志自动附加 request_id 字段，处于链路追踪的 span 中时附加 trace_id 和 span_id
+
+type requestIDKey struct{}
+
+// WithRequestID 返回带有请求 ID 的上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 取出上下文中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID 接受的外部请求 ID，限制字符和长度，防止日志注入
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ValidRequestID 上游（网关、客户端）传入的请求 ID 是否可以沿用
+func ValidRequestID(id string) bool {
+	return validRequestID.MatchString(id)
+}
+
+// NewRequestID 生成 32 位十六进制的随机请求 ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Setup 设置全局日志：format 为 json 或 text，低于 level 的日志不输出
+func Setup(w io.Writer, format string, level slog.Level) {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// contextHandler 从上下文中取出请求 ID 附加到日志上
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -2,23 +2,133 @@ package main
 
 import (
 	"backend/config"
-	"backend/router"
-	"log"
+	"backend/logging"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
 )
 
+// 退出码，供脚本判断执行结果
+const (
+	exitOK          = 0
+	exitError       = 1 // 执行失败
+	exitUsage       = 2 // 命令或参数错误
+	exitCheckFailed = 3 // check-db 发现问题
+)
+
+var (
+	// errUsage 命令行参数错误，错误信息和用法已经打印
+	errUsage = errors.New("usage error")
+	// errCheckFailed check-db 有检查项未通过，结果已经打印
+	errCheckFailed = errors.New("check failed")
+)
+
+// command 一个子命令
+type command struct {
+	name    string
+	usage   string // 参数说明，不含命令名
+	summary string
+	run     func(args []string) error
+}
+
+// commands 所有子命令，不带子命令时执行 serve
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"serve", "", "启动 HTTP 和 gRPC 服务（默认）", serve},
+		{"migrate", "up [n] | down [n] | status", "执行、回滚数据库迁移或查看迁移状态", runMigrate},
+		{"seed", "[--count n] [--seed n]", "生成演示数据", runSeed},
+		{"export", "[--file path]", "以 JSON 导出所有待办事项，默认输出到标准输出", runExport},
+		{"import", "[--file path]", "导入 export 导出的文件，默认从标准输入读取", runImport},
+		{"purge-trash", "[--older-than 30d] [--dry-run]", "彻底删除回收站中超过保留期的待办事项", runPurgeTrash},
+		{"check-db", "[--json]", "检查数据库连通性和表结构，有问题时退出码为 3", runCheckDB},
+		{"create-user", "--username name [--role user|admin] [--password-stdin]", "创建用户，未指定密码时生成随机密码", runCreateUser},
+	}
+}
+
 func main() {
-	// 初始化数据库连接
-	if err := config.InitDB(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 执行子命令并返回退出码
+func run(args []string) int {
+	logConfig := config.GetLogConfig()
+	logging.Setup(os.Stderr, logConfig.Format, logConfig.Level)
+
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return exitOK
 	}
 
-	// 配置路由
-	r := router.SetupRouter()
+	var cmd *command
+	for _, c := range commands {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return exitUsage
+	}
 
-	// 启动服务器
-	log.Println("Server starting on :8080")
-	log.Println("API available at: http://localhost:8080/api/todos")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	err := cmd.run(args)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errCheckFailed):
+		return exitCheckFailed
+	default:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return exitError
 	}
 }
+
+// printUsage 打印所有子命令
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: backend [command] [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nrun `backend <command> -h` for the flags of a command")
+	fmt.Fprintln(w, "database connection: TODO_DB_HOST, TODO_DB_PORT, TODO_DB_USER, TODO_DB_PASSWORD, TODO_DB_NAME")
+}
+
+// newFlagSet 创建子命令的参数解析器，解析失败时不退出进程而是返回错误
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: backend %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，参数错误时转换为 errUsage（flag 包已经打印了错误和用法）
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// usagef 打印参数错误和用法，返回 errUsage
+func usagef(fs *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(fs.Output(), format+"\n", args...)
+	fs.Usage()
+	return errUsage
+}
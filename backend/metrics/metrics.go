@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus 指标，通过 /metrics 暴露
+// 使用独立的注册表而不是全局默认注册表，只暴露这里注册的指标
+
+// namespace 所有指标名的前缀
+const namespace = "todo"
+
+// countTimeout 抓取时统计待办事项数量的超时时间
+const countTimeout = 2 * time.Second
+
+// Registry 应用的指标注册表
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests 按路由模板、方法、状态码统计的请求数
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration 按路由模板、方法、状态码统计的请求耗时
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// Panics Recovery 中间件捕获的 panic 次数
+	Panics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "Panics recovered while handling HTTP requests.",
+	})
+
+	// VersionConflicts 乐观锁版本冲突次数，operation 为 update、update_status 或 delete
+	VersionConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "version_conflicts_total",
+		Help:      "Optimistic locking version conflicts by operation.",
+	}, []string{"operation"})
+
+	// RateLimited 被限流拒绝的请求数，policy 为 read、write 或 create
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter by policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Panics,
+		VersionConflicts,
+		RateLimited,
+		todoCollector{},
+	)
+	// 预先创建序列，没有冲突时也能查到 0，便于计算冲突率和配置告警
+	VersionConflicts.WithLabelValues("update")
+	VersionConflicts.WithLabelValues("update_status")
+	VersionConflicts.WithLabelValues("delete")
+}
+
+// RegisterDB 注册数据库连接池指标（sql.DB.Stats），数据库连接建立后调用一次
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler /metrics 处理函数；某个指标采集失败时照常输出其余指标
+// token 不为空时要求 Authorization: Bearer <token>（Prometheus 的 authorization 配置），否则返回 401
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		ErrorLog:      log.Default(),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// todoItemsDesc 待办事项数量
+var todoItemsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "items"),
+	"Non-deleted todo items by category and completion status.",
+	[]string{"category", "completed"}, nil,
+)
+
+// todoCollector 每次抓取时从数据库统计待办事项数量
+type todoCollector struct{}
+
+func (todoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- todoItemsDesc
+}
+
+func (todoCollector) Collect(ch chan<- prometheus.Metric) {
+	if config.DB == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := models.CountByCategoryAndStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(todoItemsDesc, err)
+		return
+	}
+	for _, c := range counts {
+		ch <- prometheus.MustNewConstMetric(todoItemsDesc, prometheus.GaugeValue, float64(c.Count),
+			c.Category, strconv.FormatBool(c.Completed))
+	}
+}
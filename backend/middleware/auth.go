@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"backend/config"
+	customerrors "backend/errors"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"crypto/subtle"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate 在 gin.Context 中设置的身份，限流、CSRF 校验和需要登录的接口使用
+const (
+	UserIDKey       = "auth.user_id"       // 已登录用户的 ID
+	UserKey         = "auth.user"          // 已登录用户（*models.User）
+	SessionTokenKey = "auth.session_token" // 会话令牌，退出登录时使用
+	CSRFTokenKey    = "auth.csrf_token"    // 通过 Cookie 认证时，修改请求必须携带的 CSRF 令牌
+)
+
+// CSRFHeader 携带 CSRF 令牌的请求头，CSRFCookie 保存 CSRF 令牌的 Cookie（前端脚本可读）
+const (
+	CSRFHeader = "X-CSRF-Token"
+	CSRFCookie = "todo_csrf"
+)
+
+// Authenticate 识别请求的用户：Authorization: Bearer 令牌优先，其次是双向 TLS 的客户端证书，最后是会话 Cookie
+// Bearer 令牌无效或已过期时返回 401；客户端证书没有对应的用户、会话 Cookie 无效时视为未登录，已过期的 Cookie 不影响匿名访问。
+// 没有凭据的请求作为匿名请求继续处理，需要登录的路由再加 RequireAuth
+func Authenticate(auth *services.AuthService, cookieName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, viaCookie := bearerToken(c.GetHeader("Authorization")), false
+		if token == "" {
+			if name := clientCertificateName(c.Request.TLS); name != "" {
+				user, err := auth.AuthenticateCertificate(c.Request.Context(), name)
+				if err == nil {
+					setUser(c, user)
+					c.Next()
+					return
+				}
+				if customerrors.CodeOf(err) != customerrors.CodeUnauthenticated {
+					utils.HandleServiceError(c, err)
+					c.Abort()
+					return
+				}
+			}
+			if cookie, err := c.Cookie(cookieName); err == nil && cookie != "" {
+				token, viaCookie = cookie, true
+			}
+		}
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		user, err := auth.Authenticate(c.Request.Context(), token)
+		switch {
+		case err == nil:
+		case viaCookie && customerrors.CodeOf(err) == customerrors.CodeUnauthenticated:
+			c.Next()
+			return
+		default:
+			if customerrors.CodeOf(err) == customerrors.CodeUnauthenticated {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			utils.HandleServiceError(c, err)
+			c.Abort()
+			return
+		}
+
+		setUser(c, user)
+		c.Set(SessionTokenKey, token)
+		if viaCookie {
+			c.Set(CSRFTokenKey, services.CSRFToken(token))
+		}
+		c.Next()
+	}
+}
+
+// setUser 记录已登录的用户：gin.Context 中供中间件和 Controller 使用，请求上下文中供 Service 层记录创建者和检查权限
+func setUser(c *gin.Context, user *models.User) {
+	c.Set(UserIDKey, user.ID)
+	c.Set(UserKey, user)
+	ctx := services.WithUserID(c.Request.Context(), user.ID)
+	if user.Role == models.RoleAdmin {
+		ctx = services.WithAdmin(ctx)
+	}
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// RequireAuth 未登录时返回 401
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(UserKey); !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			utils.Fail(c, customerrors.ErrUnauthenticated)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAuthFor 按 TODO_REQUIRE_AUTH 的模式要求登录：all 所有请求，writes 修改请求和 WebSocket（协作通道中可以修改数据），
+// off 不要求。用于待办事项、同步、事件推送和 GraphQL 等数据接口
+func RequireAuthFor(mode string) gin.HandlerFunc {
+	requireAuth := RequireAuth()
+	return func(c *gin.Context) {
+		switch {
+		case mode == config.AuthRequiredAll:
+			requireAuth(c)
+		case mode == config.AuthRequiredWrites && (!safeMethod(c.Request.Method) || c.IsWebsocket()):
+			requireAuth(c)
+		default:
+			c.Next()
+		}
+	}
+}
+
+// safeMethod 不修改数据的请求方法
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// CSRF 跨站请求伪造防护，只检查修改请求（POST、PUT、PATCH、DELETE 等）：
+// 1. 带有 Origin（没有时看 Referer）的请求，来源必须是同源或 CORS 白名单中的来源，登录等未认证的请求也检查；
+// 2. 通过会话 Cookie 认证的请求，X-CSRF-Token 必须与会话的 CSRF 令牌一致。
+// 使用 Bearer 令牌的请求不会被浏览器自动带上凭据，不需要 CSRF 令牌；没有 Origin 和 Referer 的通常是非浏览器客户端
+func CSRF(policy *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if safeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			origin = refererOrigin(c.GetHeader("Referer"))
+		}
+		if origin != "" && !policy.AllowsRequestOrigin(origin, c.Request.Host) {
+			utils.Fail(c, customerrors.ErrCSRFCheckFailed.WithMessage("csrf check failed: origin %s is not allowed", origin))
+			c.Abort()
+			return
+		}
+
+		if expected := c.GetString(CSRFTokenKey); expected != "" {
+			if subtle.ConstantTimeCompare([]byte(c.GetHeader(CSRFHeader)), []byte(expected)) != 1 {
+				utils.Fail(c, customerrors.ErrCSRFCheckFailed.WithMessage("csrf check failed: missing or invalid %s header", CSRFHeader))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// bearerToken 取出 Authorization: Bearer 中的令牌
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// clientCertificateName 已通过 CA 校验的客户端证书的 CN，没有时返回空
+func clientCertificateName(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
+
+// refererOrigin Referer 的来源部分（scheme://host），无法解析时返回 "null"，按不允许的来源处理
+func refererOrigin(referer string) string {
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "null"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -1,32 +1,198 @@
 package middleware
 
 import (
-	"log"
+	"backend/config"
+	customerrors "backend/errors"
+	"backend/metrics"
+	"backend/utils"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CORSPolicy 跨域策略：来源白名单（支持通配子域名）与预检请求的响应头
+type CORSPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	patterns         []originPattern
+	allowedMethods   map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// originPattern 通配来源 https://*.example.com，* 匹配一级或多级子域名
+type originPattern struct {
+	prefix, suffix string
+}
+
+// NewCORSPolicy 按配置创建跨域策略，来源不区分大小写
+func NewCORSPolicy(cfg *config.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{
+		origins:          make(map[string]bool),
+		allowedMethods:   make(map[string]bool),
+		allowMethods:     strings.Join(cfg.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(cfg.AllowedHeaders, ", "),
+		exposeHeaders:    strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+		maxAge:           strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if origin == "*" {
+			p.anyOrigin = true
+		} else if prefix, suffix, ok := strings.Cut(origin, "*"); ok {
+			p.patterns = append(p.patterns, originPattern{prefix: prefix, suffix: suffix})
+		} else {
+			p.origins[origin] = true
+		}
+	}
+	for _, method := range cfg.AllowedMethods {
+		p.allowedMethods[strings.ToUpper(method)] = true
+	}
+	return p
+}
+
+// AllowsOrigin 来源是否在白名单中
+func (p *CORSPolicy) AllowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		// 通配部分必须是非空的子域名，不能跨越 scheme 或路径
+		if sub, ok := strings.CutPrefix(origin, pattern.prefix); ok && strings.HasSuffix(sub, pattern.suffix) {
+			if sub = strings.TrimSuffix(sub, pattern.suffix); sub != "" && !strings.ContainsAny(sub, "/:@") && !strings.HasPrefix(sub, ".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowsRequestOrigin 来源是否与请求同源（host 为请求的 Host），或在白名单中
+func (p *CORSPolicy) AllowsRequestOrigin(origin, host string) bool {
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, host) {
+		return true
+	}
+	return origin != "null" && p.AllowsOrigin(origin)
+}
+
 // CORS 跨域中间件
-func CORS() gin.HandlerFunc {
+// 只处理带 Origin 的请求：来源在白名单中时回显该来源（允许任意来源且不带凭据时为 *），不在白名单中时不加跨域响应头，由浏览器拦截；
+// 预检请求（带 Access-Control-Request-Method 的 OPTIONS）只对存在的路由和允许的方法返回 204，来源不允许时返回 403，路由不存在时照常 404。
+// 响应随 Origin 变化，始终带 Vary: Origin，避免缓存把一个来源的响应返回给另一个来源。
+// routes 返回已注册的路由，在第一次预检请求时读取，路由应在处理请求之前注册完
+func CORS(policy *CORSPolicy, routes func() gin.RoutesInfo) gin.HandlerFunc {
+	var (
+		once    sync.Once
+		matcher routeMatcher
+	)
 	return func(c *gin.Context) {
-		// 设置跨域响应头
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
-
-		// 处理 OPTIONS 预检请求
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
 			return
 		}
 
-		c.Next()
+		requestMethod := c.GetHeader("Access-Control-Request-Method")
+		preflight := c.Request.Method == http.MethodOptions && requestMethod != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !policy.AllowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if policy.anyOrigin && !policy.allowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		once.Do(func() { matcher = newRouteMatcher(routes()) })
+		if !matcher.match(requestMethod, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if !policy.allowedMethods[requestMethod] {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		h.Set("Access-Control-Allow-Methods", policy.allowMethods)
+		h.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		h.Set("Access-Control-Max-Age", policy.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
 	}
 }
 
-// Logger 请求日志中间件
+// routeMatcher 按方法和路径匹配已注册的路由模板（:param 匹配一段，*param 匹配其余部分）
+type routeMatcher map[string][][]string
+
+func newRouteMatcher(routes gin.RoutesInfo) routeMatcher {
+	m := make(routeMatcher)
+	for _, route := range routes {
+		m[route.Method] = append(m[route.Method], strings.Split(route.Path, "/"))
+	}
+	return m
+}
+
+func (m routeMatcher) match(method, path string) bool {
+	segments := strings.Split(path, "/")
+	for _, template := range m[method] {
+		if matchSegments(template, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(template, segments []string) bool {
+	for i, t := range template {
+		if strings.HasPrefix(t, "*") {
+			return true
+		}
+		if i >= len(segments) || (t != segments[i] && !(strings.HasPrefix(t, ":") && segments[i] != "")) {
+			return false
+		}
+	}
+	return len(template) == len(segments)
+}
+
+// Logger 请求日志中间件，每个请求一条结构化日志：5xx 记为 ERROR，4xx 记为 WARN，其余记为 INFO
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 开始时间
@@ -35,33 +201,28 @@ func Logger() gin.HandlerFunc {
 		// 处理请求
 		c.Next()
 
-		// 结束时间
-		endTime := time.Now()
-
-		// 执行时间
-		latencyTime := endTime.Sub(startTime)
-
-		// 请求方式
-		reqMethod := c.Request.Method
-
-		// 请求路由
-		reqUri := c.Request.RequestURI
-
-		// 状态码
 		statusCode := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case statusCode >= 500:
+			level = slog.LevelError
+		case statusCode >= 400:
+			level = slog.LevelWarn
+		}
 
-		// 请求IP
-		clientIP := c.ClientIP()
-
-		// 日志格式
-		log.Printf("[%s] %s | %3d | %13v | %15s | %s",
-			time.Now().Format("2006-01-02 15:04:05"),
-			reqMethod,
-			statusCode,
-			latencyTime,
-			clientIP,
-			reqUri,
-		)
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.RequestURI),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", statusCode),
+			slog.Float64("latency_ms", float64(time.Since(startTime).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
 	}
 }
 
@@ -71,14 +232,11 @@ func Recovery() gin.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				// 记录错误日志
-				log.Printf("[Recovery] panic recovered:\n%v", err)
+				slog.ErrorContext(c.Request.Context(), "panic recovered", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+				metrics.Panics.Inc()
 
 				// 返回 500 错误
-				c.JSON(500, gin.H{
-					"code":    500,
-					"message": "Internal Server Error",
-					"data":    nil,
-				})
+				utils.Fail(c, customerrors.ErrInternal)
 
 				// 终止后续处理
 				c.Abort()
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation 为已弃用的接口加上响应头，接口本身照常处理
+// Deprecation（RFC 9745）为弃用时间，Sunset（RFC 8594）为计划下线时间，Link 指向替代的接口版本
+func Deprecation(deprecatedAt, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetValue := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", sunsetValue)
+		header.Add("Link", link)
+		c.Next()
+	}
+}
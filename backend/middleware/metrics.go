@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"backend/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 请求指标中间件，按路由模板（如 /api/todos/:id）而不是实际路径统计，避免标签取值无限增长
+// 需要放在 Recovery 之前，panic 的请求才能以 500 计入
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		method := metricsMethod(c.Request.Method)
+		metrics.HTTPRequests.WithLabelValues(route, method, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// knownMethods 作为指标标签的请求方法，其他方法（客户端可以随意构造）都记为 OTHER，避免标签取值无限增长
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+func metricsMethod(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
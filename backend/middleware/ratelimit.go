@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	customerrors "backend/errors"
+	"backend/metrics"
+	"backend/ratelimit"
+	"backend/utils"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy 为请求选择限流策略，返回策略名和规则；规则未启用（零值）时不限流
+type RateLimitPolicy func(c *gin.Context) (name string, limit ratelimit.Limit)
+
+// RateLimit 限流中间件：按策略和客户端分别计数，响应中带有 RateLimit-Limit、RateLimit-Remaining、
+// RateLimit-Reset（秒）和 RateLimit-Policy 响应头；超过限制时返回 429 RATE_LIMITED 和 Retry-After（秒）
+// charges 为业务层按操作计数的策略（ratelimit.Charge），与路由级的同名策略共用一个桶
+// 存储出错时放行，限流不可用不应导致整个服务不可用
+func RateLimit(store ratelimit.Store, policy RateLimitPolicy, charges map[string]ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ratelimit.WithCharger(c.Request.Context(), charger(store, charges, ClientKey(c))))
+
+		name, limit := policy(c)
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(c.Request.Context(), name+"|"+ClientKey(c), limit)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limit store unavailable", "policy", name, "error", err)
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)))
+		if !result.Allowed {
+			retryAfter := max(seconds(result.RetryAfter), 1)
+			h.Set("Retry-After", strconv.Itoa(retryAfter))
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			utils.Fail(c, customerrors.ErrRateLimited.WithDetails("retry_after", retryAfter))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// charger 业务层按操作计数，被拒绝时返回带 retry_after 的 RATE_LIMITED
+// 不引用 gin.Context：WebSocket 连接上的消息在中间件返回之后仍会计数
+func charger(store ratelimit.Store, limits map[string]ratelimit.Limit, key string) ratelimit.Charger {
+	return func(ctx context.Context, name string) error {
+		limit := limits[name]
+		if !limit.Enabled() {
+			return nil
+		}
+		result, err := store.Take(ctx, name+"|"+key, limit)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit store unavailable", "policy", name, "error", err)
+			return nil
+		}
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			return customerrors.ErrRateLimited.WithDetails("retry_after", max(seconds(result.RetryAfter), 1))
+		}
+		return nil
+	}
+}
+
+// ClientKey 限流的键：已登录用户 > 客户端 IP
+// 只使用认证中间件校验过的身份，未经校验的 Authorization 不作为键，否则客户端每次换一个令牌就能绕过限流
+func ClientKey(c *gin.Context) string {
+	if id, ok := c.Get(UserIDKey); ok {
+		return fmt.Sprintf("user:%v", id)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID 请求 ID 中间件：沿用上游（网关、客户端）传入的 X-Request-ID，没有或不合法时生成一个，
+// 写入响应头，并放入请求的上下文中，之后 Service 和 GORM 的日志都带有同一个 request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
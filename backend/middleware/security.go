@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"backend/config"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders 安全响应头：
+// X-Content-Type-Options 禁止浏览器猜测内容类型，X-Frame-Options 和 CSP 的 frame-ancestors 禁止被其他网站嵌入（点击劫持），
+// Referrer-Policy 跨站时只发送来源，Strict-Transport-Security 只在 HTTPS 请求上发送，浏览器会忽略 HTTP 响应中的 HSTS。
+// 代理终结 TLS 时，只有来自可信代理（trustedProxies，与 TODO_TRUSTED_PROXIES 相同）的 X-Forwarded-Proto 才算数，
+// 否则任何客户端都能让明文响应带上 HSTS。
+// 处理函数可以覆盖 CSP，如 API 文档页面使用内联脚本的哈希
+func SecurityHeaders(cfg *config.SecurityConfig, trustedProxies []string) gin.HandlerFunc {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+	}
+	proxies := parseProxies(trustedProxies)
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		if cfg.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		if cfg.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+		}
+		if hsts != "" && (c.Request.TLS != nil || (c.GetHeader("X-Forwarded-Proto") == "https" && fromProxy(proxies, c.RemoteIP()))) {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
+
+// parseProxies 解析可信代理的 IP 或 CIDR；与 gin 的 SetTrustedProxies 一致，有无法解析的项时不信任任何代理
+func parseProxies(list []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, item := range list {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// fromProxy 直接连接的对端是否为可信代理
+func fromProxy(proxies []netip.Prefix, remoteIP string) bool {
+	addr, err := netip.ParseAddr(remoteIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
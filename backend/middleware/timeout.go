@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout 为请求的上下文设置处理时限，Service 和 Model 的数据库查询随之中断，返回 504 REQUEST_TIMEOUT
+// 处理函数仍在原协程中执行，只是查询会提前返回错误，不会出现超时后再写响应的竞争；
+// exempt 中的路由模板（SSE、WebSocket 等长连接）和转发到前端开发服务器的 WebSocket 不设时限。d 为 0 时不限制
+func Timeout(d time.Duration, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		skip[route] = true
+	}
+	return func(c *gin.Context) {
+		if d <= 0 || skip[c.FullPath()] || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,255 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 数据库结构迁移
+// 迁移脚本位于 sql/ 目录，文件名为 <版本号>_<名称>.up.sql 和对应的 .down.sql，编译时嵌入二进制；
+// 已执行的版本记录在 schema_migrations 表中。MySQL 的 DDL 会隐式提交，脚本中的语句逐条执行，
+// 执行到一半失败时需要修复后手工处理，所以每个脚本都应尽量写成可重复执行的（IF NOT EXISTS / IF EXISTS）
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration 一个迁移版本
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移版本及其执行情况
+type Status struct {
+	Migration
+	AppliedAt *time.Time // 为空表示尚未执行
+}
+
+// schemaMigration schema_migrations 表中的一行
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// All 按版本号升序返回所有迁移
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		versionStr, title, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", name)
+		}
+		content, err := files.ReadFile("sql/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up script", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// cutDirection 拆分 0001_initial.up.sql 为 0001_initial 和 up
+func cutDirection(name string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// List 返回所有迁移的执行情况
+func List(db *gorm.DB) ([]Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = Status{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的迁移
+func Pending(db *gorm.DB) ([]Migration, error) {
+	statuses, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Latest 返回最新的迁移版本号，即数据库应处于的版本
+func Latest() (int64, error) {
+	migrations, err := All()
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// ErrNotAdopted schema_migrations 表不存在：数据库是引入版本化迁移之前建的，还没有执行过 migrate up
+var ErrNotAdopted = errors.New("schema_migrations table does not exist")
+
+// Current 返回数据库已执行的最大版本号，只读查询，schema_migrations 表不存在时返回 ErrNotAdopted
+func Current(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return 0, ErrNotAdopted
+	}
+	var version int64
+	err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Up 按版本号升序执行尚未执行的迁移，steps 为 0 时执行全部，返回执行了的迁移
+func Up(db *gorm.DB, steps int) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		if err := execScript(db, m.Up); err != nil {
+			return done, fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+		}
+		if err := db.Create(&schemaMigration{Version: m.Version, Name: m.Name}).Error; err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down 按版本号降序回滚最近执行的 steps 个迁移，返回回滚了的迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	statuses, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		m := statuses[i]
+		if m.AppliedAt == nil {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migration %04d_%s has no down script", m.Version, m.Name)
+		}
+		if err := execScript(db, m.Down); err != nil {
+			return done, fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+		}
+		if err := db.Delete(&schemaMigration{}, m.Version).Error; err != nil {
+			return done, err
+		}
+		done = append(done, m.Migration)
+	}
+	return done, nil
+}
+
+// appliedVersions 读取已执行的版本，schema_migrations 表不存在时先创建
+func appliedVersions(db *gorm.DB) (map[int64]schemaMigration, error) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// execScript 逐条执行脚本中的语句；以 -- 开头的行是注释，语句以分号结尾
+func execScript(db *gorm.DB, script string) error {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt == "" {
+			continue
+		}
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
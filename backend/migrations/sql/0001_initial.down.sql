@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS outbox_cursors;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS sync_counters;
+DROP TABLE IF EXISTS todos;
@@ -0,0 +1,72 @@
+-- 初始表结构，与 DOC.md 中手工执行的建表语句相同
+-- 使用 IF NOT EXISTS 并把索引写在建表语句中：已经手工建好表的数据库执行 migrate up 时跳过这一步，只记录版本
+CREATE TABLE IF NOT EXISTS todos (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    category ENUM('work', 'study', 'life') DEFAULT 'life',
+    priority INT DEFAULT 0,
+    completed BOOLEAN DEFAULT FALSE,
+    version INT DEFAULT 0,
+    change_seq BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_category (category),
+    INDEX idx_priority (priority),
+    INDEX idx_completed (completed),
+    INDEX idx_todos_change_seq (change_seq),
+    INDEX idx_todos_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS sync_counters (
+    name VARCHAR(64) PRIMARY KEY,
+    value BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    url VARCHAR(2048) NOT NULL,
+    events VARCHAR(255) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    description VARCHAR(255),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    subscription_id BIGINT NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    locked_until TIMESTAMP(3) NULL DEFAULT NULL,
+    response_code INT DEFAULT 0,
+    last_error VARCHAR(1024),
+    delivered_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_webhook_deliveries_subscription (subscription_id),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    seq BIGINT PRIMARY KEY,
+    aggregate_id BIGINT NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    version INT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX idx_outbox_events_aggregate_id (aggregate_id)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_cursors (
+    sink VARCHAR(64) PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    owner VARCHAR(64),
+    locked_until TIMESTAMP(3) NULL DEFAULT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+-- 登录会话，Cookie 会话和 Bearer 令牌共用；只保存令牌的 SHA-256 哈希，数据库泄露时令牌不能直接使用
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    token_hash CHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_sessions_token_hash (token_hash),
+    INDEX idx_sessions_user_id (user_id)
+);
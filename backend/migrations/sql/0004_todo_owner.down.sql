@@ -0,0 +1,2 @@
+DROP INDEX idx_todos_owner_id ON todos;
+ALTER TABLE todos DROP COLUMN owner_id;
@@ -0,0 +1,3 @@
+-- 待办事项的创建者：登录用户创建的记录保存用户 ID，匿名创建和已有的记录为空
+ALTER TABLE todos ADD COLUMN owner_id BIGINT NULL;
+CREATE INDEX idx_todos_owner_id ON todos (owner_id);
@@ -0,0 +1,14 @@
+-- 待办事项的变更历史：与发件箱事件在同一个事务中写入，不随发件箱的保留期限清理，
+-- 只在待办事项的墓碑被清理时一起删除
+CREATE TABLE IF NOT EXISTS todo_history (
+    seq BIGINT PRIMARY KEY,
+    todo_id BIGINT NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    version INT NOT NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    INDEX idx_todo_history_todo_id (todo_id)
+);
+
+-- 发件箱中还保留的事件作为已有的历史
+INSERT INTO todo_history (seq, todo_id, event_type, version, created_at)
+SELECT seq, aggregate_id, event_type, version, created_at FROM outbox_events;
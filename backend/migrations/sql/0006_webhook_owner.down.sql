@@ -0,0 +1,2 @@
+DROP INDEX idx_webhook_subscriptions_owner_id ON webhook_subscriptions;
+ALTER TABLE webhook_subscriptions DROP COLUMN owner_id;
@@ -0,0 +1,3 @@
+-- Webhook 订阅的创建者：用户只能管理自己创建的订阅，迁移前已有的订阅为空，只有管理员可以管理
+ALTER TABLE webhook_subscriptions ADD COLUMN owner_id BIGINT NULL;
+CREATE INDEX idx_webhook_subscriptions_owner_id ON webhook_subscriptions (owner_id);
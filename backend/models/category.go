@@ -0,0 +1,35 @@
+package models
+
+// Category 分类。v1 接口直接使用名称（work、study、life），v2 接口使用 ID
+// 分类目前是固定的，ID 一经发布不能修改
+type Category struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// Categories 所有分类，顺序即 ID 顺序
+var Categories = []Category{
+	{ID: 1, Name: "work"},
+	{ID: 2, Name: "study"},
+	{ID: 3, Name: "life"},
+}
+
+// CategoryByID 根据 ID 查找分类
+func CategoryByID(id uint) (Category, bool) {
+	for _, c := range Categories {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
+
+// CategoryByName 根据名称查找分类
+func CategoryByName(name string) (Category, bool) {
+	for _, c := range Categories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
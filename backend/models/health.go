@@ -0,0 +1,38 @@
+package models
+
+import (
+	"backend/config"
+	"backend/migrations"
+	"context"
+)
+
+// PingDB 检查数据库连接是否可用
+func PingDB(ctx context.Context) error {
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// SchemaVersion 数据库已执行的最大迁移版本号
+func SchemaVersion(ctx context.Context) (int64, error) {
+	return migrations.Current(config.DB.WithContext(ctx))
+}
+
+// TodoCount 按分类和完成状态统计的待办事项数量
+type TodoCount struct {
+	Category  string
+	Completed bool
+	Count     int64
+}
+
+// CountByCategoryAndStatus 按分类和完成状态统计未删除的待办事项数量
+func CountByCategoryAndStatus(ctx context.Context) ([]TodoCount, error) {
+	var counts []TodoCount
+	err := config.DB.WithContext(ctx).Model(&Todo{}).
+		Select("category, completed, COUNT(*) AS count").
+		Group("category, completed").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TodoHistory 待办事项的一条变更历史
+// 与发件箱事件同时写入、序号相同，但不随发件箱的保留期限清理，待办事项的墓碑被清理时才一起删除
+type TodoHistory struct {
+	Seq       int64     `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	TodoID    uint      `gorm:"not null;index" json:"todo_id"`
+	EventType string    `gorm:"type:varchar(32);not null" json:"event_type"` // 领域事件类型，如 TodoCreated
+	Version   int       `gorm:"not null" json:"version"`                     // 变更后待办事项的版本号
+	CreatedAt time.Time `gorm:"type:timestamp(3);autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (TodoHistory) TableName() string {
+	return "todo_history"
+}
+
+// GetTodoHistories 一次查询多个待办事项的变更历史，按待办事项分组，组内按序号升序
+func GetTodoHistories(ctx context.Context, ids []uint) (map[uint][]TodoHistory, error) {
+	result := make(map[uint][]TodoHistory, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var histories []TodoHistory
+	if err := config.DB.WithContext(ctx).Where("todo_id IN ?", ids).Order("seq ASC").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range histories {
+		result[h.TodoID] = append(result[h.TodoID], h)
+	}
+	return result, nil
+}
+
+// purgeTodoHistories 删除已清理的墓碑的历史，todos 为选出这些墓碑的查询
+func purgeTodoHistories(tx *gorm.DB, todos *gorm.DB) error {
+	return tx.Where("todo_id IN (?)", todos.Select("id")).Delete(&TodoHistory{}).Error
+}
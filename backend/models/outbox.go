@@ -0,0 +1,158 @@
+package models
+
+import (
+	"backend/config"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 领域事件类型
+const (
+	EventTodoCreated   = "TodoCreated"
+	EventTodoUpdated   = "TodoUpdated"   // 修改了标题、描述、分类或优先级
+	EventTodoCompleted = "TodoCompleted" // 从未完成变为已完成
+	EventTodoReopened  = "TodoReopened"  // 从已完成变为未完成
+	EventTodoDeleted   = "TodoDeleted"
+)
+
+// OutboxEvent 事务发件箱中的领域事件
+// 与数据变更在同一个事务中写入：要么都提交，要么都回滚，进程中途退出不会丢失或多出事件。
+// 主键直接使用变更序号，序号与提交顺序一致且没有未提交的空洞，投递方按序号顺序读取即可
+type OutboxEvent struct {
+	Seq         int64     `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	AggregateID uint      `gorm:"not null;index" json:"aggregate_id"` // 待办事项 ID
+	EventType   string    `gorm:"type:varchar(32);not null" json:"event_type"`
+	Version     int       `gorm:"not null" json:"version"`           // 事件发生后待办事项的版本号
+	Payload     string    `gorm:"type:text;not null" json:"payload"` // 事件发生后待办事项的 JSON 快照
+	CreatedAt   time.Time `gorm:"type:timestamp(3);autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// Todo 解析事件携带的待办事项快照
+func (e *OutboxEvent) Todo() (*Todo, error) {
+	var todo Todo
+	if err := json.Unmarshal([]byte(e.Payload), &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// OutboxCursor 共享投递目标的消费进度
+// 多个实例共用同一个游标，通过租约保证同一时间只有一个实例在投递，实例崩溃后租约到期由其他实例接手
+type OutboxCursor struct {
+	Sink        string     `gorm:"primaryKey;type:varchar(64)" json:"sink"`
+	LastSeq     int64      `gorm:"not null;default:0" json:"last_seq"` // 已成功投递的最大序号
+	Owner       string     `gorm:"type:varchar(64)" json:"owner"`      // 持有租约的实例
+	LockedUntil *time.Time `gorm:"type:timestamp(3)" json:"locked_until"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (OutboxCursor) TableName() string {
+	return "outbox_cursors"
+}
+
+// writeOutbox 在当前事务中写入领域事件，同时记入待办事项的变更历史
+func writeOutbox(tx *gorm.DB, seq int64, eventType string, todo *Todo) error {
+	payload, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	if err := tx.Create(&OutboxEvent{
+		Seq:         seq,
+		AggregateID: todo.ID,
+		EventType:   eventType,
+		Version:     todo.Version,
+		Payload:     string(payload),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&TodoHistory{
+		Seq:       seq,
+		TodoID:    todo.ID,
+		EventType: eventType,
+		Version:   todo.Version,
+	}).Error
+}
+
+// GetOutboxEventsAfter 获取序号大于 after 的事件，按序号升序
+func GetOutboxEventsAfter(after int64, limit int) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+	err := config.DB.
+		Where("seq > ?", after).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// ClaimOutboxCursor 获取或续期投递目标的租约，返回游标和是否成功持有
+// 游标不存在时以 start 为初始进度创建
+func ClaimOutboxCursor(sink, owner string, start int64, now time.Time, lease time.Duration) (*OutboxCursor, bool, error) {
+	if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&OutboxCursor{Sink: sink, LastSeq: start}).Error; err != nil {
+		return nil, false, err
+	}
+
+	// 续期时值可能不变导致影响行数为 0，所以更新后重新读取，以 owner 判断是否持有
+	err := config.DB.Model(&OutboxCursor{}).
+		Where("sink = ?", sink).
+		Where("owner = ? OR locked_until IS NULL OR locked_until < ?", owner, now).
+		Updates(map[string]interface{}{
+			"owner":        owner,
+			"locked_until": now.Add(lease),
+		}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cursor OutboxCursor
+	if err := config.DB.Where("sink = ?", sink).First(&cursor).Error; err != nil {
+		return nil, false, err
+	}
+	return &cursor, cursor.Owner == owner, nil
+}
+
+// AdvanceOutboxCursor 保存投递进度，租约已被其他实例接手时返回 false
+func AdvanceOutboxCursor(sink, owner string, seq int64) (bool, error) {
+	result := config.DB.Model(&OutboxCursor{}).
+		Where("sink = ? AND owner = ?", sink, owner).
+		Update("last_seq", seq)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReleaseOutboxCursor 释放租约，其他实例可以立即接手
+func ReleaseOutboxCursor(sink, owner string) error {
+	return config.DB.Model(&OutboxCursor{}).
+		Where("sink = ? AND owner = ?", sink, owner).
+		Update("locked_until", nil).Error
+}
+
+// LatestOutboxSeq 获取最新事件的序号，没有事件时返回 0
+func LatestOutboxSeq() (int64, error) {
+	var seq int64
+	err := config.DB.Model(&OutboxEvent{}).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// PurgeOutboxEvents 删除早于 before 且所有共享投递目标都已处理过的事件，返回删除条数
+func PurgeOutboxEvents(before time.Time) (int64, error) {
+	var minSeq *int64
+	if err := config.DB.Model(&OutboxCursor{}).Select("MIN(last_seq)").Scan(&minSeq).Error; err != nil {
+		return 0, err
+	}
+
+	query := config.DB.Where("created_at < ?", before)
+	if minSeq != nil {
+		query = query.Where("seq <= ?", *minSeq)
+	}
+	result := query.Delete(&OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"time"
+)
+
+// Session 登录会话，TokenHash 为令牌的 SHA-256（十六进制）
+type Session struct {
+	ID        uint      `gorm:"primaryKey"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex:idx_sessions_token_hash"`
+	UserID    uint      `gorm:"not null;index:idx_sessions_user_id"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// Create 创建会话
+func (s *Session) Create(ctx context.Context) error {
+	return config.DB.WithContext(ctx).Create(s).Error
+}
+
+// GetSessionUser 查询未过期的会话所属的用户，会话不存在或已过期时返回 gorm.ErrRecordNotFound
+func GetSessionUser(ctx context.Context, tokenHash string, now time.Time) (*User, error) {
+	var user User
+	err := config.DB.WithContext(ctx).
+		Joins("JOIN sessions ON sessions.user_id = users.id").
+		Where("sessions.token_hash = ? AND sessions.expires_at > ?", tokenHash, now).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteSession 删除会话（退出登录）
+func DeleteSession(ctx context.Context, tokenHash string) error {
+	return config.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&Session{}).Error
+}
+
+// DeleteExpiredSessions 删除用户已过期的会话，登录时顺便清理
+func DeleteExpiredSessions(ctx context.Context, userID uint, now time.Time) error {
+	return config.DB.WithContext(ctx).Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&Session{}).Error
+}
+
+// GetUserByUsername 按用户名查询用户
+func GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+	if err := config.DB.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// todoSeqName sync_counters 中待办事项变更序号的名称
+const todoSeqName = "todos"
+
+// todoPurgedSeqName sync_counters 中已清理墓碑的最大变更序号
+// 同步位置小于它的客户端可能漏掉被清理的删除，只能重新拉取全量快照
+const todoPurgedSeqName = "todos_purged"
+
+// SyncCounter 变更序号计数器
+// 不使用自增主键作为序号：TiDB 的自增 ID 按节点分段分配，只保证唯一不保证递增。
+// 计数器行在事务内加锁递增，持锁直到提交，所以序号的大小顺序与提交顺序一致，
+// 客户端拿到序号 N 后，所有 <= N 的变更都已经提交，不会漏掉
+type SyncCounter struct {
+	Name  string `gorm:"primaryKey;type:varchar(64)"`
+	Value int64  `gorm:"not null;default:0"`
+}
+
+// TableName 指定表名
+func (SyncCounter) TableName() string {
+	return "sync_counters"
+}
+
+// nextChangeSeq 在当前事务中分配下一个变更序号（单调递增，回滚的事务不占用序号）
+func nextChangeSeq(tx *gorm.DB) (int64, error) {
+	result := tx.Model(&SyncCounter{}).
+		Where("name = ?", todoSeqName).
+		Update("value", gorm.Expr("value + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	// 计数器行不存在时初始化（首次运行）
+	if result.RowsAffected == 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&SyncCounter{Name: todoSeqName, Value: 0}).Error; err != nil {
+			return 0, err
+		}
+		return nextChangeSeq(tx)
+	}
+
+	var counter SyncCounter
+	if err := tx.Where("name = ?", todoSeqName).First(&counter).Error; err != nil {
+		return 0, err
+	}
+	return counter.Value, nil
+}
+
+// CurrentChangeSeq 获取已提交的最大变更序号
+func CurrentChangeSeq(ctx context.Context) (int64, error) {
+	var counter SyncCounter
+	err := config.DB.WithContext(ctx).Where("name = ?", todoSeqName).Limit(1).Find(&counter).Error
+	return counter.Value, err
+}
+
+// PurgedChangeSeq 获取已清理墓碑的最大变更序号，没有清理过时为 0
+func PurgedChangeSeq(ctx context.Context) (int64, error) {
+	var counter SyncCounter
+	err := config.DB.WithContext(ctx).Where("name = ?", todoPurgedSeqName).Limit(1).Find(&counter).Error
+	return counter.Value, err
+}
+
+// PurgeDeleted 彻底删除 before 之前软删除的墓碑记录，返回删除的条数
+// 在同一个事务中记录被删除墓碑的最大变更序号，供同步接口判断客户端是否需要全量刷新
+func PurgeDeleted(before time.Time) (int64, error) {
+	var purged int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Unscoped().Model(&Todo{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+
+		var maxSeq int64
+		if err := query.Select("COALESCE(MAX(change_seq), 0)").Scan(&maxSeq).Error; err != nil {
+			return err
+		}
+		if maxSeq == 0 {
+			return nil
+		}
+
+		// 先删除历史：删除墓碑后就无法按条件选出它们的 ID
+		if err := purgeTodoHistories(tx, tx.Unscoped().Model(&Todo{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before)); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&Todo{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+
+		// 只增不减：之前清理过更大序号的墓碑时保持不变
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&SyncCounter{Name: todoPurgedSeqName, Value: 0}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&SyncCounter{}).
+			Where("name = ? AND value < ?", todoPurgedSeqName, maxSeq).
+			Update("value", maxSeq).Error
+	})
+	return purged, err
+}
+
+// GetChangesSince 获取变更序号大于 since 的记录（包含已删除的墓碑记录），按序号升序
+func GetChangesSince(ctx context.Context, since int64, limit int) ([]Todo, error) {
+	var todos []Todo
+	err := config.DB.WithContext(ctx).Unscoped().
+		Where("change_seq > ?", since).
+		Order("change_seq ASC").
+		Limit(limit).
+		Find(&todos).Error
+	return todos, err
+}
+
+// GetAllLive 获取所有未删除的记录，用于首次同步的全量快照
+func GetAllLive(ctx context.Context) ([]Todo, error) {
+	var todos []Todo
+	err := config.DB.WithContext(ctx).Order("change_seq ASC, id ASC").Find(&todos).Error
+	return todos, err
+}
+
+// SyncPushInput 上传离线修改的输入结构
+type SyncPushInput struct {
+	Changes []SyncChangeInput `json:"changes" binding:"required,dive"`
+}
+
+// SyncChangeInput 单条离线修改
+// 按 op 使用不同字段：create 使用 title/description/category/priority；
+// update 使用 title/description/category/priority；status 使用 completed；delete 只需要 id
+type SyncChangeInput struct {
+	ClientID    string `json:"client_id"` // 客户端生成的标识，原样返回，用于匹配结果（如离线新建的临时 ID）
+	Op          string `json:"op" binding:"required,oneof=create update status delete"`
+	ID          uint   `json:"id"`           // 服务端 ID，create 时为空
+	BaseVersion int    `json:"base_version"` // 客户端修改时基于的版本号
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Priority    int    `json:"priority"`
+	Completed   bool   `json:"completed"`
+}
+
+// CountDeleted 统计 before 之前软删除的墓碑记录数，供清理前预览
+func CountDeleted(before time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Unscoped().Model(&Todo{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Count(&count).Error
+	return count, err
+}
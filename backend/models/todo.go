@@ -3,6 +3,7 @@ package models
 import (
 	"backend/config"
 	customerrors "backend/errors"
+	"context"
 	"errors"
 	"time"
 
@@ -18,8 +19,13 @@ type Todo struct {
 	Priority    int       `gorm:"default:0" json:"priority" binding:"omitempty,min=0,max=5"`
 	Completed   bool      `gorm:"default:false" json:"completed"`
 	Version     int       `gorm:"default:0" json:"version"`
+	ChangeSeq   int64     `gorm:"default:0;index" json:"change_seq"` // 最后一次变更的全局序号，用于增量同步
+	OwnerID     *uint     `gorm:"index" json:"owner_id,omitempty"`   // 创建者的用户 ID，匿名创建的为空
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 软删除：删除后保留为墓碑记录，供离线客户端同步删除操作
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 指定表名
@@ -50,41 +56,81 @@ type UpdateTodoInput struct {
 	Version     int    `json:"version" binding:"gte=0"` // 版本号必须 >= 0
 }
 
+// CountLiveByOwner 统计某个用户创建的未删除待办事项数量
+func CountLiveByOwner(ctx context.Context, ownerID uint) (int64, error) {
+	var count int64
+	err := config.DB.WithContext(ctx).Model(&Todo{}).Where("owner_id = ?", ownerID).Count(&count).Error
+	return count, err
+}
+
+// CountLiveAnonymous 统计匿名创建（没有创建者）的未删除待办事项数量
+func CountLiveAnonymous(ctx context.Context) (int64, error) {
+	var count int64
+	err := config.DB.WithContext(ctx).Model(&Todo{}).Where("owner_id IS NULL").Count(&count).Error
+	return count, err
+}
+
 // Create 创建待办事项
 // 11.22调整：默认值在Service层设置，这里只负责数据库操作
-func (t *Todo) Create() error {
-	result := config.DB.Create(t)
-	return result.Error
+// 与领域事件 TodoCreated 在同一个事务中写入
+func (t *Todo) Create(ctx context.Context) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextChangeSeq(tx)
+		if err != nil {
+			return err
+		}
+		t.ChangeSeq = seq
+		if err := tx.Create(t).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, seq, EventTodoCreated, t)
+	})
 }
 
 // GetAll 获取所有待办事项
 // 支持按分类筛选和排序
-func GetAll(category string, sortBy string) ([]Todo, error) {
+func GetAll(ctx context.Context, category string, sortBy string) ([]Todo, error) {
 	var todos []Todo
-	query := config.DB.Model(&Todo{})
+	err := listQuery(ctx, category, sortBy).Find(&todos).Error
+	return todos, err
+}
+
+// GetPage 分页获取待办事项，筛选和排序与 GetAll 相同，同时返回符合条件的总数
+func GetPage(ctx context.Context, category string, sortBy string, offset, limit int) ([]Todo, int64, error) {
+	var total int64
+	if err := listQuery(ctx, category, sortBy).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var todos []Todo
+	err := listQuery(ctx, category, sortBy).Offset(offset).Limit(limit).Find(&todos).Error
+	return todos, total, err
+}
+
+// listQuery 列表查询的筛选和排序条件
+func listQuery(ctx context.Context, category string, sortBy string) *gorm.DB {
+	query := config.DB.WithContext(ctx).Model(&Todo{})
 
 	// 分类筛选
 	if category != "" && category != "all" {
 		query = query.Where("category = ?", category)
 	}
 
-	// 排序
+	// 排序，最后按 ID 排序保证分页时顺序稳定
 	switch sortBy {
 	case "priority":
-		query = query.Order("priority DESC, created_at DESC")
+		query = query.Order("priority DESC, created_at DESC, id DESC")
 	default:
 		// 默认按创建时间降序（包括 sortBy="created_at" 和空值的情况）
-		query = query.Order("created_at DESC")
+		query = query.Order("created_at DESC, id DESC")
 	}
-
-	err := query.Find(&todos).Error
-	return todos, err
+	return query
 }
 
 // GetByID 根据ID获取待办事项
-func GetByID(id uint) (*Todo, error) {
+func GetByID(ctx context.Context, id uint) (*Todo, error) {
 	var todo Todo
-	result := config.DB.First(&todo, id)
+	result := config.DB.WithContext(ctx).First(&todo, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, customerrors.ErrTodoNotFound
@@ -96,61 +142,128 @@ func GetByID(id uint) (*Todo, error) {
 
 // Update 更新待办事项（带乐观锁）
 // 可以更新标题、描述、分类、优先级
-func (t *Todo) Update(id uint, title, description, category string, priority, version int) error {
-	result := config.DB.Model(&Todo{}).
-		Where("id = ? AND version = ?", id, version). // 乐观锁：同时检查 id 和 version
-		Updates(map[string]interface{}{
-			"title":       title,
-			"description": description,
-			"category":    category,
-			"priority":    priority,
-			"version":     version + 1, // 版本号 +1
-		})
+func (t *Todo) Update(ctx context.Context, id uint, title, description, category string, priority, version int) error {
+	return updateWithVersion(ctx, id, version, map[string]interface{}{
+		"title":       title,
+		"description": description,
+		"category":    category,
+		"priority":    priority,
+	}, func(before, after *Todo) string {
+		return EventTodoUpdated
+	})
+}
 
-	if result.Error != nil {
-		return result.Error
-	}
+// UpdateStatus 更新完成状态（带乐观锁）
+func (t *Todo) UpdateStatus(ctx context.Context, id uint, completed bool, version int) error {
+	// 假如用户同时多设备点击更新完成状态，那么只有一个设备会成功，另一个设备在where语句查不出来
+	return updateWithVersion(ctx, id, version, map[string]interface{}{
+		"completed": completed,
+	}, func(before, after *Todo) string {
+		switch {
+		case after.Completed && !before.Completed:
+			return EventTodoCompleted
+		case !after.Completed && before.Completed:
+			return EventTodoReopened
+		default:
+			return EventTodoUpdated
+		}
+	})
+}
 
-	// 检查是否有行被更新（乐观锁检查）
-	if result.RowsAffected == 0 {
-		return customerrors.ErrVersionConflict
-	}
+// updateWithVersion 乐观锁更新：同时检查 id 和 version，版本号 +1，分配新的变更序号，
+// 并在同一个事务中写入领域事件，eventType 根据更新前后的数据决定事件类型
+func updateWithVersion(ctx context.Context, id uint, version int, fields map[string]interface{}, eventType func(before, after *Todo) string) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 读取更新前的数据，版本不匹配说明已被其他设备修改
+		var before Todo
+		if err := tx.Where("id = ? AND version = ?", id, version).Take(&before).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return customerrors.ErrVersionConflict
+			}
+			return err
+		}
 
-	return nil
-}
+		seq, err := nextChangeSeq(tx)
+		if err != nil {
+			return err
+		}
+		fields["version"] = version + 1
+		fields["change_seq"] = seq
 
-// UpdateStatus 更新完成状态（带乐观锁）
-func (t *Todo) UpdateStatus(id uint, completed bool, version int) error {
-	result := config.DB.Model(&Todo{}).
-		Where("id = ? AND version = ?", id, version). // 假如用户同时多设备点击更新完成状态，那么只有一个设备会成功，另一个设备在where语句查不出来
-		Updates(map[string]interface{}{
-			"completed": completed,
-			"version":   version + 1,
-		})
+		result := tx.Model(&Todo{}).
+			Where("id = ? AND version = ?", id, version).
+			Updates(fields)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.Error != nil {
-		return result.Error
-	}
+		// 检查是否有行被更新（乐观锁检查），查不出来就会影响行数为0
+		if result.RowsAffected == 0 {
+			return customerrors.ErrVersionConflict
+		}
 
-	// 检查是否有行被更新（乐观锁检查），查不出来就会影响行数为0
-	if result.RowsAffected == 0 {
-		return customerrors.ErrVersionConflict
-	}
+		var after Todo
+		if err := tx.First(&after, id).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, seq, eventType(&before, &after), &after)
+	})
+}
 
-	return nil
+// Delete 删除待办事项
+// 软删除：保留墓碑记录（版本号 +1，分配新的变更序号），离线客户端同步时才能得知删除
+func Delete(ctx context.Context, id uint) error {
+	return softDelete(ctx, id, nil, customerrors.ErrTodoNotFound)
 }
 
-// Delete 删除待办事项，硬删除，因为待办事项一般不需要找回
-func Delete(id uint) error {
-	result := config.DB.Delete(&Todo{}, id)
+// DeleteWithVersion 删除待办事项（带乐观锁），版本不匹配时返回版本冲突
+func DeleteWithVersion(ctx context.Context, id uint, version int) error {
+	return softDelete(ctx, id, &version, customerrors.ErrVersionConflict)
+}
 
-	if result.Error != nil {
-		return result.Error
-	}
+// softDelete 将待办事项标记为已删除并写入 TodoDeleted 事件，version 不为空时同时检查版本
+// 没有可删除的记录时返回 missing，事务回滚，不会占用变更序号
+func softDelete(ctx context.Context, id uint, version *int, missing error) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Model 带有 DeletedAt 字段，GORM 会自动追加 deleted_at IS NULL 条件，已删除的记录不会被重复删除
+		live := func() *gorm.DB {
+			query := tx.Model(&Todo{}).Where("id = ?", id)
+			if version != nil {
+				query = query.Where("version = ?", *version)
+			}
+			return query
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("todo not found")
-	}
+		// 先确认记录存在（版本匹配）再分配序号
+		var count int64
+		if err := live().Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return missing
+		}
 
-	return nil
+		seq, err := nextChangeSeq(tx)
+		if err != nil {
+			return err
+		}
+		result := live().Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+			"change_seq": seq,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		// 检查之后被并发删除或修改时返回错误回滚，序号随之撤销
+		if result.RowsAffected == 0 {
+			return missing
+		}
+
+		var deleted Todo
+		if err := tx.Unscoped().First(&deleted, id).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, seq, EventTodoDeleted, &deleted)
+	})
 }
@@ -2,6 +2,8 @@ package models
 
 import (
 	"backend/config"
+	customerrors "backend/errors"
+	"context"
 	"fmt"
 	"testing"
 )
@@ -29,7 +31,7 @@ func TestCreate(t *testing.T) {
 			Priority:    5,
 		}
 
-		err := todo.Create()
+		err := todo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -55,7 +57,7 @@ func TestCreate(t *testing.T) {
 			Priority: 3,
 		}
 
-		err := todo.Create()
+		err := todo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -74,7 +76,7 @@ func TestCreate(t *testing.T) {
 			Priority: 2,
 		}
 
-		err := todo.Create()
+		err := todo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -91,7 +93,7 @@ func TestCreate(t *testing.T) {
 // TestGetAll 测试获取所有待办事项
 func TestGetAll(t *testing.T) {
 	t.Run("获取所有待办事项（无筛选）", func(t *testing.T) {
-		todos, err := GetAll("", "")
+		todos, err := GetAll(context.Background(), "", "")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -106,7 +108,7 @@ func TestGetAll(t *testing.T) {
 	})
 
 	t.Run("按分类筛选 - work", func(t *testing.T) {
-		todos, err := GetAll("work", "")
+		todos, err := GetAll(context.Background(), "work", "")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -122,7 +124,7 @@ func TestGetAll(t *testing.T) {
 	})
 
 	t.Run("按分类筛选 - study", func(t *testing.T) {
-		todos, err := GetAll("study", "")
+		todos, err := GetAll(context.Background(), "study", "")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -138,7 +140,7 @@ func TestGetAll(t *testing.T) {
 	})
 
 	t.Run("按分类筛选 - life", func(t *testing.T) {
-		todos, err := GetAll("life", "")
+		todos, err := GetAll(context.Background(), "life", "")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -157,7 +159,7 @@ func TestGetAll(t *testing.T) {
 // TestGetAllWithSort 测试排序功能
 func TestGetAllWithSort(t *testing.T) {
 	t.Run("按优先级排序", func(t *testing.T) {
-		todos, err := GetAll("", "priority")
+		todos, err := GetAll(context.Background(), "", "priority")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -181,7 +183,7 @@ func TestGetAllWithSort(t *testing.T) {
 	})
 
 	t.Run("按创建时间排序", func(t *testing.T) {
-		todos, err := GetAll("", "created_at")
+		todos, err := GetAll(context.Background(), "", "created_at")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -203,7 +205,7 @@ func TestGetAllWithSort(t *testing.T) {
 	})
 
 	t.Run("组合：按分类筛选并按优先级排序", func(t *testing.T) {
-		todos, err := GetAll("work", "priority")
+		todos, err := GetAll(context.Background(), "work", "priority")
 		if err != nil {
 			t.Errorf("获取待办事项失败: %v", err)
 			return
@@ -239,14 +241,14 @@ func TestGetByID(t *testing.T) {
 			Category:    "work",
 			Priority:    4,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
 		}
 
 		// 查询
-		todo, err := GetByID(newTodo.ID)
+		todo, err := GetByID(context.Background(), newTodo.ID)
 		if err != nil {
 			t.Errorf("查询待办事项失败: %v", err)
 			return
@@ -263,7 +265,7 @@ func TestGetByID(t *testing.T) {
 	})
 
 	t.Run("查询不存在的待办事项", func(t *testing.T) {
-		todo, err := GetByID(999999)
+		todo, err := GetByID(context.Background(), 999999)
 		if err == nil {
 			t.Error("查询不存在的 ID 应该返回错误")
 			return
@@ -286,7 +288,7 @@ func TestUpdate(t *testing.T) {
 			Category:    "work",
 			Priority:    3,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -296,7 +298,7 @@ func TestUpdate(t *testing.T) {
 		t.Logf("创建后的版本号: %d", originalVersion)
 
 		// 更新待办事项
-		err = newTodo.Update(
+		err = newTodo.Update(context.Background(),
 			newTodo.ID,
 			"修改后的标题",
 			"修改后的描述",
@@ -310,7 +312,7 @@ func TestUpdate(t *testing.T) {
 		}
 
 		// 查询验证
-		updated, err := GetByID(newTodo.ID)
+		updated, err := GetByID(context.Background(), newTodo.ID)
 		if err != nil {
 			t.Errorf("查询失败: %v", err)
 			return
@@ -342,14 +344,14 @@ func TestUpdate(t *testing.T) {
 			Category: "work",
 			Priority: 3,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
 		}
 
 		// 第一次更新（模拟用户A）
-		err = newTodo.Update(newTodo.ID, "用户A的修改", "描述A", "study", 4, 0)
+		err = newTodo.Update(context.Background(), newTodo.ID, "用户A的修改", "描述A", "study", 4, 0)
 		if err != nil {
 			t.Errorf("第一次更新失败: %v", err)
 			return
@@ -357,7 +359,7 @@ func TestUpdate(t *testing.T) {
 		t.Log("用户A 更新成功，版本号 0 -> 1")
 
 		// 第二次更新使用旧版本号（模拟用户B使用过期的版本号）
-		err = newTodo.Update(newTodo.ID, "用户B的修改", "描述B", "life", 5, 0)
+		err = newTodo.Update(context.Background(), newTodo.ID, "用户B的修改", "描述B", "life", 5, 0)
 		if err == nil {
 			t.Error("使用过期版本号更新应该失败")
 			return
@@ -368,7 +370,7 @@ func TestUpdate(t *testing.T) {
 		}
 
 		// 验证数据没有被覆盖
-		final, _ := GetByID(newTodo.ID)
+		final, _ := GetByID(context.Background(), newTodo.ID)
 		if final.Title != "用户A的修改" {
 			t.Error("数据被错误覆盖")
 		}
@@ -386,7 +388,7 @@ func TestUpdateStatus(t *testing.T) {
 			Category: "study",
 			Priority: 3,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -396,14 +398,14 @@ func TestUpdateStatus(t *testing.T) {
 		t.Logf("创建后的版本号: %d", originalVersion)
 
 		// 更新为已完成
-		err = newTodo.UpdateStatus(newTodo.ID, true, originalVersion)
+		err = newTodo.UpdateStatus(context.Background(), newTodo.ID, true, originalVersion)
 		if err != nil {
 			t.Errorf("更新状态失败: %v", err)
 			return
 		}
 
 		// 查询验证
-		updated, err := GetByID(newTodo.ID)
+		updated, err := GetByID(context.Background(), newTodo.ID)
 		if err != nil {
 			t.Errorf("查询失败: %v", err)
 			return
@@ -426,14 +428,14 @@ func TestUpdateStatus(t *testing.T) {
 			Category: "work",
 			Priority: 5,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
 		}
 
 		// 第一次更新（模拟用户A）
-		err = newTodo.UpdateStatus(newTodo.ID, true, 0)
+		err = newTodo.UpdateStatus(context.Background(), newTodo.ID, true, 0)
 		if err != nil {
 			t.Errorf("第一次更新失败: %v", err)
 			return
@@ -441,7 +443,7 @@ func TestUpdateStatus(t *testing.T) {
 		t.Log("用户A 更新成功，版本号 0 -> 1")
 
 		// 第二次更新使用旧版本号（模拟用户B使用过期的版本号）
-		err = newTodo.UpdateStatus(newTodo.ID, false, 0)
+		err = newTodo.UpdateStatus(context.Background(), newTodo.ID, false, 0)
 		if err == nil {
 			t.Error("使用过期版本号更新应该失败")
 			return
@@ -464,7 +466,7 @@ func TestDelete(t *testing.T) {
 			Category: "life",
 			Priority: 1,
 		}
-		err := newTodo.Create()
+		err := newTodo.Create(context.Background())
 		if err != nil {
 			t.Errorf("创建待办事项失败: %v", err)
 			return
@@ -474,14 +476,14 @@ func TestDelete(t *testing.T) {
 		t.Logf("创建了 ID=%d 的待办事项", todoID)
 
 		// 删除
-		err = Delete(todoID)
+		err = Delete(context.Background(), todoID)
 		if err != nil {
 			t.Errorf("删除失败: %v", err)
 			return
 		}
 
 		// 验证已删除
-		_, err = GetByID(todoID)
+		_, err = GetByID(context.Background(), todoID)
 		if err == nil {
 			t.Error("删除后查询应该失败")
 			return
@@ -491,7 +493,7 @@ func TestDelete(t *testing.T) {
 	})
 
 	t.Run("删除不存在的待办事项", func(t *testing.T) {
-		err := Delete(999999)
+		err := Delete(context.Background(), 999999)
 		if err == nil {
 			t.Error("删除不存在的待办事项应该返回错误")
 			return
@@ -503,6 +505,34 @@ func TestDelete(t *testing.T) {
 
 		t.Logf("✅ 正确处理删除不存在的记录: %v", err)
 	})
+
+	t.Run("删除失败不占用变更序号", func(t *testing.T) {
+		ctx := context.Background()
+		todo := &Todo{Title: "序号测试", Category: "life", Priority: 1}
+		if err := todo.Create(ctx); err != nil {
+			t.Fatalf("创建待办事项失败: %v", err)
+		}
+		defer Delete(ctx, todo.ID)
+
+		seq := func() int64 {
+			var counter SyncCounter
+			if err := config.DB.Where("name = ?", todoSeqName).First(&counter).Error; err != nil {
+				t.Fatalf("读取计数器失败: %v", err)
+			}
+			return counter.Value
+		}
+		before := seq()
+		if err := Delete(ctx, 999999); err != customerrors.ErrTodoNotFound {
+			t.Fatalf("应该返回 TODO_NOT_FOUND，实际: %v", err)
+		}
+		if err := DeleteWithVersion(ctx, todo.ID, todo.Version+1); err != customerrors.ErrVersionConflict {
+			t.Fatalf("应该返回 VERSION_CONFLICT，实际: %v", err)
+		}
+		if after := seq(); after != before {
+			t.Fatalf("删除失败后计数器不应变化: %d -> %d", before, after)
+		}
+		t.Logf("✅ 计数器保持为 %d", before)
+	})
 }
 
 // TestCompleteWorkflow 测试完整工作流
@@ -515,28 +545,28 @@ func TestCompleteWorkflow(t *testing.T) {
 			Category:    "work",
 			Priority:    5,
 		}
-		err := todo.Create()
+		err := todo.Create(context.Background())
 		if err != nil {
 			t.Fatalf("❌ 创建失败: %v", err)
 		}
 		t.Logf("✅ 1. 创建成功，ID=%d, Version=%d", todo.ID, todo.Version)
 
 		// 2. 查询
-		retrieved, err := GetByID(todo.ID)
+		retrieved, err := GetByID(context.Background(), todo.ID)
 		if err != nil {
 			t.Fatalf("❌ 查询失败: %v", err)
 		}
 		t.Logf("✅ 2. 查询成功: %s", retrieved.Title)
 
 		// 3. 编辑
-		err = todo.Update(todo.ID, "修改后的标题", "修改后的描述", "study", 4, retrieved.Version)
+		err = todo.Update(context.Background(), todo.ID, "修改后的标题", "修改后的描述", "study", 4, retrieved.Version)
 		if err != nil {
 			t.Fatalf("❌ 编辑失败: %v", err)
 		}
 		t.Log("✅ 3. 编辑成功")
 
 		// 4. 验证编辑
-		edited, err := GetByID(todo.ID)
+		edited, err := GetByID(context.Background(), todo.ID)
 		if err != nil {
 			t.Fatalf("❌ 查询编辑后的记录失败: %v", err)
 		}
@@ -549,14 +579,14 @@ func TestCompleteWorkflow(t *testing.T) {
 		t.Log("✅ 4. 验证编辑成功")
 
 		// 5. 更新状态
-		err = todo.UpdateStatus(todo.ID, true, edited.Version)
+		err = todo.UpdateStatus(context.Background(), todo.ID, true, edited.Version)
 		if err != nil {
 			t.Fatalf("❌ 更新状态失败: %v", err)
 		}
 		t.Log("✅ 5. 更新状态成功")
 
 		// 6. 验证状态更新
-		statusUpdated, err := GetByID(todo.ID)
+		statusUpdated, err := GetByID(context.Background(), todo.ID)
 		if err != nil {
 			t.Fatalf("❌ 查询状态更新后的记录失败: %v", err)
 		}
@@ -569,14 +599,14 @@ func TestCompleteWorkflow(t *testing.T) {
 		t.Log("✅ 6. 验证状态更新成功")
 
 		// 7. 删除
-		err = Delete(todo.ID)
+		err = Delete(context.Background(), todo.ID)
 		if err != nil {
 			t.Fatalf("❌ 删除失败: %v", err)
 		}
 		t.Log("✅ 7. 删除成功")
 
 		// 8. 验证删除
-		_, err = GetByID(todo.ID)
+		_, err = GetByID(context.Background(), todo.ID)
 		if err == nil {
 			t.Error("❌ 删除后不应该能查询到")
 		}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"backend/config"
+	"context"
+	"time"
+)
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User 用户账号
+type User struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Username     string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_users_username" json:"username"`
+	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
+	Role         string    `gorm:"type:varchar(16);not null;default:'user'" json:"role"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// CreateUserInput 创建用户的输入结构
+type CreateUserInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"` // 为空时为 user
+}
+
+// Create 创建用户
+func (u *User) Create(ctx context.Context) error {
+	return config.DB.WithContext(ctx).Create(u).Error
+}
+
+// UsernameExists 用户名是否已被使用
+func UsernameExists(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := config.DB.WithContext(ctx).Model(&User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
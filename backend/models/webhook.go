@@ -0,0 +1,246 @@
+package models
+
+import (
+	"backend/config"
+	customerrors "backend/errors"
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook 事件类型
+const (
+	WebhookEventCreated   = "created"
+	WebhookEventCompleted = "completed"
+	WebhookEventDeleted   = "deleted"
+)
+
+// 投递状态
+const (
+	DeliveryPending   = "pending"   // 等待投递（含等待重试）
+	DeliverySucceeded = "succeeded" // 对方返回 2xx
+	DeliveryDead      = "dead"      // 重试次数用尽，进入死信，需要人工重新投递
+)
+
+// WebhookSubscription Webhook 订阅
+type WebhookSubscription struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	URL         string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Events      string    `gorm:"type:varchar(255);not null" json:"-"` // 逗号分隔的事件类型
+	Secret      string    `gorm:"type:varchar(255);not null" json:"-"` // HMAC 签名密钥，不对外返回
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	Active      bool      `gorm:"default:true" json:"active"`
+	OwnerID     *uint     `gorm:"index" json:"owner_id,omitempty"` // 创建者的用户 ID，迁移前已有的订阅为空
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	EventList []string `gorm:"-" json:"events"`
+}
+
+// TableName 指定表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// AfterFind 将逗号分隔的事件类型展开为列表
+func (s *WebhookSubscription) AfterFind(tx *gorm.DB) error {
+	s.EventList = strings.Split(s.Events, ",")
+	return nil
+}
+
+// Accepts 是否订阅了某个事件
+func (s *WebhookSubscription) Accepts(event string) bool {
+	for _, e := range strings.Split(s.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery 一次投递（持久化的投递队列）
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	Event          string     `gorm:"type:varchar(32);not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(16);not null;default:'pending';index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LockedUntil    *time.Time `json:"-"`                                    // 被某个投递协程占用的截止时间，防止多实例重复投递
+	ResponseCode   int        `gorm:"default:0" json:"response_code"`       // 最近一次投递的 HTTP 状态码，0 表示请求未完成
+	LastError      string     `gorm:"type:varchar(1024)" json:"last_error"` // 最近一次失败原因
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// CreateWebhookSubscription 创建订阅
+func CreateWebhookSubscription(ctx context.Context, s *WebhookSubscription) error {
+	return config.DB.WithContext(ctx).Create(s).Error
+}
+
+// ownedSubscriptions 只查询 ownerID 创建的订阅，ownerID 为空时（管理员）不限制
+func ownedSubscriptions(ownerID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if ownerID == nil {
+			return db
+		}
+		return db.Where("owner_id = ?", *ownerID)
+	}
+}
+
+// ownedDeliveries 只查询 ownerID 创建的订阅的投递记录，ownerID 为空时（管理员）不限制
+func ownedDeliveries(ownerID *uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if ownerID == nil {
+			return db
+		}
+		return db.Where("subscription_id IN (?)",
+			config.DB.Model(&WebhookSubscription{}).Select("id").Where("owner_id = ?", *ownerID))
+	}
+}
+
+// GetWebhookSubscriptions 获取 ownerID 创建的订阅，ownerID 为空时获取所有订阅
+func GetWebhookSubscriptions(ctx context.Context, ownerID *uint) ([]WebhookSubscription, error) {
+	var subs []WebhookSubscription
+	err := config.DB.WithContext(ctx).Scopes(ownedSubscriptions(ownerID)).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+// GetActiveWebhookSubscriptions 获取所有启用的订阅
+func GetActiveWebhookSubscriptions() ([]WebhookSubscription, error) {
+	var subs []WebhookSubscription
+	err := config.DB.Where("active = ?", true).Find(&subs).Error
+	return subs, err
+}
+
+// GetWebhookSubscriptionByID 根据ID获取订阅，不是 ownerID 创建的订阅按不存在处理
+func GetWebhookSubscriptionByID(ctx context.Context, id uint, ownerID *uint) (*WebhookSubscription, error) {
+	var sub WebhookSubscription
+	result := config.DB.WithContext(ctx).Scopes(ownedSubscriptions(ownerID)).First(&sub, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrWebhookNotFound
+		}
+		return nil, result.Error
+	}
+	return &sub, nil
+}
+
+// DeleteWebhookSubscription 删除订阅及其投递记录，不是 ownerID 创建的订阅按不存在处理
+func DeleteWebhookSubscription(ctx context.Context, id uint, ownerID *uint) error {
+	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(ownedSubscriptions(ownerID)).Delete(&WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrWebhookNotFound
+		}
+		return tx.Where("subscription_id = ?", id).Delete(&WebhookDelivery{}).Error
+	})
+}
+
+// CreateWebhookDeliveries 批量写入待投递记录
+func CreateWebhookDeliveries(deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return config.DB.Create(&deliveries).Error
+}
+
+// ClaimDueWebhookDeliveries 领取到期的待投递记录
+// 先查出候选，再逐条用条件更新抢占，保证多个实例不会同时投递同一条
+func ClaimDueWebhookDeliveries(now time.Time, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	var candidates []WebhookDelivery
+	err := config.DB.
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := now.Add(lease)
+	claimed := make([]WebhookDelivery, 0, len(candidates))
+	for _, d := range candidates {
+		result := config.DB.Model(&WebhookDelivery{}).
+			Where("id = ? AND status = ?", d.ID, DeliveryPending).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Update("locked_until", lockedUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			d.LockedUntil = &lockedUntil
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// UpdateWebhookDeliveryResult 记录投递结果并释放占用
+func UpdateWebhookDeliveryResult(d *WebhookDelivery) error {
+	return config.DB.Model(&WebhookDelivery{}).
+		Where("id = ?", d.ID).
+		Updates(map[string]interface{}{
+			"status":          d.Status,
+			"attempts":        d.Attempts,
+			"next_attempt_at": d.NextAttemptAt,
+			"response_code":   d.ResponseCode,
+			"last_error":      d.LastError,
+			"delivered_at":    d.DeliveredAt,
+			"locked_until":    nil,
+		}).Error
+}
+
+// GetWebhookDeliveries 获取 ownerID 创建的订阅最近的投递记录，subscriptionID 为 0 表示全部，status 为空表示全部状态
+func GetWebhookDeliveries(ctx context.Context, subscriptionID uint, status string, limit int, ownerID *uint) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	query := config.DB.WithContext(ctx).Model(&WebhookDelivery{}).Scopes(ownedDeliveries(ownerID))
+	if subscriptionID != 0 {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RetryWebhookDelivery 将 ownerID 创建的订阅的投递记录重新放回队列（用于人工重新投递死信）
+func RetryWebhookDelivery(ctx context.Context, id uint, now time.Time, ownerID *uint) error {
+	result := config.DB.WithContext(ctx).Model(&WebhookDelivery{}).Scopes(ownedDeliveries(ownerID)).
+		Where("id = ? AND status <> ?", id, DeliveryPending).
+		Updates(map[string]interface{}{
+			"status":          DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrDeliveryNotRetryable
+	}
+	return nil
+}
+
+// CreateWebhookInput 创建 Webhook 订阅的输入结构
+type CreateWebhookInput struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Events      []string `json:"events" binding:"required,min=1"`         // created、completed、deleted，由 Service 层校验，暂不支持的 overdue 返回单独的错误码
+	Secret      string   `json:"secret" binding:"required,min=8,max=255"` // 用于 HMAC-SHA256 签名
+	Description string   `json:"description" binding:"max=255"`
+}
@@ -0,0 +1,47 @@
+package openapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+// DocsHandler 文档页面：内嵌在二进制中，不依赖外部 CDN，从 specURL 加载 OpenAPI 文档并渲染
+func DocsHandler(specURL string) gin.HandlerFunc {
+	var buf bytes.Buffer
+	if err := docsTemplate.Execute(&buf, struct{ SpecURL string }{specURL}); err != nil {
+		panic(err)
+	}
+	page := buf.Bytes()
+	csp := docsCSP(page)
+
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", csp)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+// inlinePattern 页面中的内联脚本和样式
+var inlinePattern = regexp.MustCompile(`(?s)<(script|style)>(.*?)</(?:script|style)>`)
+
+// docsCSP 文档页面的内容安全策略：页面是静态的，按内联脚本和样式的哈希放行，不需要 'unsafe-inline'
+func docsCSP(page []byte) string {
+	sources := map[string]string{"script": "", "style": ""}
+	for _, m := range inlinePattern.FindAllSubmatch(page, -1) {
+		sum := sha256.Sum256(m[2])
+		sources[string(m[1])] += " 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+	}
+	return "default-src 'none'; script-src" + sources["script"] + "; style-src" + sources["style"] +
+		"; connect-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+}
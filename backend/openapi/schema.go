@@ -0,0 +1,225 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema JSON Schema（OpenAPI 3.1 与 JSON Schema 2020-12 一致），只包含用到的关键字
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"` // 字符串，或可空时的 [类型, "null"]
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas 根据 Go 类型生成 Schema，具名结构体放入 components.schemas 并以 $ref 引用
+type schemas struct {
+	components map[string]*Schema
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema)}
+}
+
+// of 生成类型的 Schema
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.of(t.Elem())
+		if typ, ok := schema.Type.(string); ok {
+			schema.Type = []string{typ, "null"}
+		}
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// 先占位，避免自引用的结构体无限递归
+			s.components[t.Name()] = &Schema{}
+			*s.components[t.Name()] = *s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		// interface{} 等任意类型
+		return &Schema{}
+	}
+}
+
+// object 结构体的属性使用 JSON 字段名，binding 标签转换为约束
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// 没有 json 名的匿名字段，属性展开到外层（与 encoding/json 一致）
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.of(field.Type)
+		if applyBinding(property, field.Type, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyBinding 将 validator 的 binding 标签转换为 Schema 约束，返回字段是否必填
+// dive 之前的规则作用于字段本身，之后的作用于数组元素
+func applyBinding(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if schema.Items != nil {
+				applyBinding(schema.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "min", "gte":
+			setBound(schema, t, param, true, false)
+		case "max", "lte":
+			setBound(schema, t, param, false, false)
+		case "gt":
+			setBound(schema, t, param, true, true)
+		case "lt":
+			setBound(schema, t, param, false, true)
+		case "len":
+			setBound(schema, t, param, true, false)
+			setBound(schema, t, param, false, false)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(t, v))
+			}
+		case "url", "uri":
+			schema.Format = "uri"
+		case "email":
+			schema.Format = "email"
+		}
+	}
+	return required
+}
+
+// setBound 按字段类型设置长度、元素个数或数值范围
+func setBound(schema *Schema, t reflect.Type, param string, lower, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if lower {
+			schema.MinLength = intPtr(int(n))
+		} else {
+			schema.MaxLength = intPtr(int(n))
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if lower {
+			schema.MinItems = intPtr(int(n))
+		} else {
+			schema.MaxItems = intPtr(int(n))
+		}
+	default:
+		switch {
+		case lower && exclusive:
+			schema.ExclusiveMinimum = float(n)
+		case lower:
+			schema.Minimum = float(n)
+		case exclusive:
+			schema.ExclusiveMaximum = float(n)
+		default:
+			schema.Maximum = float(n)
+		}
+	}
+}
+
+// enumValue oneof 的取值按字段类型转换，数字字段的枚举值也是数字
+func enumValue(t reflect.Type, v string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+func float(n float64) *float64 { return &n }
+
+func intPtr(n int) *int { return &n }
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version 生成的文档遵循的 OpenAPI 版本
+const Version = "3.1.0"
+
+// Document OpenAPI 文档
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*Endpoint `json:"paths"` // 路径 -> 小写 HTTP 方法 -> 接口
+	Components Components                      `json:"components"`
+}
+
+// Info 文档基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components 可复用的定义
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Endpoint 一个接口（OpenAPI 中的 Operation Object）
+type Endpoint struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter 路径、查询参数或请求头
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path、query、header、cookie
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 某种内容类型的数据结构
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation 一个接口的描述，与 router 中注册的路由一一对应
+type Operation struct {
+	Method      string      // HTTP 方法，如 GET
+	Path        string      // gin 路由路径，如 /api/todos/:id，路径参数自动生成
+	ID          string      // operationId，通常为 Controller 函数名
+	Summary     string      // 一句话说明
+	Description string      // 详细说明
+	Tag         string      // 分组
+	Params      []Parameter // 查询参数、请求头等，路径参数不用列出
+	Body        interface{} // 请求体结构的零值，如 models.CreateTodoInput{}，nil 表示没有请求体
+	Data        interface{} // 成功时统一响应结构中 data 的类型，nil 表示没有 data
+	Raw         bool        // 成功响应不使用统一响应结构，此时 Data 为整个响应体
+	ContentType string      // 成功响应的内容类型，默认 application/json
+	Status      int         // 成功响应的状态码，默认 200
+	Deprecated  bool        // 已弃用，有新版本替代
+}
+
+// Mount 将一组接口挂载到路径前缀下，operationId 加上后缀以保证在文档中唯一
+func Mount(prefix, idSuffix string, ops []Operation) []Operation {
+	mounted := make([]Operation, len(ops))
+	for i, op := range ops {
+		op.Path = prefix + op.Path
+		op.ID += idSuffix
+		mounted[i] = op
+	}
+	return mounted
+}
+
+// Query 查询参数
+func Query(name, description string, example interface{}) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: newSchemas().of(reflect.TypeOf(example))}
+}
+
+// Header 请求头
+func Header(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Builder 根据路由和接口描述生成文档
+type Builder struct {
+	Info          Info
+	Envelope      interface{} // 统一成功响应结构，如 utils.Response{}
+	EnvelopeField string      // 统一响应结构中承载数据的字段，如 data
+	Problem       interface{} // 错误响应结构，如 utils.Problem{}
+	ProblemType   string      // 错误响应的内容类型，如 application/problem+json
+	Operations    []Operation
+}
+
+// Build 为每个已注册的路由生成接口文档
+// 返回的 drift 列出没有描述的路由和没有对应路由的描述，为空表示文档与路由一致
+func (b *Builder) Build(routes gin.RoutesInfo) (doc *Document, drift []string) {
+	s := newSchemas()
+	doc = &Document{
+		OpenAPI: Version,
+		Info:    b.Info,
+		Paths:   make(map[string]map[string]*Endpoint),
+	}
+
+	operations := make(map[string]Operation, len(b.Operations))
+	for _, op := range b.Operations {
+		operations[op.Method+" "+op.Path] = op
+	}
+
+	seen := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		seen[key] = true
+		op, ok := operations[key]
+		if !ok {
+			drift = append(drift, "undocumented route: "+key)
+			continue
+		}
+		path := toOpenAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Endpoint)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = b.operation(s, op)
+	}
+	for _, op := range b.Operations {
+		if key := op.Method + " " + op.Path; !seen[key] {
+			drift = append(drift, "documented route not registered: "+key)
+		}
+	}
+	sort.Strings(drift)
+
+	doc.Components.Schemas = s.components
+	return doc, drift
+}
+
+// operation 生成一个接口：路径参数、请求体、成功响应和错误响应
+func (b *Builder) operation(s *schemas, op Operation) *Endpoint {
+	item := &Endpoint{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Deprecated:  op.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+	if op.Tag != "" {
+		item.Tags = []string{op.Tag}
+	}
+
+	for _, segment := range strings.Split(op.Path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			// id 为正整数，其他路径参数为字符串
+			schema := &Schema{Type: "string"}
+			if name == "id" {
+				schema = &Schema{Type: "integer", Minimum: float(1)}
+			}
+			item.Parameters = append(item.Parameters, Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   schema,
+			})
+		}
+	}
+	item.Parameters = append(item.Parameters, op.Params...)
+
+	if op.Body != nil {
+		item.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: s.of(reflect.TypeOf(op.Body))}},
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if contentType := op.ContentType; contentType != "" {
+		success.Content = map[string]*MediaType{contentType: {Schema: &Schema{Type: "string"}}}
+	} else if op.Raw {
+		if op.Data != nil {
+			success.Content = map[string]*MediaType{"application/json": {Schema: s.of(reflect.TypeOf(op.Data))}}
+		}
+	} else {
+		success.Content = map[string]*MediaType{"application/json": {Schema: b.envelope(s, op.Data)}}
+	}
+	item.Responses[fmt.Sprint(status)] = success
+
+	if b.Problem != nil {
+		item.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]*MediaType{b.ProblemType: {Schema: s.of(reflect.TypeOf(b.Problem))}},
+		}
+	}
+	return item
+}
+
+// envelope 统一响应结构，data 字段替换为具体的数据类型
+func (b *Builder) envelope(s *schemas, data interface{}) *Schema {
+	schema := s.object(reflect.TypeOf(b.Envelope))
+	if data == nil {
+		delete(schema.Properties, b.EnvelopeField)
+	} else {
+		schema.Properties[b.EnvelopeField] = s.of(reflect.TypeOf(data))
+	}
+	return schema
+}
+
+// toOpenAPIPath 将 gin 的 :id 形式的路径参数转换为 {id}
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import "context"
+
+// 按操作计数的限流
+// 路由级的限流按请求计数，但一个请求中可能有多个修改：批量同步的每条修改、GraphQL 的每个 mutation、
+// WebSocket 连接上的每条消息。这些操作在执行的地方调用 Charge 各取一个令牌，创建待办事项在 TodoService 中计数，所有入口都一样。
+// 限流中间件把 Charger 放进请求的 context，键与路由级限流相同
+
+// 业务层按操作计数的策略
+const (
+	PolicyWrite  = "write"  // 每个修改操作
+	PolicyCreate = "create" // 每次创建待办事项，在 write 之外单独计数
+)
+
+// Charger 从当前客户端 policy 策略的桶中取一个令牌，被拒绝时返回错误
+type Charger func(ctx context.Context, policy string) error
+
+type chargerKey struct{}
+
+// WithCharger 返回带有 Charger 的 context
+func WithCharger(ctx context.Context, charger Charger) context.Context {
+	return context.WithValue(ctx, chargerKey{}, charger)
+}
+
+// Charge 为一个操作计数，超过限制时返回 Charger 的错误
+// context 中没有 Charger（未启用限流、gRPC、管理命令）时不计数
+func Charge(ctx context.Context, policy string) error {
+	if charger, ok := ctx.Value(chargerKey{}).(Charger); ok {
+		return charger(ctx, policy)
+	}
+	return nil
+}
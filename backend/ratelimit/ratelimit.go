@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 令牌桶限流
+// 每个键一个桶，容量为 Limit.Requests，每 Limit.Period 补满：允许短时间内的突发，长期速率不超过限制。
+// 状态保存在 Store 中，MemoryStore 只在单个实例内有效；多实例部署时实现一个共享的 Store（如 Redis + Lua 脚本）
+
+// Limit 限流规则：每 Period 最多 Requests 次
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit 解析 "60/1m" 形式的规则，off 或 0 表示不限流（返回零值）
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period such as 60/1m", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad period", s)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Enabled 是否限流
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// interval 补充一个令牌需要的时间
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result 一次请求的限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶的容量
+	Remaining  int           // 本次请求之后剩余的令牌
+	Reset      time.Duration // 桶补满需要的时间
+	RetryAfter time.Duration // 被拒绝时，等待多久才有一个令牌
+}
+
+// Store 限流状态的存储，Take 从 key 对应的桶中取一个令牌
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore 进程内的令牌桶存储
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// bucket 令牌桶，tokens 为 at 时刻的令牌数
+type bucket struct {
+	tokens float64
+	at     time.Time
+	limit  Limit
+}
+
+// sweepInterval 清理已补满的桶的间隔，补满的桶与不存在等价，清理后内存只与活跃的键数量有关
+const sweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Take 取一个令牌，令牌不足时拒绝，不扣减
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	capacity := float64(limit.Requests)
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: capacity, at: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(limit.interval()))
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = time.Duration((capacity - b.tokens) * float64(limit.interval()))
+	return result, nil
+}
+
+// refill 按经过的时间补充令牌，不超过容量
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.at)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(float64(b.limit.Requests), b.tokens+float64(elapsed)/float64(b.limit.interval()))
+	b.at = now
+}
+
+// sweep 删除已补满的桶
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestStore 使用假时钟的存储，时间只在调用 Advance 时前进
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+// TestMemoryStore 测试令牌桶的突发、补充、重置时间和清理
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second} // 每秒补充一个令牌
+
+	t.Run("容量内允许突发，用完后拒绝并给出 RetryAfter", func(t *testing.T) {
+		store, _ := newTestStore()
+		for i := 2; i >= 0; i-- {
+			result, _ := store.Take(ctx, "k", limit)
+			if !result.Allowed || result.Remaining != i {
+				t.Fatalf("第 %d 次应该允许、剩余 %d，实际: %+v", 3-i, i, result)
+			}
+		}
+		result, _ := store.Take(ctx, "k", limit)
+		if result.Allowed || result.Remaining != 0 {
+			t.Fatalf("令牌用完后应该拒绝，实际: %+v", result)
+		}
+		if result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+			t.Errorf("RetryAfter 应该为 1s、Reset 为 3s，实际: %s %s", result.RetryAfter, result.Reset)
+		}
+		t.Logf("✅ 拒绝: %+v", result)
+	})
+
+	t.Run("按经过的时间补充令牌，不超过容量", func(t *testing.T) {
+		store, clock := newTestStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "k", limit)
+		}
+
+		clock.Advance(500 * time.Millisecond)
+		result, _ := store.Take(ctx, "k", limit)
+		if result.Allowed || result.RetryAfter != 500*time.Millisecond {
+			t.Fatalf("半个令牌时应该拒绝并等待 500ms，实际: %+v", result)
+		}
+
+		clock.Advance(500 * time.Millisecond)
+		if result, _ := store.Take(ctx, "k", limit); !result.Allowed || result.Remaining != 0 {
+			t.Fatalf("补充一个令牌后应该允许一次，实际: %+v", result)
+		}
+
+		clock.Advance(time.Hour)
+		result, _ = store.Take(ctx, "k", limit)
+		if !result.Allowed || result.Remaining != 2 || result.Reset != time.Second {
+			t.Fatalf("很久之后桶应该是满的（取一个后剩 2、1s 补满），实际: %+v", result)
+		}
+		t.Logf("✅ 补充: %+v", result)
+	})
+
+	t.Run("不同的键和规则互不影响", func(t *testing.T) {
+		store, _ := newTestStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "a", limit)
+		}
+		if result, _ := store.Take(ctx, "b", limit); !result.Allowed {
+			t.Errorf("其他键不应受影响: %+v", result)
+		}
+		// 同一个键的规则变化时按新规则重新开始
+		if result, _ := store.Take(ctx, "a", Limit{Requests: 10, Period: time.Minute}); !result.Allowed || result.Remaining != 9 {
+			t.Errorf("规则变化后应该使用新的桶: %+v", result)
+		}
+		t.Logf("✅ 键之间相互独立")
+	})
+
+	t.Run("定期清理已补满的桶，未补满的保留", func(t *testing.T) {
+		store, clock := newTestStore()
+		slow := Limit{Requests: 1, Period: time.Hour}
+		store.Take(ctx, "fast", limit)
+		store.Take(ctx, "slow", slow)
+
+		// 第一次 Take 时已经清理过，间隔不到 sweepInterval 时不清理
+		clock.Advance(sweepInterval / 2)
+		store.Take(ctx, "other", limit)
+		if len(store.buckets) != 3 {
+			t.Fatalf("未到清理间隔时不应清理，实际 %d 个桶", len(store.buckets))
+		}
+
+		clock.Advance(sweepInterval)
+		store.Take(ctx, "trigger", limit)
+		if _, ok := store.buckets["fast"]; ok {
+			t.Errorf("已补满的桶应该被清理")
+		}
+		if _, ok := store.buckets["slow"]; !ok {
+			t.Errorf("未补满的桶应该保留，否则相当于重置了限流")
+		}
+		if result, _ := store.Take(ctx, "slow", slow); result.Allowed {
+			t.Errorf("清理后 slow 仍应被拒绝: %+v", result)
+		}
+		t.Logf("✅ 清理后剩余 %d 个桶", len(store.buckets))
+	})
+}
+
+// TestParseLimit 测试规则解析
+func TestParseLimit(t *testing.T) {
+	t.Run("次数/时长，off 和 0 表示不限流", func(t *testing.T) {
+		if l, err := ParseLimit("60/1m"); err != nil || l != (Limit{Requests: 60, Period: time.Minute}) {
+			t.Errorf("60/1m 解析错误: %v %v", l, err)
+		}
+		for _, s := range []string{"off", "0"} {
+			if l, err := ParseLimit(s); err != nil || l.Enabled() {
+				t.Errorf("%s 应该不限流: %v %v", s, l, err)
+			}
+		}
+		for _, s := range []string{"60", "x/1m", "-1/1m", "60/0s", "60/abc"} {
+			if _, err := ParseLimit(s); err == nil {
+				t.Errorf("%s 应该解析失败", s)
+			}
+		}
+		t.Logf("✅ 规则解析正确")
+	})
+}
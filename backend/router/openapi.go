@@ -0,0 +1,209 @@
+package router
+
+import (
+	"backend/controllers"
+	"backend/models"
+	"backend/openapi"
+	"backend/services"
+	"backend/utils"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpecPath OpenAPI 文档的地址，DocsPath 文档页面的地址
+const (
+	SpecPath = "/api/openapi.json"
+	DocsPath = "/api/docs"
+)
+
+// operations 所有路由的接口描述，新增或修改路由时需要同步修改这里，否则 router_test 会失败
+// 请求体和响应的结构从 Go 类型生成，binding 标签转换为 Schema 约束
+var operations = slices.Concat(
+	systemOperations,
+	authOperations,
+	openapi.Mount("/api", "", v1Operations),
+	openapi.Mount("/api/v1", "V1", v1Operations),
+	openapi.Mount("/api/v2", "V2", v2Operations),
+)
+
+// systemOperations 与接口版本无关的路由
+var systemOperations = []openapi.Operation{
+	{Method: "GET", Path: "/ping", ID: "Ping", Summary: "健康检查", Tag: "system", Raw: true, Data: map[string]string{}},
+	{Method: "GET", Path: "/healthz", ID: "Liveness", Summary: "存活检查", Tag: "system",
+		Description: "不检查依赖，进程能处理请求即返回 200", Raw: true, Data: controllers.HealthStatus{}},
+	{Method: "GET", Path: "/readyz", ID: "Readiness", Summary: "就绪检查", Tag: "system", Raw: true, Data: services.HealthReport{},
+		Description: "检查数据库连通性、迁移版本和后台投递协程；数据库不可用、迁移版本落后或服务正在退出时返回 503"},
+	{Method: "GET", Path: "/problems/:type", ID: "GetProblemType", Summary: "错误类型说明", Tag: "system", ContentType: "text/html",
+		Description: "problem+json 中 type 指向的页面，按请求的语言显示错误码的概括；不存在的错误码返回 404"},
+	{Method: "GET", Path: "/metrics", ID: "Metrics", Summary: "Prometheus 指标", Tag: "system", ContentType: "text/plain",
+		Description: "请求数与耗时（按路由模板）、数据库连接池、待办事项数量、版本冲突次数、panic 次数"},
+	{Method: "GET", Path: SpecPath, ID: "GetOpenAPISpec", Summary: "OpenAPI 文档", Tag: "system", Raw: true, Data: openapi.Document{}},
+	{Method: "GET", Path: DocsPath, ID: "GetDocs", Summary: "API 文档页面", Tag: "system", ContentType: "text/html"},
+	{Method: "POST", Path: "/graphql", ID: "GraphQL", Summary: "GraphQL 接口", Tag: "system",
+		Description: "schema 见 gql/schema.graphql，支持内省查询", Body: gqlRequest{}, Raw: true, Data: map[string]interface{}{}},
+}
+
+// authOperations 登录与退出，与接口版本无关
+var authOperations = []openapi.Operation{
+	{Method: "POST", Path: "/api/auth/login", ID: "Login", Summary: "登录", Tag: "auth",
+		Description: "mode 为 cookie（默认）时设置 HttpOnly 会话 Cookie，之后的修改请求需要在 X-CSRF-Token 中带上 csrf_token；" +
+			"mode 为 token 时返回令牌，放在 Authorization: Bearer 中。用户名或密码错误返回 401",
+		Body: controllers.LoginInput{}, Data: controllers.LoginResponse{}},
+	{Method: "POST", Path: "/api/auth/logout", ID: "Logout", Summary: "退出登录", Tag: "auth",
+		Description: "删除会话并清除 Cookie，未登录时也返回成功"},
+	{Method: "GET", Path: "/api/auth/me", ID: "CurrentUser", Summary: "当前登录的用户", Tag: "auth",
+		Description: "未登录时返回 401", Data: models.User{}},
+}
+
+// v1Operations v1 接口，路径相对于 /api 或 /api/v1；已有 v2 替代的标记为弃用
+var v1Operations = slices.Concat(v1TodoOperations, deprecate(webhookOperations), syncOperations)
+
+// v1TodoOperations v1 待办事项接口：分类使用名称，列表不分页
+var v1TodoOperations = deprecate([]openapi.Operation{
+	{Method: "POST", Path: "/todos", ID: "AddTodo", Summary: "创建待办事项", Tag: "todos",
+		Body: models.CreateTodoInput{}, Data: models.Todo{}},
+	{Method: "GET", Path: "/todos", ID: "GetTodos", Summary: "获取待办事项列表", Tag: "todos",
+		Params: []openapi.Parameter{
+			openapi.Query("category", "按分类筛选：work、study、life", ""),
+			openapi.Query("sort", "排序方式：priority、created_at", ""),
+		},
+		Data: []models.Todo{}},
+	{Method: "GET", Path: "/todos/:id", ID: "GetTodoByID", Summary: "获取单个待办事项", Tag: "todos", Data: models.Todo{}},
+	{Method: "PUT", Path: "/todos/:id", ID: "UpdateTodo", Summary: "编辑待办事项", Tag: "todos",
+		Description: "version 与服务端不一致时返回 409，响应中带有 current_version 和 latest_data",
+		Body:        models.UpdateTodoInput{}, Data: models.Todo{}},
+	{Method: "PUT", Path: "/todos/:id/status", ID: "UpdateTodoStatus", Summary: "更新完成状态", Tag: "todos",
+		Description: "version 与服务端不一致时返回 409，响应中带有 current_version 和 latest_data",
+		Body:        models.UpdateStatusInput{}, Data: models.Todo{}},
+	{Method: "DELETE", Path: "/todos/:id", ID: "DeleteTodo", Summary: "删除待办事项", Tag: "todos"},
+})
+
+// v2Operations v2 接口，路径相对于 /api/v2
+var v2Operations = slices.Concat([]openapi.Operation{
+	{Method: "GET", Path: "/categories", ID: "GetCategories", Summary: "获取所有分类", Tag: "todos", Data: []models.Category{}},
+	{Method: "POST", Path: "/todos", ID: "AddTodo", Summary: "创建待办事项", Tag: "todos",
+		Body: controllers.CreateTodoInputV2{}, Data: controllers.TodoV2{}},
+	{Method: "GET", Path: "/todos", ID: "GetTodos", Summary: "分页获取待办事项列表", Tag: "todos",
+		Params: []openapi.Parameter{
+			openapi.Query("category_id", "按分类筛选，取值见 /api/v2/categories", uint(0)),
+			openapi.Query("sort", "排序方式：priority、created_at", ""),
+			openapi.Query("page", "页码，从 1 开始", 0),
+			openapi.Query("page_size", "每页条数，1-100，默认 20", 0),
+		},
+		Data: controllers.TodoPageV2{}},
+	{Method: "GET", Path: "/todos/:id", ID: "GetTodoByID", Summary: "获取单个待办事项", Tag: "todos", Data: controllers.TodoV2{}},
+	{Method: "PUT", Path: "/todos/:id", ID: "UpdateTodo", Summary: "编辑待办事项", Tag: "todos",
+		Description: "version 与服务端不一致时返回 409，响应中带有 current_version 和 latest_data",
+		Body:        controllers.UpdateTodoInputV2{}, Data: controllers.TodoV2{}},
+	{Method: "PUT", Path: "/todos/:id/status", ID: "UpdateTodoStatus", Summary: "更新完成状态", Tag: "todos",
+		Description: "version 与服务端不一致时返回 409，响应中带有 current_version 和 latest_data",
+		Body:        models.UpdateStatusInput{}, Data: controllers.TodoV2{}},
+	{Method: "DELETE", Path: "/todos/:id", ID: "DeleteTodo", Summary: "删除待办事项", Tag: "todos"},
+}, webhookOperations)
+
+// webhookOperations Webhook 接口，两个版本相同
+var webhookOperations = []openapi.Operation{
+	{Method: "POST", Path: "/webhooks", ID: "AddWebhook", Summary: "创建 Webhook 订阅", Tag: "webhooks",
+		Description: "Webhook 接口都需要登录，用户只能管理自己创建的订阅及其投递记录（管理员除外）。url 不能指向回环、链路本地和内网地址（TODO_WEBHOOK_ALLOWED_TARGETS 中的除外）；" +
+			"events 可选 created、completed、deleted；overdue 事件暂不支持，返回 WEBHOOK_EVENT_UNSUPPORTED，其他事件名返回 INVALID_WEBHOOK_EVENT",
+		Body: models.CreateWebhookInput{}, Data: models.WebhookSubscription{}},
+	{Method: "GET", Path: "/webhooks", ID: "GetWebhooks", Summary: "获取所有订阅", Tag: "webhooks",
+		Data: []models.WebhookSubscription{}},
+	{Method: "GET", Path: "/webhooks/deliveries", ID: "GetAllWebhookDeliveries", Summary: "最近的投递记录", Tag: "webhooks",
+		Params: deliveryParams, Data: []models.WebhookDelivery{}},
+	{Method: "POST", Path: "/webhooks/deliveries/:id/redeliver", ID: "RedeliverWebhook", Summary: "重新投递", Tag: "webhooks"},
+	{Method: "GET", Path: "/webhooks/:id", ID: "GetWebhookByID", Summary: "获取单个订阅", Tag: "webhooks",
+		Data: models.WebhookSubscription{}},
+	{Method: "DELETE", Path: "/webhooks/:id", ID: "DeleteWebhook", Summary: "删除订阅", Tag: "webhooks"},
+	{Method: "GET", Path: "/webhooks/:id/deliveries", ID: "GetWebhookDeliveries", Summary: "某个订阅的投递记录", Tag: "webhooks",
+		Params: deliveryParams, Data: []models.WebhookDelivery{}},
+}
+
+// syncOperations 同步与推送接口，目前只有 v1
+var syncOperations = []openapi.Operation{
+	{Method: "GET", Path: "/sync", ID: "PullChanges", Summary: "拉取增量变更", Tag: "sync",
+		Description: "since 为空时返回全量快照；has_more 为 true 时应使用返回的 sync_token 继续拉取",
+		Params: []openapi.Parameter{
+			openapi.Query("since", "上次拉取返回的 sync_token", ""),
+			openapi.Query("limit", "每次最多返回的变更条数", 0),
+		},
+		Data: services.SyncPullResult{}},
+	{Method: "POST", Path: "/sync", ID: "PushChanges", Summary: "上传离线修改", Tag: "sync",
+		Description: "逐条返回 accepted、conflict、not_found 或 rejected",
+		Body:        models.SyncPushInput{}, Data: services.SyncPushResult{}},
+	{Method: "GET", Path: "/events", ID: "StreamEvents", Summary: "数据变更推送（Server-Sent Events）", Tag: "sync",
+		Params: []openapi.Parameter{
+			openapi.Query("category", "只推送该分类的变更", ""),
+			openapi.Query("todo_id", "只推送该待办事项的变更", uint(0)),
+			openapi.Query("owner", "me 表示只推送当前用户创建的待办事项的变更，需要登录", ""),
+			openapi.Query("last_event_id", "断线重连时从该事件之后继续", ""),
+			openapi.Header("Last-Event-ID", "浏览器断线重连时自动带上"),
+		},
+		ContentType: "text/event-stream"},
+	{Method: "GET", Path: "/ws", ID: "Collaborate", Summary: "协作通道（WebSocket），编辑状态中的用户名取自登录身份", Tag: "sync",
+		Status: http.StatusSwitchingProtocols, Raw: true},
+}
+
+// gqlRequest GraphQL 请求体，仅用于生成文档
+type gqlRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// deprecate 返回标记为弃用的副本
+func deprecate(ops []openapi.Operation) []openapi.Operation {
+	deprecated := slices.Clone(ops)
+	for i := range deprecated {
+		deprecated[i].Deprecated = true
+	}
+	return deprecated
+}
+
+// deliveryParams 投递记录的查询参数
+var deliveryParams = []openapi.Parameter{
+	openapi.Query("status", "按状态筛选：pending、succeeded、dead", ""),
+	openapi.Query("limit", "最多返回的条数", 0),
+}
+
+// specBuilder 文档的基本信息和通用的响应结构
+var specBuilder = &openapi.Builder{
+	Info: openapi.Info{
+		Title:       "Todo API",
+		Version:     "1.0.0",
+		Description: "成功时返回统一响应结构 {code, message, data}，失败时返回 RFC 7807 application/problem+json",
+	},
+	Envelope:      utils.Response{},
+	EnvelopeField: "data",
+	Problem:       utils.Problem{},
+	ProblemType:   utils.ProblemContentType,
+	Operations:    operations,
+}
+
+// BuildSpec 根据已注册的路由生成 OpenAPI 文档，drift 为路由与接口描述不一致的地方
+func BuildSpec(r *gin.Engine) (*openapi.Document, []string) {
+	return specBuilder.Build(r.Routes())
+}
+
+// registerDocs 注册文档接口；文档在第一次请求时生成，此时所有路由都已注册
+func registerDocs(r *gin.Engine, api *gin.RouterGroup) {
+	var (
+		once sync.Once
+		spec *openapi.Document
+	)
+	api.GET("/openapi.json", func(c *gin.Context) {
+		once.Do(func() {
+			var drift []string
+			spec, drift = BuildSpec(r)
+			for _, d := range drift {
+				slog.WarnContext(c.Request.Context(), "openapi spec out of sync with routes", "component", "openapi", "drift", d)
+			}
+		})
+		c.JSON(http.StatusOK, spec)
+	})
+	api.GET("/docs", openapi.DocsHandler(SpecPath))
+}
@@ -1,21 +1,81 @@
 package router
 
 import (
+	"backend/config"
 	"backend/controllers"
+	"backend/metrics"
 	"backend/middleware"
+	"backend/ratelimit"
+	"backend/services"
+	"backend/web"
+	"log/slog"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// longLivedRoutes 长连接路由，不受请求处理时限的限制
+var longLivedRoutes = []string{"/api/events", "/api/v1/events", "/api/ws", "/api/v1/ws"}
+
+// unlimitedRoutes 不限流的路由：健康检查和指标由负载均衡、监控系统频繁访问
+var unlimitedRoutes = map[string]bool{"/ping": true, "/healthz": true, "/readyz": true, "/metrics": true}
+
+// meteredRoutes 一个请求中可能有多个修改的路由，请求本身按读请求计数，每个修改由业务层另外计数（ratelimit.Charge）
+var meteredRoutes = map[string]bool{"/graphql": true, "/api/sync": true, "/api/v1/sync": true}
+
+// loginRoute 登录路由，使用单独的 login 策略
+const loginRoute = "/api/auth/login"
+
 // SetupRouter 配置所有路由
 func SetupRouter() *gin.Engine {
 	// 创建 Gin 引擎（不使用 Default，手动添加中间件）
 	r := gin.New()
 
+	// 只信任配置的反向代理传入的 X-Forwarded-For，否则客户端可以伪造 IP 绕过限流
+	serverConfig := config.GetServerConfig()
+	if err := r.SetTrustedProxies(serverConfig.TrustedProxies); err != nil {
+		slog.Warn("invalid TODO_TRUSTED_PROXIES, trusting no proxies", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+
 	// 应用中间件
-	r.Use(middleware.Recovery()) // 错误恢复
-	r.Use(middleware.Logger())   // 请求日志
-	r.Use(middleware.CORS())     // 跨域处理
+	r.Use(middleware.RequestID()) // 请求 ID（最先执行，之后的日志都带有 request_id）
+	r.Use(middleware.Tracing())   // 链路追踪（之后的日志都带有 trace_id）
+	r.Use(middleware.Metrics())   // 请求指标（在 Recovery 之前，panic 的请求计为 500）
+	r.Use(middleware.Recovery())  // 错误恢复
+	r.Use(middleware.Logger())    // 请求日志
+
+	// 安全响应头（在 CORS 之前，预检和被拒绝的请求也带有）
+	r.Use(middleware.SecurityHeaders(config.GetSecurityConfig(), serverConfig.TrustedProxies))
+
+	// 跨域处理：按配置的来源白名单，预检请求只对已注册的路由返回 204
+	corsPolicy := middleware.NewCORSPolicy(config.GetCORSConfig())
+	r.Use(middleware.CORS(corsPolicy, r.Routes))
+
+	// Prometheus 指标，在认证中间件之前注册（gin 在注册路由时合并中间件）：
+	// 抓取时携带的是 TODO_METRICS_TOKEN 而不是登录令牌，不能被当作会话令牌校验。
+	// 配置了单独的监听地址时由 serve 在该地址上提供，不在接口端口上暴露
+	if metricsConfig := config.GetMetricsConfig(); metricsConfig.Addr == "" {
+		r.GET("/metrics", gin.WrapH(metrics.Handler(metricsConfig.Token)))
+	}
+
+	// 识别登录用户（Bearer 令牌或会话 Cookie），之后检查修改请求的来源和 CSRF 令牌
+	authConfig := config.GetAuthConfig()
+	r.Use(middleware.Authenticate(services.NewAuthService(authConfig.SessionTTL), authConfig.CookieName))
+	r.Use(middleware.CSRF(corsPolicy))
+
+	// 限流（在 CORS 之后，预检请求不计数；在认证之后，已登录的用户按用户计数）
+	if rateLimitConfig := config.GetRateLimitConfig(); rateLimitConfig.Enabled {
+		charges := map[string]ratelimit.Limit{
+			ratelimit.PolicyWrite:  rateLimitConfig.Write,
+			ratelimit.PolicyCreate: rateLimitConfig.Create,
+		}
+		r.Use(middleware.RateLimit(ratelimit.NewMemoryStore(), rateLimitPolicy(rateLimitConfig), charges))
+	}
+
+	// 请求处理时限，超时后数据库查询中断并返回 504
+	r.Use(middleware.Timeout(serverConfig.RequestTimeout, longLivedRoutes...))
 
 	// 健康检查接口
 	r.GET("/ping", func(c *gin.Context) {
@@ -23,21 +83,142 @@ func SetupRouter() *gin.Engine {
 			"message": "pong",
 		})
 	})
+	r.GET("/healthz", controllers.Liveness)
+	r.GET("/readyz", controllers.Readiness)
+
+	// 错误类型说明，problem+json 中的 type 指向这里
+	r.GET("/problems/:type", controllers.ProblemTypeDoc)
+
+	// 数据接口的登录要求（TODO_REQUIRE_AUTH），登录、文档、健康检查等不受影响
+	requireLogin := middleware.RequireAuthFor(authConfig.Required)
+
+	// GraphQL 接口，一次请求获取待办事项及其变更历史；查询也使用 POST，writes 模式下同样需要登录
+	r.POST("/graphql", requireLogin, controllers.GraphQL)
 
 	// API 路由组
 	api := r.Group("/api")
 	{
-		// Todos 相关路由
-		todos := api.Group("/todos")
+		// OpenAPI 文档与文档页面，与接口版本无关
+		registerDocs(r, api)
+
+		// 登录与退出，与接口版本无关
+		auth := api.Group("/auth")
 		{
-			todos.POST("", controllers.AddTodo)                    // 创建待办事项
-			todos.GET("", controllers.GetTodos)                    // 获取待办事项列表（支持筛选和排序）
-			todos.GET("/:id", controllers.GetTodoByID)             // 获取单个待办事项
-			todos.PUT("/:id", controllers.UpdateTodo)              // 更新待办事项（编辑）
-			todos.PUT("/:id/status", controllers.UpdateTodoStatus) // 更新待办事项状态
-			todos.DELETE("/:id", controllers.DeleteTodo)           // 删除待办事项
+			auth.POST("/login", controllers.Login)                             // 登录
+			auth.POST("/logout", controllers.Logout)                           // 退出登录
+			auth.GET("/me", middleware.RequireAuth(), controllers.CurrentUser) // 当前用户
 		}
+
+		// v1 接口：/api/v1，以及不带版本号的 /api（现有的前端使用）
+		// 已有 v2 替代的部分返回 Deprecation 和 Sunset 响应头
+		apiConfig := config.GetAPIConfig()
+		deprecated := middleware.Deprecation(apiConfig.V1DeprecatedAt, apiConfig.V1Sunset, "/api/v2")
+		registerV1(api.Group("", requireLogin), deprecated)
+		registerV1(api.Group("/v1", requireLogin), deprecated)
+
+		// v2 接口：分类使用 ID，列表分页
+		registerV2(api.Group("/v2", requireLogin))
 	}
 
+	// 前端页面，API 路由优先
+	registerFrontend(r)
+
 	return r
 }
+
+// registerFrontend 未匹配的路径交给前端：内嵌的构建产物或 Vite 开发服务器
+// /api 和 /graphql 下未匹配的路径仍返回 404，不返回页面
+func registerFrontend(r *gin.Engine) {
+	frontend, err := web.Handler(config.GetFrontendConfig().DevURL)
+	if err != nil {
+		slog.Warn("frontend disabled", "error", err)
+		return
+	}
+	if frontend == nil {
+		return
+	}
+	r.NoRoute(func(c *gin.Context) {
+		p := c.Request.URL.Path
+		if p == "/api" || strings.HasPrefix(p, "/api/") || strings.HasPrefix(p, "/graphql") {
+			return
+		}
+		frontend(c)
+	})
+}
+
+// rateLimitPolicy 按路由选择限流策略：登录最严格，然后是写请求，读请求最宽松
+// 创建待办事项不在这里区分，由 TodoService 对每次创建计数，批量同步、GraphQL 和 WebSocket 的创建都不能绕过
+// 不存在的路由按读请求计数，避免扫描不受限制
+func rateLimitPolicy(cfg *config.RateLimitConfig) middleware.RateLimitPolicy {
+	return func(c *gin.Context) (string, ratelimit.Limit) {
+		route, method := c.FullPath(), c.Request.Method
+		switch {
+		case unlimitedRoutes[route]:
+			return "", ratelimit.Limit{}
+		case route == loginRoute:
+			return "login", cfg.Login
+		case method == http.MethodGet || method == http.MethodHead || route == "" || meteredRoutes[route]:
+			return "read", cfg.Read
+		default:
+			return ratelimit.PolicyWrite, cfg.Write
+		}
+	}
+}
+
+// registerV1 注册 v1 接口，deprecated 作用于已有 v2 替代的路由
+func registerV1(api *gin.RouterGroup, deprecated gin.HandlerFunc) {
+	// Todos 相关路由
+	todos := api.Group("/todos", deprecated)
+	{
+		todos.POST("", controllers.AddTodo)                    // 创建待办事项
+		todos.GET("", controllers.GetTodos)                    // 获取待办事项列表（支持筛选和排序）
+		todos.GET("/:id", controllers.GetTodoByID)             // 获取单个待办事项
+		todos.PUT("/:id", controllers.UpdateTodo)              // 更新待办事项（编辑）
+		todos.PUT("/:id/status", controllers.UpdateTodoStatus) // 更新待办事项状态
+		todos.DELETE("/:id", controllers.DeleteTodo)           // 删除待办事项
+	}
+
+	// Webhook 订阅与投递记录
+	registerWebhooks(api.Group("/webhooks", deprecated))
+
+	// 离线增量同步
+	api.GET("/sync", controllers.PullChanges)  // 拉取 since 之后的变更
+	api.POST("/sync", controllers.PushChanges) // 上传离线修改
+
+	// 数据变更推送（SSE）
+	api.GET("/events", controllers.StreamEvents)
+
+	// 协作通道（WebSocket）
+	api.GET("/ws", controllers.Collaborate)
+}
+
+// registerV2 注册 v2 接口，与 v1 共用 Service 层，请求和响应结构在 Controller 中转换
+// 同步、SSE 和 WebSocket 仍使用 v1 的数据结构，暂时只在 v1 中提供
+func registerV2(api *gin.RouterGroup) {
+	api.GET("/categories", controllers.GetCategoriesV2) // 获取所有分类
+
+	todos := api.Group("/todos")
+	{
+		todos.POST("", controllers.AddTodoV2)                    // 创建待办事项
+		todos.GET("", controllers.GetTodosV2)                    // 分页获取待办事项列表
+		todos.GET("/:id", controllers.GetTodoByIDV2)             // 获取单个待办事项
+		todos.PUT("/:id", controllers.UpdateTodoV2)              // 更新待办事项（编辑）
+		todos.PUT("/:id/status", controllers.UpdateTodoStatusV2) // 更新待办事项状态
+		todos.DELETE("/:id", controllers.DeleteTodo)             // 删除待办事项，与 v1 相同
+	}
+
+	// Webhook 的数据结构在两个版本中相同
+	registerWebhooks(api.Group("/webhooks"))
+}
+
+// registerWebhooks 注册 Webhook 订阅与投递记录相关路由，需要登录：订阅会让服务端向订阅的地址发送数据
+func registerWebhooks(webhooks *gin.RouterGroup) {
+	webhooks.Use(middleware.RequireAuth())
+	webhooks.POST("", controllers.AddWebhook)                                // 创建订阅
+	webhooks.GET("", controllers.GetWebhooks)                                // 获取所有订阅
+	webhooks.GET("/deliveries", controllers.GetAllWebhookDeliveries)         // 最近的投递记录
+	webhooks.POST("/deliveries/:id/redeliver", controllers.RedeliverWebhook) // 重新投递
+	webhooks.GET("/:id", controllers.GetWebhookByID)                         // 获取单个订阅
+	webhooks.DELETE("/:id", controllers.DeleteWebhook)                       // 删除订阅
+	webhooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries)        // 某个订阅的投递记录
+}
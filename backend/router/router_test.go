@@ -0,0 +1,612 @@
+package router
+
+import (
+	"backend/controllers"
+	customerrors "backend/errors"
+	"backend/middleware"
+	"backend/openapi"
+	"backend/ratelimit"
+	"backend/utils"
+	"backend/web"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestOpenAPISpec 测试 OpenAPI 文档与路由保持一致
+func TestOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	t.Run("每个路由都有接口描述，每个描述都对应已注册的路由", func(t *testing.T) {
+		_, drift := BuildSpec(r)
+		for _, d := range drift {
+			t.Error(d)
+		}
+		if len(drift) == 0 {
+			t.Logf("✅ %d 个路由与文档一致", len(r.Routes()))
+		}
+	})
+
+	t.Run("binding 标签转换为 Schema 约束", func(t *testing.T) {
+		spec, _ := BuildSpec(r)
+		input := spec.Components.Schemas["CreateTodoInput"]
+		if input == nil {
+			t.Fatal("缺少 CreateTodoInput 的定义")
+		}
+		if len(input.Required) != 1 || input.Required[0] != "title" {
+			t.Errorf("必填字段应该只有 title，实际: %v", input.Required)
+		}
+		if title := input.Properties["title"]; title.MaxLength == nil || *title.MaxLength != 255 {
+			t.Error("title 应该有 maxLength 255")
+		}
+		if priority := input.Properties["priority"]; priority.Maximum == nil || *priority.Maximum != 5 {
+			t.Error("priority 应该有 maximum 5")
+		}
+		if category := input.Properties["category"]; len(category.Enum) != 3 {
+			t.Errorf("category 应该有 3 个枚举值，实际: %v", category.Enum)
+		}
+
+		t.Log("✅ 约束生成正确")
+	})
+
+	t.Run("通过 /api/openapi.json 获取文档", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("状态码应该为 200，实际: %d", w.Code)
+		}
+
+		var spec openapi.Document
+		if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+			t.Fatalf("文档不是合法的 JSON: %v", err)
+		}
+		if spec.OpenAPI != openapi.Version {
+			t.Errorf("openapi 版本应该为 %s，实际: %s", openapi.Version, spec.OpenAPI)
+		}
+		if spec.Paths["/api/todos/{id}"]["put"] == nil {
+			t.Error("缺少 PUT /api/todos/{id}")
+		}
+
+		t.Logf("✅ 文档包含 %d 个路径", len(spec.Paths))
+	})
+}
+
+// TestMetrics 测试 Prometheus 指标
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	t.Run("请求按路由模板计数，未匹配的路由归为 unmatched", func(t *testing.T) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, SpecPath, nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/no-such-path/123", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-RANDOM-1234", "/api/no-such-path/123", nil))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("状态码应该为 200，实际: %d", w.Code)
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			`todo_http_requests_total{method="GET",route="` + SpecPath + `",status="200"}`,
+			`todo_http_requests_total{method="GET",route="unmatched",status="404"}`,
+			`todo_http_requests_total{method="OTHER",route="unmatched",status="404"}`,
+			`todo_version_conflicts_total{operation="update"}`,
+			`todo_version_conflicts_total{operation="delete"}`,
+			`todo_http_panics_total`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("缺少指标 %s", want)
+			}
+		}
+		if strings.Contains(body, "X-RANDOM-1234") {
+			t.Errorf("客户端构造的请求方法不应该成为标签取值")
+		}
+		t.Logf("✅ 指标输出 %d 字节", len(body))
+	})
+
+	t.Run("设置了令牌时需要 Bearer 令牌才能抓取", func(t *testing.T) {
+		t.Setenv("TODO_METRICS_TOKEN", "scrape-secret")
+		r := SetupRouter()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("没有令牌应该返回 401，实际: %d", w.Code)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer scrape-secret")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("带令牌应该返回 200，实际: %d %s", w.Code, w.Body.String())
+		}
+		t.Logf("✅ 令牌校验通过")
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	t.Run("沿用请求头中的请求 ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("X-Request-ID", "gateway-42")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get("X-Request-ID"); got != "gateway-42" {
+			t.Fatalf("响应头应该返回传入的请求 ID，实际: %q", got)
+		}
+		t.Logf("✅ 请求 ID: %s", w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("没有或不合法时生成新的请求 ID", func(t *testing.T) {
+		for _, header := range []string{"", "bad id\nwith newline"} {
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			if header != "" {
+				req.Header.Set("X-Request-ID", header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			got := w.Header().Get("X-Request-ID")
+			if len(got) != 32 || got == header {
+				t.Fatalf("应该生成 32 位请求 ID，实际: %q", got)
+			}
+		}
+		t.Logf("✅ 生成了新的请求 ID")
+	})
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Run("沿用 traceparent 中的链路，span 以路由模板命名", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("应该有 1 个 span，实际: %d", len(spans))
+		}
+		span := spans[0]
+		if span.Name != "GET /healthz" {
+			t.Errorf("span 名称错误: %s", span.Name)
+		}
+		if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("没有沿用上游的链路: trace=%s parent=%s", span.SpanContext.TraceID(), span.Parent.SpanID())
+		}
+		t.Logf("✅ %s trace=%s", span.Name, span.SpanContext.TraceID())
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TODO_RATE_LIMIT_READ", "2/1m")
+	r := SetupRouter()
+
+	get := func(path, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("超过限制后返回 429 和 Retry-After", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			w := get("/no-such-route", "")
+			if w.Code == http.StatusTooManyRequests {
+				t.Fatalf("第 %d 个请求不应被限流", i+1)
+			}
+			if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Policy") != "2;w=60" {
+				t.Fatalf("限流响应头不正确: %v", w.Header())
+			}
+		}
+		// 伪造 X-Forwarded-For 不能绕过限流（默认不信任任何代理）
+		w := get("/no-such-route", "203.0.113.9")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("第 3 个请求应该返回 429，实际: %d", w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" || w.Header().Get("RateLimit-Remaining") != "0" {
+			t.Fatalf("应该返回 Retry-After 且剩余次数为 0: %v", w.Header())
+		}
+		if !strings.Contains(w.Body.String(), "RATE_LIMITED") {
+			t.Fatalf("应该返回 RATE_LIMITED 错误码: %s", w.Body.String())
+		}
+		t.Logf("✅ Retry-After: %s 秒", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("健康检查不限流", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if w := get("/ping", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+				t.Fatalf("健康检查不应被限流，状态码: %d", w.Code)
+			}
+		}
+		t.Logf("✅ 健康检查不限流")
+	})
+
+	t.Run("一个请求中的多次操作各自计数", func(t *testing.T) {
+		engine := gin.New()
+		noRouteLimit := func(*gin.Context) (string, ratelimit.Limit) { return "", ratelimit.Limit{} }
+		charges := map[string]ratelimit.Limit{ratelimit.PolicyCreate: {Requests: 2, Period: time.Minute}}
+		engine.Use(middleware.RateLimit(ratelimit.NewMemoryStore(), noRouteLimit, charges))
+		// 模拟批量同步：一个请求中创建三条
+		engine.POST("/batch", func(c *gin.Context) {
+			for i := 0; i < 3; i++ {
+				if err := ratelimit.Charge(c.Request.Context(), ratelimit.PolicyCreate); err != nil {
+					utils.Fail(c, customerrors.AsAppError(err))
+					return
+				}
+			}
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", nil))
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("第三次创建应该返回 429 和 Retry-After，实际: %d %v", w.Code, w.Header())
+		}
+		if !strings.Contains(w.Body.String(), "RATE_LIMITED") {
+			t.Fatalf("应该返回 RATE_LIMITED 错误码: %s", w.Body.String())
+		}
+		t.Logf("✅ 批量请求按条计数，Retry-After: %s 秒", w.Header().Get("Retry-After"))
+	})
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TODO_CORS_ORIGINS", "https://todo.example.com,https://*.example.org")
+	r := SetupRouter()
+
+	send := func(method, path, origin, requestMethod string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", requestMethod)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("允许的来源：回显来源并允许凭据", func(t *testing.T) {
+		for _, origin := range []string{"https://todo.example.com", "https://app.example.org", "https://a.b.example.org"} {
+			w := send(http.MethodGet, "/ping", origin, "")
+			if w.Header().Get("Access-Control-Allow-Origin") != origin || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Fatalf("%s 应该被允许: %v", origin, w.Header())
+			}
+			if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "RateLimit-Remaining") {
+				t.Fatalf("应该暴露 RateLimit-* 响应头: %v", w.Header())
+			}
+			if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Origin") {
+				t.Fatalf("应该带有 Vary: Origin: %v", w.Header())
+			}
+		}
+		t.Logf("✅ 白名单和通配子域名都被允许")
+	})
+
+	t.Run("不允许的来源：没有跨域响应头，预检返回 403", func(t *testing.T) {
+		for _, origin := range []string{"https://evil.com", "https://example.org", "https://todo.example.com.evil.com"} {
+			if w := send(http.MethodGet, "/ping", origin, ""); w.Header().Get("Access-Control-Allow-Origin") != "" {
+				t.Fatalf("%s 不应被允许: %v", origin, w.Header())
+			}
+			if w := send(http.MethodOptions, "/api/todos", origin, "POST"); w.Code != http.StatusForbidden {
+				t.Fatalf("%s 的预检应该返回 403，实际: %d", origin, w.Code)
+			}
+		}
+		t.Logf("✅ 不在白名单中的来源被拒绝")
+	})
+
+	t.Run("预检只对存在的路由返回 204", func(t *testing.T) {
+		w := send(http.MethodOptions, "/api/v2/todos/42", "https://todo.example.com", "PUT")
+		if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Max-Age") != "600" {
+			t.Fatalf("预检应该返回 204 和 Max-Age，实际: %d %v", w.Code, w.Header())
+		}
+		if w := send(http.MethodOptions, "/api/no-such-route", "https://todo.example.com", "GET"); w.Code != http.StatusNotFound {
+			t.Fatalf("不存在的路由应该返回 404，实际: %d", w.Code)
+		}
+		if w := send(http.MethodOptions, "/api/v2/categories", "https://todo.example.com", "DELETE"); w.Code != http.StatusNotFound {
+			t.Fatalf("路由不支持的方法应该返回 404，实际: %d", w.Code)
+		}
+		t.Logf("✅ 预检按路由和方法判断")
+	})
+
+	t.Run("错误响应同时保留 Vary: Origin 和 Vary: Accept-Language", func(t *testing.T) {
+		w := send(http.MethodGet, "/api/todos/abc", "https://todo.example.com", "")
+		vary := strings.Join(w.Header().Values("Vary"), ",")
+		if w.Code != http.StatusBadRequest || !strings.Contains(vary, "Origin") || !strings.Contains(vary, "Accept-Language") {
+			t.Fatalf("应该同时带有两个 Vary，实际: %d %v", w.Code, w.Header().Values("Vary"))
+		}
+		t.Logf("✅ Vary: %s", vary)
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	t.Run("所有响应都带有安全响应头", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		for header, want := range map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+			"Referrer-Policy":        "strict-origin-when-cross-origin",
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s 应该为 %q，实际: %q", header, want, got)
+			}
+		}
+		if !strings.Contains(w.Header().Get("Content-Security-Policy"), "frame-ancestors 'none'") {
+			t.Errorf("缺少 CSP: %v", w.Header())
+		}
+		if w.Header().Get("Strict-Transport-Security") != "" {
+			t.Errorf("HTTP 请求不应带有 HSTS")
+		}
+		t.Logf("✅ CSP: %s", w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("HTTPS 请求带有 HSTS，文档页面按哈希放行内联脚本", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, DocsPath, nil)
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if !strings.HasPrefix(w.Header().Get("Strict-Transport-Security"), "max-age=") {
+			t.Errorf("应该带有 HSTS: %v", w.Header())
+		}
+		if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'sha256-") || strings.Contains(csp, "unsafe-inline") {
+			t.Errorf("文档页面的 CSP 应该使用哈希: %s", csp)
+		}
+		t.Logf("✅ HSTS: %s", w.Header().Get("Strict-Transport-Security"))
+	})
+
+	forwarded := func(r *gin.Engine) string {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.0.0.5:43210"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Header().Get("Strict-Transport-Security")
+	}
+
+	t.Run("不可信来源的 X-Forwarded-Proto 不会带来 HSTS", func(t *testing.T) {
+		if hsts := forwarded(r); hsts != "" {
+			t.Fatalf("未配置可信代理时不应带有 HSTS，实际: %s", hsts)
+		}
+		t.Logf("✅ 客户端伪造的 X-Forwarded-Proto 被忽略")
+	})
+
+	t.Run("可信代理转发的 HTTPS 请求带有 HSTS", func(t *testing.T) {
+		t.Setenv("TODO_TRUSTED_PROXIES", "10.0.0.0/8")
+		if hsts := forwarded(SetupRouter()); !strings.HasPrefix(hsts, "max-age=") {
+			t.Fatalf("可信代理转发时应该带有 HSTS，实际: %q", hsts)
+		}
+		t.Logf("✅ 可信代理 10.0.0.0/8 转发的请求带有 HSTS")
+	})
+}
+
+func TestCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+
+	post := func(origin, referer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+		req.Host = "todo.example.com"
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("来自其他网站的修改请求返回 403", func(t *testing.T) {
+		for _, c := range []struct{ origin, referer string }{
+			{"https://evil.com", ""},
+			{"null", ""},
+			{"", "https://evil.com/page"},
+		} {
+			w := post(c.origin, c.referer)
+			if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "CSRF_CHECK_FAILED") {
+				t.Fatalf("%+v 应该返回 403 CSRF_CHECK_FAILED，实际: %d %s", c, w.Code, w.Body.String())
+			}
+		}
+		t.Logf("✅ 跨站请求被拒绝")
+	})
+
+	t.Run("同源、白名单和非浏览器客户端的请求通过", func(t *testing.T) {
+		for _, c := range []struct{ origin, referer string }{
+			{"https://todo.example.com", ""},
+			{"http://localhost:5173", ""},
+			{"", "https://todo.example.com/todos"},
+			{"", ""},
+		} {
+			if w := post(c.origin, c.referer); w.Code != http.StatusOK {
+				t.Fatalf("%+v 应该通过，实际: %d %s", c, w.Code, w.Body.String())
+			}
+		}
+		t.Logf("✅ 同源请求通过")
+	})
+}
+
+// TestFrontend 测试前端页面转发到开发服务器，API 路由优先
+func TestFrontend(t *testing.T) {
+	if web.Embedded {
+		t.Skip("内嵌前端的构建不转发到开发服务器")
+	}
+	gin.SetMode(gin.TestMode)
+	vite := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("vite " + r.URL.Path))
+	}))
+	defer vite.Close()
+	t.Setenv("TODO_FRONTEND_DEV_URL", vite.URL)
+	r := SetupRouter()
+
+	t.Run("未匹配的页面路径转发到开发服务器，/api 下的不转发", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/42", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "vite /todos/42" {
+			t.Errorf("应该转发到开发服务器，实际: %d %s", w.Code, w.Body.String())
+		}
+		for _, path := range []string{"/api/no-such-route", "/api"} {
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusNotFound || strings.HasPrefix(w.Body.String(), "vite") {
+				t.Errorf("%s 应该返回 404，实际: %d %s", path, w.Code, w.Body.String())
+			}
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if strings.HasPrefix(w.Body.String(), "vite") {
+			t.Errorf("已注册的路由不应该转发")
+		}
+		t.Logf("✅ 页面请求转发到 %s", vite.URL)
+	})
+
+	t.Run("默认不转发，未匹配的路径返回 404", func(t *testing.T) {
+		t.Setenv("TODO_FRONTEND_DEV_URL", "")
+		w := httptest.NewRecorder()
+		SetupRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/42", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("没有配置开发服务器时应该返回 404，实际: %d %s", w.Code, w.Body.String())
+		}
+		t.Logf("✅ 默认不转发")
+	})
+}
+
+// TestProblemTypes 测试错误响应中的 type 可以访问到说明页面
+func TestProblemTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+	get := func(path, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("错误响应的 type 返回该错误码的说明", func(t *testing.T) {
+		w := get("/api/todos/abc", "en")
+		var problem struct {
+			Type string `json:"type"`
+			Code string `json:"error_code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil || problem.Type == "" {
+			t.Fatalf("应该返回 problem+json: %d %s", w.Code, w.Body.String())
+		}
+		page := get(problem.Type, "zh-CN")
+		if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), problem.Code) || page.Header().Get("Content-Language") != "zh-CN" {
+			t.Fatalf("%s 应该返回说明页面，实际: %d %s", problem.Type, page.Code, page.Body.String())
+		}
+		t.Logf("✅ %s -> %s", problem.Code, problem.Type)
+	})
+
+	t.Run("不存在的错误码返回 404", func(t *testing.T) {
+		for _, path := range []string{"/problems/no-such-error", "/problems/TODO_NOT_FOUND"} {
+			if w := get(path, "en"); w.Code != http.StatusNotFound {
+				t.Errorf("%s 应该返回 404，实际: %d", path, w.Code)
+			}
+		}
+		t.Logf("✅ 只有已知的错误码有说明页面")
+	})
+}
+
+// TestRequireAuth 测试 TODO_REQUIRE_AUTH 对数据接口的登录要求
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	send := func(r *gin.Engine, method, path string, header ...string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("writes：修改请求和 WebSocket 需要登录，读请求不需要", func(t *testing.T) {
+		t.Setenv("TODO_REQUIRE_AUTH", "writes")
+		r := SetupRouter()
+		for _, path := range []string{"/api/todos", "/api/v1/todos", "/api/v2/todos", "/api/sync", "/graphql"} {
+			if code := send(r, http.MethodPost, path); code != http.StatusUnauthorized {
+				t.Errorf("POST %s 应该返回 401，实际: %d", path, code)
+			}
+		}
+		if code := send(r, http.MethodGet, "/api/ws", "Connection", "Upgrade", "Upgrade", "websocket"); code != http.StatusUnauthorized {
+			t.Errorf("WebSocket 应该返回 401，实际: %d", code)
+		}
+		if code := send(r, http.MethodGet, "/api/v2/categories"); code != http.StatusOK {
+			t.Errorf("读请求不需要登录，实际: %d", code)
+		}
+		if code := send(r, http.MethodPost, "/api/auth/login"); code == http.StatusUnauthorized {
+			t.Errorf("登录接口不应要求登录")
+		}
+		t.Logf("✅ writes 模式")
+	})
+
+	t.Run("all：读请求也需要登录；off：都不需要", func(t *testing.T) {
+		t.Setenv("TODO_REQUIRE_AUTH", "all")
+		if code := send(SetupRouter(), http.MethodGet, "/api/v2/categories"); code != http.StatusUnauthorized {
+			t.Errorf("all 模式下读请求应该返回 401，实际: %d", code)
+		}
+		t.Setenv("TODO_REQUIRE_AUTH", "off")
+		if code := send(SetupRouter(), http.MethodGet, "/api/v2/categories"); code != http.StatusOK {
+			t.Errorf("off 模式下不需要登录，实际: %d", code)
+		}
+		t.Logf("✅ all 和 off 模式")
+	})
+}
+
+// TestAddWebhookEvents 测试不支持和不存在的事件名经过参数绑定后由 Service 层返回具体的错误码
+func TestAddWebhookEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 只测试参数校验，不经过登录，校验失败时不访问数据库
+	r := gin.New()
+	r.POST("/api/webhooks", controllers.AddWebhook)
+	add := func(events string) *httptest.ResponseRecorder {
+		body := `{"url":"https://hooks.example.com/todo","secret":"webhook-secret","events":[` + events + `]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("overdue 返回 WEBHOOK_EVENT_UNSUPPORTED，拼错的事件返回 INVALID_WEBHOOK_EVENT", func(t *testing.T) {
+		for events, code := range map[string]string{
+			`"created","overdue"`: "WEBHOOK_EVENT_UNSUPPORTED",
+			`"complete"`:          "INVALID_WEBHOOK_EVENT",
+		} {
+			w := add(events)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error_code":"`+code+`"`) {
+				t.Errorf("[%s] 应该返回 400 %s，实际: %d %s", events, code, w.Code, w.Body.String())
+			}
+		}
+		t.Logf("✅ 事件名由 Service 层校验")
+	})
+}
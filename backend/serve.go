@@ -0,0 +1,317 @@
+package main
+
+import (
+	"backend/collab"
+	"backend/config"
+	"backend/events"
+	"backend/grpcserver"
+	"backend/lifecycle"
+	"backend/metrics"
+	"backend/migrations"
+	"backend/router"
+	"backend/services"
+	"backend/tlsreload"
+	"backend/tracing"
+	"backend/web"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// serve 启动 HTTP 和 gRPC 服务，直到收到退出信号
+func serve(args []string) error {
+	fs := newFlagSet("serve", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	return serveUntil(quit)
+}
+
+// serveUntil 启动服务，直到 quit 收到信号或 HTTP 服务出错
+// 每个启动步骤成功后用 defer 注册对应的停止步骤，任何一步出错返回时都按启动的相反顺序停止已经启动的部分；
+// 监听中的服务由 shutdown 先行关闭，之后依次停止发件箱和 Webhook 投递、关闭数据库连接池、导出剩余的 span
+func serveUntil(quit <-chan os.Signal) error {
+	// 按配置导出链路追踪数据，退出时把缓冲中剩余的 span 导出
+	tracingConfig := config.GetTracingConfig()
+	flushTraces, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    tracingConfig.Exporter,
+		File:        tracingConfig.File,
+		SampleRatio: tracingConfig.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := flushTraces(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	// 初始化数据库连接
+	if err := config.InitDB(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := config.Close(); err != nil {
+			slog.Error("failed to close database", "error", err)
+		}
+	}()
+	warnPendingMigrations()
+	if sqlDB, err := config.DB.DB(); err == nil {
+		metrics.RegisterDB(sqlDB, config.GetDatabaseConfig().DBName)
+	}
+
+	// 配置了证书时使用 HTTPS，证书文件变化后自动重新加载
+	tlsConfig := config.GetTLSConfig()
+	var certs *tlsreload.Reloader
+	if tlsConfig.Enabled() {
+		if certs, err = newTLSReloader(tlsConfig); err != nil {
+			return err
+		}
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go certs.Watch(watchCtx)
+	}
+
+	// 待办事项数量上限
+	services.MaxTodos = config.GetRateLimitConfig().MaxTodos
+
+	// Webhook 只允许投递到公网地址和白名单中的内网目标
+	services.WebhookAllowedTargets = services.NewTargetAllowlist(config.GetWebhookConfig().AllowedTargets)
+
+	// 配置路由
+	r := router.SetupRouter()
+
+	// 启动 Webhook 投递协程
+	services.DefaultWebhookDispatcher.Start()
+	defer services.DefaultWebhookDispatcher.Stop()
+
+	// 按配置添加可选的发件箱投递目标，启动发件箱投递协程
+	outboxConfig := config.GetOutboxConfig()
+	if outboxConfig.LogEnabled {
+		services.DefaultOutboxDispatcher.AddSink(services.NewLogSink())
+	}
+	if outboxConfig.FilePath != "" {
+		fileSink, err := services.NewFileSink(outboxConfig.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to open outbox file: %w", err)
+		}
+		services.DefaultOutboxDispatcher.AddSink(fileSink)
+	}
+	services.DefaultOutboxDispatcher.Start()
+	// 在 Webhook 投递之前停止：请求都已结束，不会再有新的事件入队
+	defer services.DefaultOutboxDispatcher.Stop()
+
+	if config.GetAuthConfig().Required == config.AuthRequiredOff {
+		slog.Warn("TODO_REQUIRE_AUTH=off, todo, sync and GraphQL APIs accept anonymous requests", "component", "auth")
+	}
+
+	// 指标接口：配置了单独的监听地址时只在该地址上提供，接口端口上不再暴露
+	metricsConfig := config.GetMetricsConfig()
+	if metricsConfig.Public() {
+		slog.Warn("/metrics is public on the API port, set TODO_METRICS_ADDR or TODO_METRICS_TOKEN to restrict it", "component", "metrics")
+	}
+	if metricsConfig.Addr != "" {
+		metricsSrv, err := startMetrics(metricsConfig)
+		if err != nil {
+			return err
+		}
+		defer metricsSrv.Close()
+	}
+
+	// 在单独的端口上启动 gRPC 服务，与 HTTP 接口共用 TodoService 和事件中心
+	grpcServer, err := startGRPC(config.GetGRPCConfig())
+	if err != nil {
+		return err
+	}
+
+	// 启动 HTTP 服务，监听失败（如端口被占用）时直接退出
+	serverConfig := config.GetServerConfig()
+	srv := &http.Server{
+		Addr:              serverConfig.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+	scheme := "http"
+	if certs != nil {
+		// 启用 HTTP/2，浏览器的 WebSocket 仍使用 HTTP/1.1 连接
+		srv.TLSConfig = certs.TLSConfig()
+		scheme = "https"
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			serveErr <- srv.ListenAndServeTLS("", "")
+		} else {
+			serveErr <- srv.ListenAndServe()
+		}
+	}()
+	slog.Info("server starting", "addr", serverConfig.Addr, "api", fmt.Sprintf("%s://localhost%s/api/todos", scheme, serverConfig.Addr))
+	if web.Embedded {
+		slog.Info("frontend embedded", "url", fmt.Sprintf("%s://localhost%s/", scheme, serverConfig.Addr))
+	} else if devURL := config.GetFrontendConfig().DevURL; devURL != "" {
+		slog.Info("frontend requests proxied to Vite dev server", "url", devURL)
+	}
+
+	select {
+	case err := <-serveErr:
+		shutdown(nil, grpcServer, serverConfig)
+		return err
+	case sig := <-quit:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	// 退出过程中再次收到信号时立即退出
+	go func() {
+		<-quit
+		slog.Warn("received second signal, exiting immediately")
+		os.Exit(exitError)
+	}()
+	shutdown(srv, grpcServer, serverConfig)
+	slog.Info("server stopped")
+	return nil
+}
+
+// shutdown 关闭监听中的服务：标记为未就绪并等待负载均衡摘除，断开长连接，
+// 停止接受新连接并等待进行中的请求完成；后台投递和数据库连接池由 serveUntil 的 defer 随后关闭
+func shutdown(srv *http.Server, grpcServer *grpc.Server, cfg *config.ServerConfig) {
+	if srv != nil && cfg.DrainDelay > 0 {
+		lifecycle.StartDraining()
+		slog.Info("draining before closing listeners", "delay", cfg.DrainDelay)
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	// SSE 和 gRPC 推送随之结束，WebSocket 连接由协作中心通知并断开
+	lifecycle.Shutdown()
+	collab.DefaultHub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	if srv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Warn("HTTP server did not stop in time, closing remaining connections", "timeout", cfg.ShutdownTimeout, "error", err)
+				srv.Close()
+			}
+		}()
+	}
+	if grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopGRPC(ctx, grpcServer)
+		}()
+	}
+	wg.Wait()
+}
+
+// newTLSReloader 按配置加载证书；配置了客户端 CA 时启用双向 TLS
+func newTLSReloader(cfg *config.TLSConfig) (*tlsreload.Reloader, error) {
+	if cfg.KeyFile == "" {
+		return nil, fmt.Errorf("TODO_TLS_KEY is required when TODO_TLS_CERT is set")
+	}
+	clientAuth := tls.RequireAndVerifyClientCert
+	if cfg.ClientAuth == "optional" {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsreload.New(tlsreload.Options{
+		CertFile:     cfg.CertFile,
+		KeyFile:      cfg.KeyFile,
+		ClientCAFile: cfg.ClientCAFile,
+		ClientAuth:   clientAuth,
+		Interval:     cfg.ReloadInterval,
+	})
+}
+
+// warnPendingMigrations 有未执行的迁移时打印提示，不阻止启动
+func warnPendingMigrations() {
+	pending, err := migrations.Pending(config.DB)
+	if err != nil {
+		slog.Error("failed to check migrations", "component", "migrate", "error", err)
+		return
+	}
+	if len(pending) > 0 {
+		slog.Warn("pending migrations, run `backend migrate up`", "component", "migrate", "pending", len(pending))
+	}
+}
+
+// startMetrics 在单独的地址上提供 /metrics，监听失败时返回错误；退出时直接关闭，不需要等待
+func startMetrics(cfg *config.MetricsConfig) (*http.Server, error) {
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(cfg.Token))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		slog.Info("metrics server starting", "component", "metrics", "url", fmt.Sprintf("http://%s/metrics", cfg.Addr))
+		if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server stopped", "component", "metrics", "error", err)
+		}
+	}()
+	return srv, nil
+}
+
+// startGRPC 启动 gRPC 服务，地址为 off 时不启动并返回 nil
+func startGRPC(cfg *config.GRPCConfig) (*grpc.Server, error) {
+	if cfg.Addr == "off" {
+		return nil, nil
+	}
+	// 没有访问令牌时不校验身份，只允许监听回环地址，否则拒绝启动
+	if cfg.Token == "" {
+		if !cfg.Loopback() {
+			return nil, fmt.Errorf("TODO_GRPC_TOKEN is required when gRPC listens on %s (non-loopback), or set TODO_GRPC_ADDR=off", cfg.Addr)
+		}
+		slog.Warn("TODO_GRPC_TOKEN is not set, authentication disabled; listening on loopback only", "component", "grpc", "addr", cfg.Addr)
+	}
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+	srv := grpcserver.New(services.NewTodoService(), events.DefaultBroker, cfg.Token, config.GetServerConfig().RequestTimeout)
+	go func() {
+		// gRPC 不使用 TODO_TLS_CERT，始终为明文，跨主机访问时应放在内网或由代理终结 TLS
+		slog.Info("gRPC server starting (plaintext)", "component", "grpc", "addr", cfg.Addr)
+		if err := srv.Serve(lis); err != nil {
+			slog.Error("gRPC server stopped", "component", "grpc", "error", err)
+		}
+	}()
+	return srv, nil
+}
+
+// stopGRPC 等待进行中的调用结束，ctx 结束后强制停止
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestServeShutdown 测试收到退出信号后先标记为未就绪，等待期间照常响应，之后才关闭监听
+func TestServeShutdown(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("获取空闲端口失败: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	t.Setenv("TODO_HTTP_ADDR", addr)
+	t.Setenv("TODO_GRPC_ADDR", "off")
+	t.Setenv("TODO_METRICS_ADDR", "")
+	t.Setenv("TODO_SHUTDOWN_DRAIN_DELAY", "500ms")
+
+	quit := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() { done <- serveUntil(quit) }()
+
+	// 每次检查都新建连接，监听关闭后立即失败
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	readyz := func() (int, error) {
+		resp, err := client.Get("http://" + addr + "/readyz")
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if status, _ := readyz(); status == http.StatusOK {
+			break
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("服务启动失败: %v", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("服务没有就绪")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Log("✅ 服务已就绪")
+
+	quit <- syscall.SIGTERM
+	notReady := false
+	for {
+		status, err := readyz()
+		if err != nil {
+			break
+		}
+		if status == http.StatusServiceUnavailable {
+			notReady = true
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("监听没有关闭")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !notReady {
+		t.Fatal("关闭监听之前 /readyz 应该返回 503")
+	}
+	t.Log("✅ /readyz 先返回 503，之后才关闭监听")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("退出时返回错误: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("服务没有退出")
+	}
+	t.Log("✅ 服务正常退出")
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginResult 登录结果，Token 只在登录时返回一次，服务端只保存其哈希
+type LoginResult struct {
+	User      *models.User
+	Token     string
+	CSRFToken string
+	ExpiresAt time.Time
+}
+
+// AuthService 登录会话服务
+// 会话令牌是 32 字节随机数，浏览器放在 HttpOnly Cookie 中，其他客户端放在 Authorization: Bearer 中；
+// CSRF 令牌由会话令牌派生，不需要单独保存，不知道会话令牌就无法算出
+type AuthService struct {
+	ttl time.Duration
+}
+
+// NewAuthService 创建登录会话服务，ttl 为会话的有效期
+func NewAuthService(ttl time.Duration) *AuthService {
+	return &AuthService{ttl: ttl}
+}
+
+// dummyHash 用户不存在时用来校验密码的哈希，使两种情况的耗时相同，不能据此判断用户名是否存在
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("dummy password")
+	return hash
+})
+
+// Login 校验用户名和密码并创建会话，用户不存在和密码错误返回同一个错误
+func (s *AuthService) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	user, err := models.GetUserByUsername(ctx, username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.WrapQueryUserError(err)
+	}
+	if user == nil {
+		VerifyPassword(password, dummyHash())
+		return nil, customerrors.ErrInvalidCredentials
+	}
+	if !VerifyPassword(password, user.PasswordHash) {
+		return nil, customerrors.ErrInvalidCredentials
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := models.DeleteExpiredSessions(ctx, user.ID, now); err != nil {
+		return nil, customerrors.WrapSessionError(err)
+	}
+	session := &models.Session{TokenHash: hashToken(token), UserID: user.ID, ExpiresAt: now.Add(s.ttl)}
+	if err := session.Create(ctx); err != nil {
+		return nil, customerrors.WrapSessionError(err)
+	}
+	return &LoginResult{User: user, Token: token, CSRFToken: CSRFToken(token), ExpiresAt: session.ExpiresAt}, nil
+}
+
+// Authenticate 按会话令牌查询用户，令牌无效或已过期时返回 UNAUTHENTICATED
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	user, err := models.GetSessionUser(ctx, hashToken(token), time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, customerrors.WrapSessionError(err)
+	}
+	return user, nil
+}
+
+// AuthenticateCertificate 按客户端证书的 CN 查询同名用户，证书由 TLS 层校验，没有对应的用户时返回 UNAUTHENTICATED
+func (s *AuthService) AuthenticateCertificate(ctx context.Context, commonName string) (*models.User, error) {
+	user, err := models.GetUserByUsername(ctx, commonName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, customerrors.WrapQueryUserError(err)
+	}
+	return user, nil
+}
+
+// Logout 删除会话，令牌已失效时不报错
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if err := models.DeleteSession(ctx, hashToken(token)); err != nil {
+		return customerrors.WrapSessionError(err)
+	}
+	return nil
+}
+
+// userIDKey 请求上下文中已登录用户的 ID
+type userIDKey struct{}
+
+// WithUserID 在上下文中记录已登录用户的 ID，由认证中间件设置，Service 层据此记录创建者
+func WithUserID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFromContext 上下文中已登录用户的 ID，匿名请求返回 false
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userIDKey{}).(uint)
+	return id, ok
+}
+
+// adminKey 请求上下文中已登录用户是否为管理员
+type adminKey struct{}
+
+// WithAdmin 在上下文中记录已登录用户是管理员，由认证中间件设置，Service 层据此放开按创建者的限制
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey{}, true)
+}
+
+// IsAdmin 上下文中已登录的用户是否为管理员
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+// CSRFToken 会话的 CSRF 令牌
+func CSRFToken(sessionToken string) string {
+	sum := sha256.Sum256([]byte("csrf:" + sessionToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// newSessionToken 生成会话令牌
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 令牌的 SHA-256，令牌本身是高熵随机数，不需要加盐和慢哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestAuthService 测试登录、会话校验和退出登录
+func TestAuthService(t *testing.T) {
+	ctx := context.Background()
+	auth := NewAuthService(time.Hour)
+	username := fmt.Sprintf("auth-test-%d", time.Now().UnixNano())
+	if _, err := NewUserService().CreateUser(ctx, &models.CreateUserInput{Username: username, Password: "correct horse"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	t.Run("用户名或密码错误返回同一个错误", func(t *testing.T) {
+		for _, c := range []struct{ username, password string }{
+			{username, "wrong password"},
+			{username + "-missing", "correct horse"},
+		} {
+			if _, err := auth.Login(ctx, c.username, c.password); !errors.Is(err, customerrors.ErrInvalidCredentials) {
+				t.Fatalf("应该返回 INVALID_CREDENTIALS，实际: %v", err)
+			}
+		}
+		t.Logf("✅ 登录失败不区分用户名和密码")
+	})
+
+	t.Run("登录后令牌可用，退出后失效", func(t *testing.T) {
+		result, err := auth.Login(ctx, username, "correct horse")
+		if err != nil {
+			t.Fatalf("登录失败: %v", err)
+		}
+		if result.CSRFToken != CSRFToken(result.Token) || result.CSRFToken == result.Token {
+			t.Fatal("CSRF 令牌应该由会话令牌派生且不同于会话令牌")
+		}
+
+		user, err := auth.Authenticate(ctx, result.Token)
+		if err != nil || user.Username != username {
+			t.Fatalf("令牌应该对应登录的用户，实际: %v %v", user, err)
+		}
+
+		if err := auth.Logout(ctx, result.Token); err != nil {
+			t.Fatalf("退出登录失败: %v", err)
+		}
+		if _, err := auth.Authenticate(ctx, result.Token); !errors.Is(err, customerrors.ErrUnauthenticated) {
+			t.Fatalf("退出后应该返回 UNAUTHENTICATED，实际: %v", err)
+		}
+		t.Logf("✅ 会话有效期至 %s", result.ExpiresAt.Format(time.RFC3339))
+	})
+
+	t.Run("过期的会话无效", func(t *testing.T) {
+		result, err := NewAuthService(-time.Minute).Login(ctx, username, "correct horse")
+		if err != nil {
+			t.Fatalf("登录失败: %v", err)
+		}
+		if _, err := auth.Authenticate(ctx, result.Token); !errors.Is(err, customerrors.ErrUnauthenticated) {
+			t.Fatalf("过期的会话应该返回 UNAUTHENTICATED，实际: %v", err)
+		}
+		t.Logf("✅ 过期的会话被拒绝")
+	})
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"backend/lifecycle"
+	"backend/migrations"
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout 就绪检查中每项依赖检查的超时时间，应小于编排系统探针的超时
+const healthCheckTimeout = 2 * time.Second
+
+// 就绪状态
+const (
+	HealthReady    = "ready"
+	HealthNotReady = "not_ready"
+	HealthDraining = "draining"
+)
+
+// WorkerStatus 后台协程的运行状态
+type WorkerStatus struct {
+	Running  bool       `json:"running"`
+	LastPoll *time.Time `json:"last_poll,omitempty"` // 最近一次轮询完成的时间
+}
+
+// newWorkerStatus 由 UnixNano 形式的轮询时间构造运行状态，0 表示还没有完成过轮询
+func newWorkerStatus(running bool, lastPoll int64) WorkerStatus {
+	status := WorkerStatus{Running: running}
+	if lastPoll != 0 {
+		t := time.Unix(0, lastPoll)
+		status.LastPoll = &t
+	}
+	return status
+}
+
+// HealthCheck 一项依赖检查的结果
+// Critical 的检查失败时实例不再就绪；非 Critical 的只做报告（如后台投递协程停了不影响处理请求）
+type HealthCheck struct {
+	Name      string      `json:"name"`
+	OK        bool        `json:"ok"`
+	Critical  bool        `json:"critical"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Detail    interface{} `json:"detail,omitempty"`
+}
+
+// HealthReport 就绪检查结果
+type HealthReport struct {
+	Status string        `json:"status"` // ready、not_ready 或 draining
+	Checks []HealthCheck `json:"checks"`
+}
+
+// Ready 是否就绪
+func (r *HealthReport) Ready() bool {
+	return r.Status == HealthReady
+}
+
+// HealthService 就绪检查：数据库连通性、迁移版本、后台投递协程
+type HealthService struct {
+	outbox   *OutboxDispatcher
+	webhooks *WebhookDispatcher
+}
+
+func NewHealthService(outbox *OutboxDispatcher, webhooks *WebhookDispatcher) *HealthService {
+	return &HealthService{outbox: outbox, webhooks: webhooks}
+}
+
+// Readiness 执行所有检查；服务正在退出时状态为 draining
+func (s *HealthService) Readiness(ctx context.Context) *HealthReport {
+	report := &HealthReport{Status: HealthReady}
+	report.Checks = []HealthCheck{
+		runCheck(ctx, "database", true, func(ctx context.Context) (interface{}, error) {
+			return nil, models.PingDB(ctx)
+		}),
+		runCheck(ctx, "migrations", true, checkMigrations),
+		runCheck(ctx, "outbox_dispatcher", false, workerCheck(s.outbox.Status)),
+		runCheck(ctx, "webhook_dispatcher", false, workerCheck(s.webhooks.Status)),
+	}
+
+	for _, c := range report.Checks {
+		if c.Critical && !c.OK {
+			report.Status = HealthNotReady
+		}
+	}
+	if !lifecycle.Ready() {
+		report.Status = HealthDraining
+	}
+	return report
+}
+
+// runCheck 带超时执行一项检查并记录耗时
+func runCheck(ctx context.Context, name string, critical bool, check func(ctx context.Context) (interface{}, error)) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := HealthCheck{
+		Name:      name,
+		OK:        err == nil,
+		Critical:  critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Detail:    detail,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// warnNotAdopted 数据库还没有采用版本化迁移的警告只打印一次，就绪检查会被频繁调用
+var warnNotAdopted sync.Once
+
+// checkMigrations 数据库的迁移版本不能低于代码要求的版本；高于时（新版本回滚到旧版本）只报告不失败
+// 没有 schema_migrations 表或其中没有记录时，视为引入版本化迁移之前按文档手工建的库（基线），
+// 不判为未就绪，只在结果中标记 adopted: false 并打印警告，执行 migrate up 后开始检查版本
+func checkMigrations(ctx context.Context) (interface{}, error) {
+	expected, err := migrations.Latest()
+	if err != nil {
+		return nil, err
+	}
+	current, err := models.SchemaVersion(ctx)
+	if errors.Is(err, migrations.ErrNotAdopted) || (err == nil && current == 0) {
+		warnNotAdopted.Do(func() {
+			slog.Warn("database has no recorded migrations, treating it as the baseline; run `backend migrate up` to adopt versioned migrations",
+				"expected", expected)
+		})
+		return map[string]interface{}{"current": 0, "expected": expected, "adopted": false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail := map[string]interface{}{"current": current, "expected": expected, "adopted": true}
+	if current < expected {
+		return detail, fmt.Errorf("schema version %d is behind %d, run `backend migrate up`", current, expected)
+	}
+	return detail, nil
+}
+
+// workerCheck 后台协程未运行时检查失败
+func workerCheck(status func() WorkerStatus) func(context.Context) (interface{}, error) {
+	return func(context.Context) (interface{}, error) {
+		s := status()
+		if !s.Running {
+			return s, fmt.Errorf("not running")
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"backend/config"
+	"context"
+	"net/http"
+	"testing"
+)
+
+// TestReadiness 测试就绪检查
+func TestReadiness(t *testing.T) {
+	t.Run("后台协程未运行只报告，不影响就绪状态", func(t *testing.T) {
+		outbox := NewOutboxDispatcher()
+		webhooks := NewWebhookDispatcher(http.DefaultClient)
+		report := NewHealthService(outbox, webhooks).Readiness(context.Background())
+
+		criticalOK := true
+		for _, c := range report.Checks {
+			switch c.Name {
+			case "database":
+				if !c.OK {
+					t.Errorf("数据库检查应该通过: %s", c.Error)
+				}
+			case "outbox_dispatcher", "webhook_dispatcher":
+				if c.OK || c.Critical {
+					t.Errorf("未启动的协程应该报告为非关键的失败: %+v", c)
+				}
+			}
+			if c.Critical && !c.OK {
+				criticalOK = false
+			}
+		}
+		if report.Ready() != criticalOK {
+			t.Errorf("就绪状态应该只取决于关键检查，实际: %+v", report)
+		}
+
+		outbox.Start()
+		defer outbox.Stop()
+		if status := outbox.Status(); !status.Running {
+			t.Errorf("启动后应该为运行状态")
+		}
+		t.Logf("✅ 就绪状态: %s", report.Status)
+	})
+
+	t.Run("没有 schema_migrations 表的数据库视为基线，不判为未就绪", func(t *testing.T) {
+		const name = "todo_baseline_test"
+		if err := config.DB.Exec("CREATE DATABASE IF NOT EXISTS " + name).Error; err != nil {
+			t.Fatalf("创建数据库失败: %v", err)
+		}
+		original := config.DB
+		defer func() { config.DB = original }()
+		cfg := *config.GetDatabaseConfig()
+		cfg.DBName = name
+		if err := config.Connect(&cfg); err != nil {
+			t.Fatalf("连接失败: %v", err)
+		}
+		defer config.DB.Exec("DROP DATABASE " + name)
+
+		detail, err := checkMigrations(context.Background())
+		if err != nil {
+			t.Fatalf("基线数据库不应判为未就绪: %v", err)
+		}
+		if adopted := detail.(map[string]interface{})["adopted"]; adopted != false {
+			t.Errorf("应该标记为未采用版本化迁移，实际: %v", detail)
+		}
+		t.Logf("✅ 迁移检查: %v", detail)
+	})
+}
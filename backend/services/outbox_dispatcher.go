@@ -0,0 +1,273 @@
+package services
+
+import (
+	"backend/events"
+	"backend/models"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// outboxPollInterval 轮询新事件的间隔，事务提交后会立即通知，轮询只是兜底
+	outboxPollInterval = 2 * time.Second
+	// outboxBatchSize 每次读取的事件条数
+	outboxBatchSize = 100
+	// outboxLease 共享投递目标的租约时长，实例崩溃后到期由其他实例接手
+	outboxLease = 30 * time.Second
+	// outboxMaxBackoff 投递目标连续失败时的最长等待时间
+	outboxMaxBackoff = time.Minute
+	// outboxRetention 事件保留时长，超过且所有共享投递目标都已处理过的事件会被清理
+	outboxRetention = 7 * 24 * time.Hour
+	// outboxPurgeInterval 清理旧事件的间隔
+	outboxPurgeInterval = time.Hour
+)
+
+// outboxTarget 一个投递目标及其投递进度
+// 共享目标（Webhook、日志、文件）的进度保存在数据库中，集群内只投递一次；
+// 本地目标（进程内事件中心）每个实例各自投递，进度只保存在内存中，从启动时的最新事件开始
+type outboxTarget struct {
+	sink     OutboxSink
+	shared   bool
+	lastSeq  int64 // 本地目标的投递进度
+	failures int
+	retryAt  time.Time
+}
+
+// OutboxDispatcher 发件箱投递协程：按序号顺序读取事件并分别投递到各个目标
+// 某个目标失败时只有它自己暂停并稍后从失败的事件重试，不影响其他目标
+type OutboxDispatcher struct {
+	owner    string // 实例标识，用于共享目标的租约
+	interval time.Duration
+	nudge    chan struct{}
+
+	mu        sync.Mutex
+	targets   []*outboxTarget
+	startSeq  int64 // 启动时的最新事件序号，新建的游标从这里开始
+	started   bool  // startSeq 是否已读取
+	lastPurge time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+	lastPoll  atomic.Int64 // 最近一次轮询完成的时间（UnixNano），就绪检查使用
+}
+
+// DefaultOutboxDispatcher 全局发件箱投递协程，默认投递到进程内事件中心和 Webhook，由 main 启动和停止
+var DefaultOutboxDispatcher = newDefaultOutboxDispatcher()
+
+func newDefaultOutboxDispatcher() *OutboxDispatcher {
+	d := NewOutboxDispatcher()
+	d.AddLocalSink(NewBusSink(events.DefaultBroker))
+	d.AddSink(NewWebhookSink(NewWebhookService(DefaultWebhookDispatcher)))
+	return d
+}
+
+func NewOutboxDispatcher() *OutboxDispatcher {
+	hostname, _ := os.Hostname()
+	return &OutboxDispatcher{
+		owner:    fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		interval: outboxPollInterval,
+		nudge:    make(chan struct{}, 1),
+	}
+}
+
+// AddSink 添加共享投递目标，需在 Start 之前调用
+func (d *OutboxDispatcher) AddSink(sink OutboxSink) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.targets = append(d.targets, &outboxTarget{sink: sink, shared: true})
+}
+
+// AddLocalSink 添加本地投递目标，需在 Start 之前调用
+func (d *OutboxDispatcher) AddLocalSink(sink OutboxSink) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.targets = append(d.targets, &outboxTarget{sink: sink, lastSeq: -1})
+}
+
+// Start 启动投递协程，重复调用无效
+func (d *OutboxDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go d.run(ctx, d.done)
+}
+
+// Stop 停止投递协程并释放持有的租约，关闭实现了 io.Closer 的投递目标
+// 未投递的事件保留在发件箱中，下次启动或由其他实例继续投递
+func (d *OutboxDispatcher) Stop() {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel, d.done = nil, nil
+	d.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+
+	for _, t := range d.targets {
+		if t.shared {
+			if err := models.ReleaseOutboxCursor(t.sink.Name(), d.owner); err != nil {
+				slog.Error("failed to release cursor", "component", "outbox", "sink", t.sink.Name(), "error", err)
+			}
+		}
+		if closer, ok := t.sink.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// Status 投递协程的运行状态
+func (d *OutboxDispatcher) Status() WorkerStatus {
+	d.mu.Lock()
+	running := d.cancel != nil
+	d.mu.Unlock()
+	return newWorkerStatus(running, d.lastPoll.Load())
+}
+
+// Notify 通知有新的事件提交，立即投递而不必等到下次轮询
+func (d *OutboxDispatcher) Notify() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// run 轮询循环
+func (d *OutboxDispatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.processAll(ctx)
+		d.purge()
+		d.lastPoll.Store(time.Now().UnixNano())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.nudge:
+		}
+	}
+}
+
+// processAll 依次处理所有投递目标
+func (d *OutboxDispatcher) processAll(ctx context.Context) {
+	if !d.started {
+		seq, err := models.LatestOutboxSeq()
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to read latest event", "component", "outbox", "error", err)
+			return
+		}
+		d.startSeq, d.started = seq, true
+	}
+
+	now := time.Now()
+	for _, t := range d.targets {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.Before(t.retryAt) {
+			continue
+		}
+
+		if err := d.process(ctx, t); err != nil {
+			t.failures++
+			backoff := time.Second << min(t.failures-1, 6)
+			if backoff > outboxMaxBackoff {
+				backoff = outboxMaxBackoff
+			}
+			t.retryAt = time.Now().Add(backoff)
+			slog.WarnContext(ctx, "sink failed", "component", "outbox", "sink", t.sink.Name(), "failures", t.failures, "retry_in", backoff, "error", err)
+			continue
+		}
+		t.failures = 0
+	}
+}
+
+// process 将一个目标的未投递事件全部投递完，遇到失败立即返回，进度停在失败的事件之前
+func (d *OutboxDispatcher) process(ctx context.Context, t *outboxTarget) error {
+	name := t.sink.Name()
+	if !t.shared && t.lastSeq < 0 {
+		t.lastSeq = d.startSeq
+	}
+
+	for ctx.Err() == nil {
+		lastSeq := t.lastSeq
+		if t.shared {
+			// 每批之前续期租约，其他实例持有租约时跳过
+			cursor, ok, err := models.ClaimOutboxCursor(name, d.owner, d.startSeq, time.Now(), outboxLease)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			lastSeq = cursor.LastSeq
+		}
+
+		batch, err := models.GetOutboxEventsAfter(lastSeq, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			if err := t.sink.Publish(ctx, &batch[i]); err != nil {
+				return fmt.Errorf("event %d: %w", batch[i].Seq, err)
+			}
+			if !t.shared {
+				t.lastSeq = batch[i].Seq
+				continue
+			}
+			// 逐条保存进度，进程中途退出时最多重复投递一条
+			ok, err := models.AdvanceOutboxCursor(name, d.owner, batch[i].Seq)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// 租约已被其他实例接手
+				return nil
+			}
+		}
+
+		if len(batch) < outboxBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// purge 定期清理所有共享目标都已处理过的旧事件
+func (d *OutboxDispatcher) purge() {
+	if time.Since(d.lastPurge) < outboxPurgeInterval {
+		return
+	}
+	d.lastPurge = time.Now()
+
+	purged, err := models.PurgeOutboxEvents(time.Now().Add(-outboxRetention))
+	if err != nil {
+		slog.Error("failed to purge old events", "component", "outbox", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("purged old events", "component", "outbox", "count", purged)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingSink 记录收到的事件，failing 为 true 时投递失败
+type recordingSink struct {
+	name    string
+	failing bool
+	events  []models.OutboxEvent
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if s.failing {
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+// TestOutboxDispatch 测试领域事件随数据变更写入发件箱并投递到各个目标
+func TestOutboxDispatch(t *testing.T) {
+	recorder := &recordingSink{name: "test-recorder"}
+	failing := &recordingSink{name: "test-failing", failing: true}
+	local := &recordingSink{name: "test-local"}
+
+	dispatcher := NewOutboxDispatcher()
+	dispatcher.AddSink(failing)
+	dispatcher.AddSink(recorder)
+	dispatcher.AddLocalSink(local)
+	// 测试用的游标不保留，否则会阻止旧事件的清理
+	t.Cleanup(func() {
+		config.DB.Where("sink LIKE ?", "test-%").Delete(&models.OutboxCursor{})
+	})
+	ctx := context.Background()
+	// 第一次处理确定起始序号，之前的事件不投递
+	dispatcher.processAll(ctx)
+	recorder.events, local.events = nil, nil
+
+	created, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "发件箱测试", Category: "work"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	completed, err := service.UpdateTodoStatus(context.Background(), created.ID, &models.UpdateStatusInput{Completed: true, Version: created.Version})
+	if err != nil {
+		t.Fatalf("更新状态失败: %v", err)
+	}
+	reopened, err := service.UpdateTodoStatus(context.Background(), created.ID, &models.UpdateStatusInput{Completed: false, Version: completed.Version})
+	if err != nil {
+		t.Fatalf("更新状态失败: %v", err)
+	}
+	if err := service.DeleteTodo(context.Background(), created.ID); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+
+	dispatcher.processAll(ctx)
+
+	expected := []struct {
+		eventType string
+		version   int
+	}{
+		{models.EventTodoCreated, created.Version},
+		{models.EventTodoCompleted, completed.Version},
+		{models.EventTodoReopened, reopened.Version},
+		{models.EventTodoDeleted, reopened.Version + 1},
+	}
+
+	t.Run("事件按提交顺序投递并携带版本号", func(t *testing.T) {
+		var got []models.OutboxEvent
+		for _, e := range recorder.events {
+			if e.AggregateID == created.ID {
+				got = append(got, e)
+			}
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("应该收到 %d 个事件，实际 %d 个", len(expected), len(got))
+		}
+		for i, e := range got {
+			if e.EventType != expected[i].eventType || e.Version != expected[i].version {
+				t.Errorf("第 %d 个事件应该为 %s v%d，实际 %s v%d", i+1, expected[i].eventType, expected[i].version, e.EventType, e.Version)
+			}
+			if i > 0 && e.Seq <= got[i-1].Seq {
+				t.Errorf("事件序号应该递增: %d <= %d", e.Seq, got[i-1].Seq)
+			}
+		}
+		t.Logf("✅ 收到事件: %s %s %s %s", got[0].EventType, got[1].EventType, got[2].EventType, got[3].EventType)
+	})
+
+	t.Run("失败的目标不影响其他目标，恢复后从失败处继续", func(t *testing.T) {
+		if len(local.events) != len(recorder.events) {
+			t.Errorf("本地目标应该收到 %d 个事件，实际 %d 个", len(recorder.events), len(local.events))
+		}
+
+		for _, target := range dispatcher.targets {
+			if target.sink == failing {
+				target.retryAt = target.retryAt.AddDate(-1, 0, 0)
+			}
+		}
+		failing.failing = false
+		dispatcher.processAll(ctx)
+		if len(failing.events) != len(recorder.events) {
+			t.Errorf("恢复后应该补投 %d 个事件，实际 %d 个", len(recorder.events), len(failing.events))
+		}
+		t.Logf("✅ 恢复后补投 %d 个事件", len(failing.events))
+	})
+}
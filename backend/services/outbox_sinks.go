@@ -0,0 +1,149 @@
+package services
+
+import (
+	"backend/events"
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// OutboxSink 发件箱事件的投递目标
+// Publish 返回错误时投递协程稍后从同一条事件重试，所以实现需要容忍重复投递
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// BusSink 转发到进程内事件中心（SSE、WebSocket 协作通道的数据来源）
+type BusSink struct {
+	broker *events.Broker
+}
+
+func NewBusSink(broker *events.Broker) *BusSink {
+	return &BusSink{broker: broker}
+}
+
+func (s *BusSink) Name() string {
+	return "bus"
+}
+
+// Publish 完成、重新打开都属于数据更新，删除事件不携带 todo 数据
+func (s *BusSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	todo, err := event.Todo()
+	if err != nil {
+		return err
+	}
+
+	e := events.Event{
+		TodoID:   event.AggregateID,
+		Version:  event.Version,
+		Category: todo.Category,
+		Data:     todo,
+		Time:     event.CreatedAt,
+	}
+	if todo.OwnerID != nil {
+		e.OwnerID = *todo.OwnerID
+	}
+	switch event.EventType {
+	case models.EventTodoCreated:
+		e.Type = events.TodoCreated
+	case models.EventTodoDeleted:
+		e.Type = events.TodoDeleted
+		e.Data = nil
+	default:
+		e.Type = events.TodoUpdated
+	}
+	s.broker.Publish(e)
+	return nil
+}
+
+// WebhookSink 为订阅了对应事件的 Webhook 写入投递队列
+type WebhookSink struct {
+	webhooks *WebhookService
+}
+
+func NewWebhookSink(webhooks *WebhookService) *WebhookSink {
+	return &WebhookSink{webhooks: webhooks}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// webhookEvents 领域事件到 Webhook 事件的映射，未列出的事件不投递
+var webhookEvents = map[string]string{
+	models.EventTodoCreated:   models.WebhookEventCreated,
+	models.EventTodoCompleted: models.WebhookEventCompleted,
+	models.EventTodoDeleted:   models.WebhookEventDeleted,
+}
+
+// Publish 事件 ID 由序号生成，重复入队时接收方可以按 ID 去重
+func (s *WebhookSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	webhookEvent, ok := webhookEvents[event.EventType]
+	if !ok {
+		return nil
+	}
+	todo, err := event.Todo()
+	if err != nil {
+		return err
+	}
+	return s.webhooks.enqueue(fmt.Sprintf("evt_%d", event.Seq), webhookEvent, event.CreatedAt, todo)
+}
+
+// LogSink 将事件写入服务日志
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	slog.InfoContext(ctx, "outbox event", "component", "outbox", "seq", event.Seq, "event", event.EventType, "todo_id", event.AggregateID, "version", event.Version)
+	return nil
+}
+
+// FileSink 将事件以 JSON Lines 格式追加到文件
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink 打开（不存在时创建）事件文件
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close 关闭文件
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
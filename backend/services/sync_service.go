@@ -0,0 +1,265 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"backend/ratelimit"
+	"context"
+	"errors"
+	"strconv"
+)
+
+const (
+	// DefaultSyncLimit 每次拉取的默认变更条数
+	DefaultSyncLimit = 500
+	// MaxSyncLimit 每次拉取的最大变更条数
+	MaxSyncLimit = 1000
+	// MaxSyncBatch 每次上传的最大修改条数
+	MaxSyncBatch = 200
+)
+
+// 上传结果状态
+const (
+	SyncAccepted = "accepted"  // 已应用
+	SyncConflict = "conflict"  // 版本冲突，附带服务端最新数据
+	SyncNotFound = "not_found" // 记录不存在或已被删除
+	SyncRejected = "rejected"  // 参数校验失败等其他错误
+)
+
+// SyncService 增量同步服务，供离线优先的客户端使用
+// 所有修改都通过 TodoService 完成，与在线修改共用校验、乐观锁和事件推送
+type SyncService struct {
+	todos *TodoService
+}
+
+func NewSyncService(todos *TodoService) *SyncService {
+	return &SyncService{todos: todos}
+}
+
+// SyncChange 一条变更，Deleted 为 true 时是删除墓碑，不携带 Todo
+type SyncChange struct {
+	ID        uint         `json:"id"`
+	ChangeSeq int64        `json:"change_seq"`
+	Version   int          `json:"version"`
+	Deleted   bool         `json:"deleted"`
+	Todo      *models.Todo `json:"todo,omitempty"`
+}
+
+// SyncPullResult 拉取结果
+type SyncPullResult struct {
+	Changes   []SyncChange `json:"changes"`
+	SyncToken string       `json:"sync_token"` // 下次拉取时作为 since 传回
+	HasMore   bool         `json:"has_more"`   // 还有更多变更，应立即用新 token 继续拉取
+	Full      bool         `json:"full"`       // 是否为全量快照（since 为空时），客户端应替换本地数据
+}
+
+// SyncResult 单条上传修改的处理结果
+type SyncResult struct {
+	ClientID       string       `json:"client_id,omitempty"`
+	ID             uint         `json:"id,omitempty"`
+	Status         string       `json:"status"`
+	Todo           *models.Todo `json:"todo,omitempty"`            // accepted 时为修改后的数据
+	CurrentVersion int          `json:"current_version,omitempty"` // conflict 时为服务端版本
+	LatestData     *models.Todo `json:"latest_data,omitempty"`     // conflict 时为服务端最新数据
+	Error          string       `json:"error,omitempty"`
+	ErrorCode      string       `json:"error_code,omitempty"` // 错误码，如 TODO_NOT_FOUND
+	Err            error        `json:"-"`                    // 原始错误，由 Controller 按请求语言生成 Error
+}
+
+// SyncPushResult 上传结果
+type SyncPushResult struct {
+	Results   []SyncResult `json:"results"`
+	SyncToken string       `json:"sync_token"` // 上传后的同步位置，客户端仍需用旧 token 拉取以获取期间其他设备的修改
+}
+
+// parseSyncToken 解析同步 token，空字符串表示从头开始
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, customerrors.ErrInvalidSyncToken
+	}
+	return seq, nil
+}
+
+// formatSyncToken 生成同步 token，对客户端而言是不透明字符串
+func formatSyncToken(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+// Pull 拉取 since 之后的所有变更
+// since 为空时返回全部未删除记录的快照；否则按变更序号返回新增、修改和删除（墓碑）；
+// since 之后的墓碑已被清理时同样返回快照（Full 为 true）
+func (s *SyncService) Pull(ctx context.Context, since string, limit int) (*SyncPullResult, error) {
+	seq, err := parseSyncToken(since)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSyncLimit
+	}
+	if limit > MaxSyncLimit {
+		limit = MaxSyncLimit
+	}
+
+	if seq == 0 {
+		return s.snapshot(ctx)
+	}
+
+	// 墓碑已被清理（purge-trash），增量变更中会缺少这些删除，改为返回全量快照
+	purged, err := models.PurgedChangeSeq(ctx)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+	if seq < purged {
+		return s.snapshot(ctx)
+	}
+
+	// 多取一条用于判断是否还有更多
+	todos, err := models.GetChangesSince(ctx, seq, limit+1)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+
+	result := &SyncPullResult{Changes: make([]SyncChange, 0, len(todos)), SyncToken: since}
+	if len(todos) > limit {
+		todos = todos[:limit]
+		result.HasMore = true
+	}
+	for i := range todos {
+		result.Changes = append(result.Changes, toSyncChange(&todos[i]))
+	}
+	if len(todos) > 0 {
+		result.SyncToken = formatSyncToken(todos[len(todos)-1].ChangeSeq)
+	}
+
+	return result, nil
+}
+
+// snapshot 全量快照
+// 先读取当前序号再读取数据：快照中可能包含序号更大的变更，下次增量拉取时会重复收到，
+// 客户端按版本号覆盖即可，不会遗漏
+func (s *SyncService) snapshot(ctx context.Context) (*SyncPullResult, error) {
+	seq, err := models.CurrentChangeSeq(ctx)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+
+	todos, err := models.GetAllLive(ctx)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+
+	result := &SyncPullResult{
+		Changes:   make([]SyncChange, 0, len(todos)),
+		SyncToken: formatSyncToken(seq),
+		Full:      true,
+	}
+	for i := range todos {
+		result.Changes = append(result.Changes, toSyncChange(&todos[i]))
+	}
+
+	return result, nil
+}
+
+// toSyncChange 将记录转换为同步变更
+func toSyncChange(todo *models.Todo) SyncChange {
+	change := SyncChange{
+		ID:        todo.ID,
+		ChangeSeq: todo.ChangeSeq,
+		Version:   todo.Version,
+		Deleted:   todo.DeletedAt.Valid,
+	}
+	if !change.Deleted {
+		change.Todo = todo
+	}
+	return change
+}
+
+// Push 按顺序应用客户端上传的离线修改，每条修改独立处理，互不影响
+func (s *SyncService) Push(ctx context.Context, input *models.SyncPushInput) (*SyncPushResult, error) {
+	if len(input.Changes) > MaxSyncBatch {
+		return nil, customerrors.ErrSyncBatchTooLarge
+	}
+
+	result := &SyncPushResult{Results: make([]SyncResult, 0, len(input.Changes))}
+	for i := range input.Changes {
+		result.Results = append(result.Results, s.apply(ctx, &input.Changes[i]))
+	}
+
+	seq, err := models.CurrentChangeSeq(ctx)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+	result.SyncToken = formatSyncToken(seq)
+
+	return result, nil
+}
+
+// apply 应用单条修改
+func (s *SyncService) apply(ctx context.Context, change *models.SyncChangeInput) SyncResult {
+	result := SyncResult{ClientID: change.ClientID, ID: change.ID}
+
+	todo, err := s.applyOp(ctx, change)
+
+	var conflictErr *VersionConflictError
+	switch {
+	case err == nil:
+		result.Status = SyncAccepted
+		if todo != nil {
+			result.ID = todo.ID
+			result.Todo = todo
+		}
+		return result
+	case errors.As(err, &conflictErr):
+		result.Status = SyncConflict
+		result.CurrentVersion = conflictErr.CurrentVersion
+		result.LatestData = conflictErr.LatestData
+		result.Error = conflictErr.Message
+	case errors.Is(err, customerrors.ErrTodoNotFound):
+		result.Status = SyncNotFound
+		result.Error = err.Error()
+	default:
+		result.Status = SyncRejected
+		result.Error = err.Error()
+	}
+
+	result.ErrorCode = customerrors.CodeOf(err)
+	result.Err = err
+	return result
+}
+
+// applyOp 按操作类型调用 TodoService；每条修改按一个写请求计数，上传请求本身按读请求计数
+func (s *SyncService) applyOp(ctx context.Context, change *models.SyncChangeInput) (*models.Todo, error) {
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyWrite); err != nil {
+		return nil, err
+	}
+	switch change.Op {
+	case "create":
+		return s.todos.CreateTodo(ctx, &models.CreateTodoInput{
+			Title:       change.Title,
+			Description: change.Description,
+			Category:    change.Category,
+			Priority:    change.Priority,
+		})
+	case "update":
+		return s.todos.UpdateTodo(ctx, change.ID, &models.UpdateTodoInput{
+			Title:       change.Title,
+			Description: change.Description,
+			Category:    change.Category,
+			Priority:    change.Priority,
+			Version:     change.BaseVersion,
+		})
+	case "status":
+		return s.todos.UpdateTodoStatus(ctx, change.ID, &models.UpdateStatusInput{
+			Completed: change.Completed,
+			Version:   change.BaseVersion,
+		})
+	case "delete":
+		return nil, s.todos.DeleteTodoWithVersion(ctx, change.ID, change.BaseVersion)
+	}
+	return nil, nil
+}
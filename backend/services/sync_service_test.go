@@ -0,0 +1,161 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"backend/ratelimit"
+	"context"
+	"testing"
+)
+
+// TestSyncPull 测试增量拉取
+func TestSyncPull(t *testing.T) {
+	syncService := NewSyncService(service)
+
+	t.Run("全量快照后增量拉取新增、修改和删除", func(t *testing.T) {
+		snapshot, err := syncService.Pull(context.Background(), "", 0)
+		if err != nil {
+			t.Fatalf("拉取快照失败: %v", err)
+		}
+		if !snapshot.Full {
+			t.Error("since 为空时应该返回全量快照")
+		}
+		token := snapshot.SyncToken
+		t.Logf("快照 %d 条，sync_token=%s", len(snapshot.Changes), token)
+
+		// 新建、修改、删除各一次
+		created, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "同步测试", Category: "work"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		updated, err := service.UpdateTodoStatus(context.Background(), created.ID, &models.UpdateStatusInput{Completed: true, Version: created.Version})
+		if err != nil {
+			t.Fatalf("更新状态失败: %v", err)
+		}
+		if err := service.DeleteTodo(context.Background(), created.ID); err != nil {
+			t.Fatalf("删除失败: %v", err)
+		}
+
+		result, err := syncService.Pull(context.Background(), token, 0)
+		if err != nil {
+			t.Fatalf("增量拉取失败: %v", err)
+		}
+
+		// 同一条记录只返回最后的状态：墓碑
+		var change *SyncChange
+		for i := range result.Changes {
+			if result.Changes[i].ID == created.ID {
+				change = &result.Changes[i]
+			}
+		}
+		if change == nil {
+			t.Fatal("增量结果中应该包含新建后又删除的记录")
+		}
+		if !change.Deleted || change.Todo != nil {
+			t.Errorf("删除的记录应该以墓碑形式返回: %+v", change)
+		}
+		if change.Version != updated.Version+1 {
+			t.Errorf("删除后版本号应该为 %d，实际为 %d", updated.Version+1, change.Version)
+		}
+		if result.SyncToken == token {
+			t.Error("有新变更时 sync_token 应该前进")
+		}
+
+		// 用新 token 再拉取，不应有任何变更
+		again, err := syncService.Pull(context.Background(), result.SyncToken, 0)
+		if err != nil {
+			t.Fatalf("再次拉取失败: %v", err)
+		}
+		if len(again.Changes) != 0 {
+			t.Errorf("没有新变更时应该返回空列表，实际 %d 条", len(again.Changes))
+		}
+
+		t.Logf("✅ 增量拉取 %d 条，墓碑版本号=%d", len(result.Changes), change.Version)
+	})
+
+	t.Run("验证：无效 token 应该失败", func(t *testing.T) {
+		_, err := syncService.Pull(context.Background(), "abc", 0)
+		if err == nil {
+			t.Error("无效 token 应该返回错误")
+			return
+		}
+		t.Logf("✅ 正确拒绝无效 token: %v", err)
+	})
+}
+
+// TestSyncPush 测试上传离线修改
+func TestSyncPush(t *testing.T) {
+	syncService := NewSyncService(service)
+
+	created, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "离线修改测试", Category: "life"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	defer service.DeleteTodo(context.Background(), created.ID)
+
+	result, err := syncService.Push(context.Background(), &models.SyncPushInput{Changes: []models.SyncChangeInput{
+		{ClientID: "c1", Op: "create", Title: "离线新建", Category: "study"},
+		{ClientID: "c2", Op: "status", ID: created.ID, BaseVersion: created.Version, Completed: true},
+		// 基于旧版本的修改应该冲突
+		{ClientID: "c3", Op: "update", ID: created.ID, BaseVersion: created.Version, Title: "过期修改", Category: "life"},
+		{ClientID: "c4", Op: "delete", ID: 999999},
+		{ClientID: "c5", Op: "create", Title: ""},
+	}})
+	if err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+
+	expected := []string{SyncAccepted, SyncAccepted, SyncConflict, SyncNotFound, SyncRejected}
+	for i, r := range result.Results {
+		if r.Status != expected[i] {
+			t.Errorf("第 %d 条（%s）状态应该为 %s，实际为 %s: %s", i+1, r.ClientID, expected[i], r.Status, r.Error)
+		}
+	}
+
+	if conflict := result.Results[2]; conflict.LatestData == nil || conflict.CurrentVersion != created.Version+1 {
+		t.Errorf("冲突结果应该携带最新数据: %+v", conflict)
+	}
+	if r := result.Results[0]; r.ID != 0 {
+		service.DeleteTodo(context.Background(), r.ID)
+	}
+
+	t.Logf("✅ 上传结果: %s %s %s %s %s",
+		result.Results[0].Status, result.Results[1].Status, result.Results[2].Status,
+		result.Results[3].Status, result.Results[4].Status)
+}
+
+// TestSyncPushCharge 测试批量上传中的每条修改和每次创建都单独计数
+func TestSyncPushCharge(t *testing.T) {
+	syncService := NewSyncService(service)
+
+	// 写请求不限，创建只允许一次
+	charged := map[string]int{}
+	ctx := ratelimit.WithCharger(context.Background(), func(ctx context.Context, policy string) error {
+		charged[policy]++
+		if policy == ratelimit.PolicyCreate && charged[policy] > 1 {
+			return customerrors.ErrRateLimited.WithDetails("retry_after", 1)
+		}
+		return nil
+	})
+
+	result, err := syncService.Push(ctx, &models.SyncPushInput{Changes: []models.SyncChangeInput{
+		{ClientID: "c1", Op: "create", Title: "批量创建一", Category: "work"},
+		{ClientID: "c2", Op: "create", Title: "批量创建二", Category: "work"},
+		{ClientID: "c3", Op: "delete", ID: 999999},
+	}})
+	if err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	if r := result.Results[0]; r.Status != SyncAccepted {
+		t.Fatalf("第一次创建应该成功: %+v", r)
+	} else {
+		service.DeleteTodo(context.Background(), r.ID)
+	}
+	if r := result.Results[1]; r.Status != SyncRejected || r.ErrorCode != customerrors.CodeRateLimited {
+		t.Errorf("第二次创建应该被限流: %+v", r)
+	}
+	if charged[ratelimit.PolicyWrite] != 3 || charged[ratelimit.PolicyCreate] != 2 {
+		t.Errorf("应该计 3 次写、2 次创建，实际: %v", charged)
+	}
+	t.Logf("✅ 计数: %v", charged)
+}
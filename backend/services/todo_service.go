@@ -2,28 +2,51 @@ package services
 
 import (
 	customerrors "backend/errors"
+	"backend/metrics"
 	"backend/models"
+	"backend/ratelimit"
+	"backend/tracing"
+	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultPageSize 分页查询的默认每页条数
+	DefaultPageSize = 20
+	// MaxPageSize 分页查询的最大每页条数
+	MaxPageSize = 100
 )
 
 // TodoService 待办事项业务逻辑服务，一切数据库查询放到models/todo.go中
-type TodoService struct{}
+// 领域事件由 Model 层在同一个事务中写入发件箱，提交后通知发件箱投递协程
+// 每个公开方法创建一个名为 TodoService.<方法名> 的 span，数据库查询的 span 挂在它下面
+type TodoService struct {
+	outbox *OutboxDispatcher
+}
+
+// MaxTodos 每个登录用户创建的未删除待办事项数量上限，0 表示不限制，由 main 按配置设置
+// 按创建者计数，一个用户写满不影响其他用户；匿名创建的记录合计使用一份上限，
+// 匿名请求写满后只影响匿名创建，登录用户仍可创建。不要求登录时这是存储量的上界。
+// 创建前先计数，并发创建时可能略微超出。导入和演示数据不受限制
+var MaxTodos int
 
 func NewTodoService() *TodoService {
-	return &TodoService{}
+	return &TodoService{outbox: DefaultOutboxDispatcher}
 }
 
 // validateCreateInput 验证创建输入
 func (s *TodoService) validateCreateInput(input *models.CreateTodoInput) error {
+	var errs []*customerrors.AppError
+
 	// 标题验证
 	if strings.TrimSpace(input.Title) == "" {
-		return customerrors.ErrTitleRequired
-	}
-
-	if len(input.Title) > 255 {
-		return customerrors.ErrTitleTooLong
+		errs = append(errs, customerrors.ErrTitleRequired)
+	} else if len(input.Title) > 255 {
+		errs = append(errs, customerrors.ErrTitleTooLong)
 	}
 
 	// 分类验证。空字符串是允许的，会在后续设置为默认值 "life"
@@ -31,16 +54,17 @@ func (s *TodoService) validateCreateInput(input *models.CreateTodoInput) error {
 	if input.Category != "" {
 		validCategories := []string{"work", "study", "life"}
 		if !contains(validCategories, input.Category) {
-			return customerrors.ErrInvalidCategory(input.Category)
+			errs = append(errs, customerrors.ErrInvalidCategory(input.Category))
 		}
 	}
 
 	// 优先级验证
 	if input.Priority < 0 || input.Priority > 5 {
-		return customerrors.ErrInvalidPriority
+		errs = append(errs, customerrors.ErrInvalidPriority)
 	}
 
-	return nil
+	// 一次返回所有不合法的字段，前端可以同时标出
+	return customerrors.Validation(errs...)
 }
 
 // contains 辅助函数：检查item是否在切片中
@@ -54,10 +78,20 @@ func contains(slice []string, item string) bool {
 }
 
 // CreateTodo 创建待办事项
-func (s *TodoService) CreateTodo(input *models.CreateTodoInput) (*models.Todo, error) {
+func (s *TodoService) CreateTodo(ctx context.Context, input *models.CreateTodoInput) (_ *models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.CreateTodo")
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.validateCreateInput(input); err != nil {
 		return nil, err
 	}
+	// 每次创建单独计数，一个请求中的多次创建（批量同步、GraphQL、WebSocket）同样受 create 策略约束
+	if err := ratelimit.Charge(ctx, ratelimit.PolicyCreate); err != nil {
+		return nil, err
+	}
+	if err := checkTodoLimit(ctx); err != nil {
+		return nil, err
+	}
 
 	todo := &models.Todo{
 		Title:       strings.TrimSpace(input.Title),
@@ -65,6 +99,10 @@ func (s *TodoService) CreateTodo(input *models.CreateTodoInput) (*models.Todo, e
 		Category:    input.Category,
 		Priority:    input.Priority,
 	}
+	// 登录用户创建的记录保存创建者
+	if userID, ok := UserIDFromContext(ctx); ok {
+		todo.OwnerID = &userID
+	}
 
 	// Category 为空时，默认设置为 "life"
 	if todo.Category == "" {
@@ -79,45 +117,154 @@ func (s *TodoService) CreateTodo(input *models.CreateTodoInput) (*models.Todo, e
 	}
 
 	// 数据库插入
-	if err := todo.Create(); err != nil {
+	if err := todo.Create(ctx); err != nil {
+		return nil, customerrors.WrapCreateError(err)
+	}
+
+	s.outbox.Notify()
+	return todo, nil
+}
+
+// checkTodoLimit 当前登录用户（匿名时为所有匿名用户合计）创建的未删除待办事项达到 MaxTodos 时返回 TODO_LIMIT_REACHED
+func checkTodoLimit(ctx context.Context) error {
+	if MaxTodos <= 0 {
+		return nil
+	}
+	var count int64
+	var err error
+	if ownerID, ok := UserIDFromContext(ctx); ok {
+		count, err = models.CountLiveByOwner(ctx, ownerID)
+	} else {
+		count, err = models.CountLiveAnonymous(ctx)
+	}
+	if err != nil {
+		return customerrors.WrapQueryError(err)
+	}
+	if count >= int64(MaxTodos) {
+		return customerrors.ErrTodoLimitReached.WithDetails("limit", MaxTodos)
+	}
+	return nil
+}
+
+// ImportTodo 导入一条待办事项（如从 export 导出的备份中恢复）
+// 与创建相同地校验并分配新的 ID、变更序号和 TodoCreated 事件，保留完成状态和创建时间
+func (s *TodoService) ImportTodo(ctx context.Context, source *models.Todo) (_ *models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.ImportTodo")
+	defer func() { tracing.End(span, err) }()
+
+	input := &models.CreateTodoInput{
+		Title:       source.Title,
+		Description: source.Description,
+		Category:    source.Category,
+		Priority:    source.Priority,
+	}
+	if err := s.validateCreateInput(input); err != nil {
+		return nil, err
+	}
+
+	todo := &models.Todo{
+		Title:       strings.TrimSpace(input.Title),
+		Description: strings.TrimSpace(input.Description),
+		Category:    input.Category,
+		Priority:    input.Priority,
+		Completed:   source.Completed,
+		CreatedAt:   source.CreatedAt,
+	}
+	if todo.Category == "" {
+		todo.Category = "life"
+	}
+
+	if err := todo.Create(ctx); err != nil {
 		return nil, customerrors.WrapCreateError(err)
 	}
 
+	s.outbox.Notify()
 	return todo, nil
 }
 
 // GetAllTodos 获取所有待办事项
-func (s *TodoService) GetAllTodos(category string, sortBy string) ([]models.Todo, error) {
+func (s *TodoService) GetAllTodos(ctx context.Context, category string, sortBy string) (_ []models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetAllTodos",
+		attribute.String("todo.category", category),
+		attribute.String("todo.sort", sortBy),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateListParams(category, sortBy); err != nil {
+		return nil, err
+	}
+
+	todos, err := models.GetAll(ctx, category, sortBy)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+
+	return todos, nil
+}
+
+// ListTodos 分页获取待办事项，page 从 1 开始，返回当前页和符合条件的总数
+func (s *TodoService) ListTodos(ctx context.Context, category string, sortBy string, page, pageSize int) (_ []models.Todo, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.ListTodos",
+		attribute.String("todo.category", category),
+		attribute.String("todo.sort", sortBy),
+		attribute.Int("page", page),
+		attribute.Int("page_size", pageSize),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateListParams(category, sortBy); err != nil {
+		return nil, 0, err
+	}
+
+	// 验证分页参数
+	var errs []*customerrors.AppError
+	if page < 1 {
+		errs = append(errs, customerrors.ErrInvalidPage)
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		errs = append(errs, customerrors.ErrInvalidPageSize)
+	}
+	if err := customerrors.Validation(errs...); err != nil {
+		return nil, 0, err
+	}
+
+	todos, total, err := models.GetPage(ctx, category, sortBy, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, customerrors.WrapQueryError(err)
+	}
+
+	return todos, total, nil
+}
+
+// validateListParams 验证列表的筛选和排序参数
+func validateListParams(category string, sortBy string) error {
 	// 验证分类参数，避免调接口时故意传不正确的category
 	if category != "" && category != "all" {
 		validCategories := []string{"work", "study", "life"}
 		if !contains(validCategories, category) {
-			return nil, customerrors.ErrInvalidCategory(category)
+			return customerrors.ErrInvalidCategory(category)
 		}
 	}
 
 	// 验证排序参数
 	if sortBy != "" && sortBy != "priority" && sortBy != "created_at" {
-		return nil, customerrors.ErrInvalidSort(sortBy)
-	}
-
-	todos, err := models.GetAll(category, sortBy)
-	if err != nil {
-		return nil, customerrors.WrapQueryError(err)
+		return customerrors.ErrInvalidSort(sortBy)
 	}
-
-	return todos, nil
+	return nil
 }
 
 // GetTodoByID 根据ID获取待办事项
-func (s *TodoService) GetTodoByID(id uint) (*models.Todo, error) {
+func (s *TodoService) GetTodoByID(ctx context.Context, id uint) (_ *models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTodoByID", todoIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == 0 {
 		return nil, customerrors.ErrInvalidID
 	}
 
-	todo, err := models.GetByID(id)
+	todo, err := models.GetByID(ctx, id)
 	if err != nil {
-		return nil, customerrors.ErrTodoNotFoundWithID(id)
+		return nil, lookupError(id, err)
 	}
 
 	return todo, nil
@@ -125,32 +272,32 @@ func (s *TodoService) GetTodoByID(id uint) (*models.Todo, error) {
 
 // validateUpdateInput 验证编辑输入
 func (s *TodoService) validateUpdateInput(input *models.UpdateTodoInput) error {
+	var errs []*customerrors.AppError
+
 	// 标题验证
 	if strings.TrimSpace(input.Title) == "" {
-		return customerrors.ErrTitleRequired
-	}
-
-	if len(input.Title) > 255 {
-		return customerrors.ErrTitleTooLong
+		errs = append(errs, customerrors.ErrTitleRequired)
+	} else if len(input.Title) > 255 {
+		errs = append(errs, customerrors.ErrTitleTooLong)
 	}
 
 	// 分类验证（编辑时分类是必填的）
 	validCategories := []string{"work", "study", "life"}
 	if !contains(validCategories, input.Category) {
-		return customerrors.ErrInvalidCategory(input.Category)
+		errs = append(errs, customerrors.ErrInvalidCategory(input.Category))
 	}
 
 	// 优先级验证
 	if input.Priority < 0 || input.Priority > 5 {
-		return customerrors.ErrInvalidPriority
+		errs = append(errs, customerrors.ErrInvalidPriority)
 	}
 
 	// 版本号验证
 	if input.Version < 0 {
-		return customerrors.ErrInvalidVersion
+		errs = append(errs, customerrors.ErrInvalidVersion)
 	}
 
-	return nil
+	return customerrors.Validation(errs...)
 }
 
 // VersionConflictError 版本冲突错误
@@ -165,12 +312,28 @@ func (e *VersionConflictError) Error() string {
 	return e.Message
 }
 
+// LocalizationParams 本地化提示中使用的参数
+func (e *VersionConflictError) LocalizationParams() map[string]interface{} {
+	return map[string]interface{}{
+		"current_version":  e.CurrentVersion,
+		"provided_version": e.ProvidedVersion,
+	}
+}
+
+// Unwrap 使 errors.Is(err, customerrors.ErrVersionConflict) 成立
+func (e *VersionConflictError) Unwrap() error {
+	return customerrors.ErrVersionConflict
+}
+
 // UpdateTodo 更新待办事项
 // 可以更新标题、描述、分类、优先级，使用乐观锁保护
-func (s *TodoService) UpdateTodo(id uint, input *models.UpdateTodoInput) (*models.Todo, error) {
+func (s *TodoService) UpdateTodo(ctx context.Context, id uint, input *models.UpdateTodoInput) (_ *models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.UpdateTodo", todoIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+
 	// 验证 ID
 	if id == 0 {
-		return nil, errors.New("invalid id: id must be greater than 0")
+		return nil, customerrors.ErrInvalidID
 	}
 
 	// 验证输入
@@ -179,13 +342,14 @@ func (s *TodoService) UpdateTodo(id uint, input *models.UpdateTodoInput) (*model
 	}
 
 	// 先查询当前记录是否存在
-	existingTodo, err := models.GetByID(id)
+	existingTodo, err := models.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("todo not found with id %d", id)
+		return nil, lookupError(id, err)
 	}
 
 	// 乐观锁冲突检测
 	if existingTodo.Version != input.Version {
+		recordVersionConflict(ctx, "update", id, input.Version, existingTodo.Version)
 		return nil, &VersionConflictError{
 			Message:         "version conflict: data has been modified by another user",
 			CurrentVersion:  existingTodo.Version,
@@ -200,11 +364,15 @@ func (s *TodoService) UpdateTodo(id uint, input *models.UpdateTodoInput) (*model
 
 	// 调用 Model 层更新
 	todo := &models.Todo{}
-	if err := todo.Update(id, title, description, input.Category, input.Priority, input.Version); err != nil {
+	if err := todo.Update(ctx, id, title, description, input.Category, input.Priority, input.Version); err != nil {
 		// 处理乐观锁冲突（双重检查）
-		if strings.Contains(err.Error(), "version conflict") {
+		if errors.Is(err, customerrors.ErrVersionConflict) {
 			// 获取最新数据返回给客户端
-			latestTodo, _ := models.GetByID(id)
+			latestTodo, getErr := models.GetByID(ctx, id)
+			if getErr != nil {
+				return nil, lookupError(id, getErr)
+			}
+			recordVersionConflict(ctx, "update", id, input.Version, latestTodo.Version)
 			return nil, &VersionConflictError{
 				Message:         err.Error(),
 				CurrentVersion:  latestTodo.Version,
@@ -216,16 +384,20 @@ func (s *TodoService) UpdateTodo(id uint, input *models.UpdateTodoInput) (*model
 	}
 
 	// 返回更新后的数据
-	updatedTodo, err := models.GetByID(id)
+	updatedTodo, err := models.GetByID(ctx, id)
 	if err != nil {
 		return nil, customerrors.WrapGetError(err)
 	}
 
+	s.outbox.Notify()
 	return updatedTodo, nil
 }
 
 // UpdateTodoStatus 更新待办事项状态
-func (s *TodoService) UpdateTodoStatus(id uint, input *models.UpdateStatusInput) (*models.Todo, error) {
+func (s *TodoService) UpdateTodoStatus(ctx context.Context, id uint, input *models.UpdateStatusInput) (_ *models.Todo, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.UpdateTodoStatus", todoIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == 0 {
 		return nil, customerrors.ErrInvalidID
 	}
@@ -235,13 +407,14 @@ func (s *TodoService) UpdateTodoStatus(id uint, input *models.UpdateStatusInput)
 	}
 
 	// 先查询当前记录是否存在
-	existingTodo, err := models.GetByID(id)
+	existingTodo, err := models.GetByID(ctx, id)
 	if err != nil {
-		return nil, customerrors.ErrTodoNotFoundWithID(id)
+		return nil, lookupError(id, err)
 	}
 
 	// 乐观锁冲突检测
 	if existingTodo.Version != input.Version {
+		recordVersionConflict(ctx, "update_status", id, input.Version, existingTodo.Version)
 		return nil, &VersionConflictError{
 			Message:         "version conflict: data has been modified by another user",
 			CurrentVersion:  existingTodo.Version,
@@ -252,11 +425,15 @@ func (s *TodoService) UpdateTodoStatus(id uint, input *models.UpdateStatusInput)
 
 	// 5. 调用 Model 层更新状态
 	todo := &models.Todo{}
-	if err := todo.UpdateStatus(id, input.Completed, input.Version); err != nil {
+	if err := todo.UpdateStatus(ctx, id, input.Completed, input.Version); err != nil {
 		// 处理乐观锁冲突（双重检查）
-		if strings.Contains(err.Error(), "version conflict") {
+		if errors.Is(err, customerrors.ErrVersionConflict) {
 			// 获取最新数据返回给客户端
-			latestTodo, _ := models.GetByID(id)
+			latestTodo, getErr := models.GetByID(ctx, id)
+			if getErr != nil {
+				return nil, lookupError(id, getErr)
+			}
+			recordVersionConflict(ctx, "update_status", id, input.Version, latestTodo.Version)
 			return nil, &VersionConflictError{
 				Message:         err.Error(),
 				CurrentVersion:  latestTodo.Version,
@@ -264,35 +441,128 @@ func (s *TodoService) UpdateTodoStatus(id uint, input *models.UpdateStatusInput)
 				LatestData:      latestTodo,
 			}
 		}
-		return nil, fmt.Errorf("failed to update todo status: %w", err)
+		return nil, customerrors.WrapUpdateError(err)
 	}
 
 	// 6. 返回更新后的数据
-	updatedTodo, err := models.GetByID(id)
+	updatedTodo, err := models.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get updated todo: %w", err)
+		return nil, customerrors.WrapGetError(err)
 	}
 
+	s.outbox.Notify()
 	return updatedTodo, nil
 }
 
 // DeleteTodo 删除待办事项
-func (s *TodoService) DeleteTodo(id uint) error {
+func (s *TodoService) DeleteTodo(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.DeleteTodo", todoIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+
 	// 验证 ID
 	if id == 0 {
 		return customerrors.ErrInvalidID
 	}
 
 	// 先检查是否存在
-	_, err := models.GetByID(id)
-	if err != nil {
-		return customerrors.ErrTodoNotFoundWithID(id)
+	if _, err := models.GetByID(ctx, id); err != nil {
+		return lookupError(id, err)
 	}
 
 	// 调用 Model 层删除
-	if err := models.Delete(id); err != nil {
+	if err := models.Delete(ctx, id); err != nil {
+		return customerrors.WrapDeleteError(err)
+	}
+
+	s.outbox.Notify()
+	return nil
+}
+
+// DeleteTodoWithVersion 删除待办事项（带乐观锁），用于离线同步时按客户端的基准版本删除
+func (s *TodoService) DeleteTodoWithVersion(ctx context.Context, id uint, version int) (err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.DeleteTodoWithVersion", todoIDAttr(id))
+	defer func() { tracing.End(span, err) }()
+
+	if id == 0 {
+		return customerrors.ErrInvalidID
+	}
+
+	if version < 0 {
+		return customerrors.ErrInvalidVersion
+	}
+
+	existingTodo, err := models.GetByID(ctx, id)
+	if err != nil {
+		return lookupError(id, err)
+	}
+
+	// 乐观锁冲突检测
+	if existingTodo.Version != version {
+		recordVersionConflict(ctx, "delete", id, version, existingTodo.Version)
+		return &VersionConflictError{
+			Message:         "version conflict: data has been modified by another user",
+			CurrentVersion:  existingTodo.Version,
+			ProvidedVersion: version,
+			LatestData:      existingTodo,
+		}
+	}
+
+	if err := models.DeleteWithVersion(ctx, id, version); err != nil {
+		// 处理乐观锁冲突（双重检查）
+		if errors.Is(err, customerrors.ErrVersionConflict) {
+			latestTodo, getErr := models.GetByID(ctx, id)
+			if getErr != nil {
+				// 其间已被其他设备删除
+				return lookupError(id, getErr)
+			}
+			recordVersionConflict(ctx, "delete", id, version, latestTodo.Version)
+			return &VersionConflictError{
+				Message:         err.Error(),
+				CurrentVersion:  latestTodo.Version,
+				ProvidedVersion: version,
+				LatestData:      latestTodo,
+			}
+		}
 		return customerrors.WrapDeleteError(err)
 	}
 
+	s.outbox.Notify()
 	return nil
 }
+
+// GetTodoHistories 批量获取多个待办事项的变更历史，一次查询，按待办事项 ID 分组
+func (s *TodoService) GetTodoHistories(ctx context.Context, ids []uint) (_ map[uint][]models.TodoHistory, err error) {
+	ctx, span := tracing.Start(ctx, "TodoService.GetTodoHistories", attribute.Int("todo.count", len(ids)))
+	defer func() { tracing.End(span, err) }()
+
+	histories, err := models.GetTodoHistories(ctx, ids)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+	return histories, nil
+}
+
+// recordVersionConflict 记录版本冲突：计入 todo_version_conflicts_total 指标，并输出带请求 ID 的日志
+func recordVersionConflict(ctx context.Context, operation string, id uint, provided, current int) {
+	metrics.VersionConflicts.WithLabelValues(operation).Inc()
+	slog.InfoContext(ctx, "version conflict",
+		"operation", operation,
+		"todo_id", id,
+		"provided_version", provided,
+		"current_version", current,
+	)
+}
+
+// todoIDAttr span 上的待办事项 ID
+func todoIDAttr(id uint) attribute.KeyValue {
+	return attribute.Int64("todo.id", int64(id))
+}
+
+// lookupError 查询已有记录失败：不存在时返回带 ID 的 TODO_NOT_FOUND，
+// 其他错误（数据库错误、请求取消或超时）保留原因，不能都当作不存在
+func lookupError(id uint, err error) error {
+	if errors.Is(err, customerrors.ErrTodoNotFound) {
+		return customerrors.ErrTodoNotFoundWithID(id)
+	}
+	return customerrors.WrapGetError(err)
+}
@@ -2,9 +2,17 @@ package services
 
 import (
 	"backend/config"
+	customerrors "backend/errors"
+	"backend/i18n"
+	"backend/metrics"
 	"backend/models"
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 var service *TodoService
@@ -35,7 +43,7 @@ func TestCreateTodo(t *testing.T) {
 			Priority:    5,
 		}
 
-		todo, err := service.CreateTodo(input)
+		todo, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -62,7 +70,7 @@ func TestCreateTodo(t *testing.T) {
 			Title: "服务层测试任务2（默认值）",
 		}
 
-		todo, err := service.CreateTodo(input)
+		todo, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -85,7 +93,7 @@ func TestCreateTodo(t *testing.T) {
 			Category:    "study",
 		}
 
-		todo, err := service.CreateTodo(input)
+		todo, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -106,7 +114,7 @@ func TestCreateTodo(t *testing.T) {
 			Title: "",
 		}
 
-		_, err := service.CreateTodo(input)
+		_, err := service.CreateTodo(context.Background(), input)
 		if err == nil {
 			t.Error("标题为空应该返回错误")
 			return
@@ -120,7 +128,7 @@ func TestCreateTodo(t *testing.T) {
 			Title: "   ",
 		}
 
-		_, err := service.CreateTodo(input)
+		_, err := service.CreateTodo(context.Background(), input)
 		if err == nil {
 			t.Error("标题只有空格应该返回错误")
 			return
@@ -135,7 +143,7 @@ func TestCreateTodo(t *testing.T) {
 			Category: "invalid_category",
 		}
 
-		_, err := service.CreateTodo(input)
+		_, err := service.CreateTodo(context.Background(), input)
 		if err == nil {
 			t.Error("无效分类应该返回错误")
 			return
@@ -150,7 +158,7 @@ func TestCreateTodo(t *testing.T) {
 			Priority: 10,
 		}
 
-		_, err := service.CreateTodo(input)
+		_, err := service.CreateTodo(context.Background(), input)
 		if err == nil {
 			t.Error("优先级超出范围应该返回错误")
 			return
@@ -158,12 +166,66 @@ func TestCreateTodo(t *testing.T) {
 
 		t.Logf("✅ 正确拦截无效优先级: %v", err)
 	})
+
+	t.Run("验证：多个字段不合法时一次返回所有字段", func(t *testing.T) {
+		_, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: " ", Category: "game", Priority: -1})
+		appErr := customerrors.AsAppError(err)
+		if appErr.Code != customerrors.CodeValidationFailed {
+			t.Fatalf("错误码应该为 %s，实际: %s", customerrors.CodeValidationFailed, appErr.Code)
+		}
+
+		fields := make([]string, 0, len(appErr.Fields))
+		for _, f := range appErr.Fields {
+			fields = append(fields, f.Field+":"+f.Rule)
+		}
+		if fmt.Sprint(fields) != "[title:required category:oneof priority:range]" {
+			t.Errorf("字段错误不正确: %v", fields)
+		}
+
+		t.Logf("✅ 字段错误: %v", fields)
+
+		// 字段提示按语言生成
+		localized := i18n.Error(i18n.LangZhCN, appErr, nil)
+		if localized.Fields[0].Message != "标题不能为空" {
+			t.Errorf("中文字段提示不正确: %s", localized.Fields[0].Message)
+		}
+		if msg := i18n.Error(i18n.LangEN, appErr, nil).Fields[0].Message; msg != "Title is required" {
+			t.Errorf("英文字段提示不正确: %s", msg)
+		}
+
+		t.Logf("✅ 本地化提示: %s", localized.Detail)
+	})
+
+	t.Run("登录用户创建的记录保存创建者，匿名创建的为空", func(t *testing.T) {
+		owned, err := service.CreateTodo(WithUserID(context.Background(), 42), &models.CreateTodoInput{Title: "有创建者的任务"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer service.DeleteTodo(context.Background(), owned.ID)
+		anonymous, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "匿名创建的任务"})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer service.DeleteTodo(context.Background(), anonymous.ID)
+
+		saved, err := service.GetTodoByID(context.Background(), owned.ID)
+		if err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		if saved.OwnerID == nil || *saved.OwnerID != 42 {
+			t.Errorf("创建者应该为 42，实际: %v", saved.OwnerID)
+		}
+		if anonymous.OwnerID != nil {
+			t.Errorf("匿名创建的记录不应该有创建者，实际: %v", *anonymous.OwnerID)
+		}
+		t.Logf("✅ 创建者: %d", *saved.OwnerID)
+	})
 }
 
 // TestGetAllTodos 测试获取所有待办事项
 func TestGetAllTodos(t *testing.T) {
 	t.Run("获取所有待办事项", func(t *testing.T) {
-		todos, err := service.GetAllTodos("", "")
+		todos, err := service.GetAllTodos(context.Background(), "", "")
 		if err != nil {
 			t.Errorf("获取失败: %v", err)
 			return
@@ -173,7 +235,7 @@ func TestGetAllTodos(t *testing.T) {
 	})
 
 	t.Run("按分类筛选", func(t *testing.T) {
-		todos, err := service.GetAllTodos("work", "")
+		todos, err := service.GetAllTodos(context.Background(), "work", "")
 		if err != nil {
 			t.Errorf("获取失败: %v", err)
 			return
@@ -189,7 +251,7 @@ func TestGetAllTodos(t *testing.T) {
 	})
 
 	t.Run("按优先级排序", func(t *testing.T) {
-		todos, err := service.GetAllTodos("", "priority")
+		todos, err := service.GetAllTodos(context.Background(), "", "priority")
 		if err != nil {
 			t.Errorf("获取失败: %v", err)
 			return
@@ -207,7 +269,7 @@ func TestGetAllTodos(t *testing.T) {
 	})
 
 	t.Run("验证：无效分类应该失败", func(t *testing.T) {
-		_, err := service.GetAllTodos("invalid", "")
+		_, err := service.GetAllTodos(context.Background(), "invalid", "")
 		if err == nil {
 			t.Error("无效分类应该返回错误")
 			return
@@ -217,7 +279,7 @@ func TestGetAllTodos(t *testing.T) {
 	})
 
 	t.Run("验证：无效排序参数应该失败", func(t *testing.T) {
-		_, err := service.GetAllTodos("", "invalid_sort")
+		_, err := service.GetAllTodos(context.Background(), "", "invalid_sort")
 		if err == nil {
 			t.Error("无效排序参数应该返回错误")
 			return
@@ -225,6 +287,36 @@ func TestGetAllTodos(t *testing.T) {
 
 		t.Logf("✅ 正确拦截无效排序参数: %v", err)
 	})
+
+	t.Run("分页获取", func(t *testing.T) {
+		all, err := service.GetAllTodos(context.Background(), "", "priority")
+		if err != nil {
+			t.Fatalf("获取失败: %v", err)
+		}
+
+		page, total, err := service.ListTodos(context.Background(), "", "priority", 1, 2)
+		if err != nil {
+			t.Fatalf("分页获取失败: %v", err)
+		}
+		if total != int64(len(all)) {
+			t.Errorf("总数应该为 %d，实际: %d", len(all), total)
+		}
+		if len(all) >= 2 && (len(page) != 2 || page[0].ID != all[0].ID || page[1].ID != all[1].ID) {
+			t.Error("第一页应该与不分页结果的前两条相同")
+		}
+
+		t.Logf("✅ 分页获取成功，共 %d 条，第一页 %d 条", total, len(page))
+	})
+
+	t.Run("验证：无效分页参数应该失败", func(t *testing.T) {
+		_, _, err := service.ListTodos(context.Background(), "", "", 0, MaxPageSize+1)
+		appErr := customerrors.AsAppError(err)
+		if appErr.Code != customerrors.CodeValidationFailed || len(appErr.Fields) != 2 {
+			t.Errorf("应该同时返回 page 和 page_size 两个字段错误，实际: %v", err)
+		}
+
+		t.Logf("✅ 正确拦截无效分页参数: %v", err)
+	})
 }
 
 // TestGetTodoByID 测试根据ID获取
@@ -236,14 +328,14 @@ func TestGetTodoByID(t *testing.T) {
 			Category: "work",
 			Priority: 3,
 		}
-		created, err := service.CreateTodo(input)
+		created, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
 		}
 
 		// 查询
-		todo, err := service.GetTodoByID(created.ID)
+		todo, err := service.GetTodoByID(context.Background(), created.ID)
 		if err != nil {
 			t.Errorf("查询失败: %v", err)
 			return
@@ -257,7 +349,7 @@ func TestGetTodoByID(t *testing.T) {
 	})
 
 	t.Run("验证：ID为0应该失败", func(t *testing.T) {
-		_, err := service.GetTodoByID(0)
+		_, err := service.GetTodoByID(context.Background(), 0)
 		if err == nil {
 			t.Error("ID为0应该返回错误")
 			return
@@ -267,11 +359,14 @@ func TestGetTodoByID(t *testing.T) {
 	})
 
 	t.Run("验证：不存在的ID应该失败", func(t *testing.T) {
-		_, err := service.GetTodoByID(999999)
+		_, err := service.GetTodoByID(context.Background(), 999999)
 		if err == nil {
 			t.Error("不存在的ID应该返回错误")
 			return
 		}
+		if !errors.Is(err, customerrors.ErrTodoNotFound) || customerrors.CodeOf(err) != customerrors.CodeTodoNotFound {
+			t.Errorf("错误码应该为 %s，实际: %s", customerrors.CodeTodoNotFound, customerrors.CodeOf(err))
+		}
 
 		t.Logf("✅ 正确处理不存在的ID: %v", err)
 	})
@@ -287,7 +382,7 @@ func TestUpdateTodo(t *testing.T) {
 			Category:    "work",
 			Priority:    3,
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -305,7 +400,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority:    5,
 			Version:     originalVersion,
 		}
-		updated, err := service.UpdateTodo(created.ID, updateInput)
+		updated, err := service.UpdateTodo(context.Background(), created.ID, updateInput)
 		if err != nil {
 			t.Errorf("更新失败: %v", err)
 			return
@@ -332,7 +427,7 @@ func TestUpdateTodo(t *testing.T) {
 			updated.Title, updated.Category, updated.Priority, updated.Version)
 
 		// 清理
-		service.DeleteTodo(created.ID)
+		service.DeleteTodo(context.Background(), created.ID)
 	})
 
 	t.Run("编辑时自动去除空格", func(t *testing.T) {
@@ -341,7 +436,7 @@ func TestUpdateTodo(t *testing.T) {
 			Title:    "测试任务",
 			Category: "work",
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -355,7 +450,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority:    3,
 			Version:     created.Version,
 		}
-		updated, err := service.UpdateTodo(created.ID, updateInput)
+		updated, err := service.UpdateTodo(context.Background(), created.ID, updateInput)
 		if err != nil {
 			t.Errorf("更新失败: %v", err)
 			return
@@ -371,7 +466,7 @@ func TestUpdateTodo(t *testing.T) {
 		t.Log("✅ 自动去除空格功能正常")
 
 		// 清理
-		service.DeleteTodo(created.ID)
+		service.DeleteTodo(context.Background(), created.ID)
 	})
 
 	t.Run("乐观锁：编辑时版本冲突", func(t *testing.T) {
@@ -381,7 +476,7 @@ func TestUpdateTodo(t *testing.T) {
 			Category: "work",
 			Priority: 3,
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -394,7 +489,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority: 4,
 			Version:  0,
 		}
-		_, err = service.UpdateTodo(created.ID, updateInput1)
+		_, err = service.UpdateTodo(context.Background(), created.ID, updateInput1)
 		if err != nil {
 			t.Errorf("第一次编辑失败: %v", err)
 			return
@@ -408,7 +503,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority: 5,
 			Version:  0, // 使用旧版本号
 		}
-		_, err = service.UpdateTodo(created.ID, updateInput2)
+		_, err = service.UpdateTodo(context.Background(), created.ID, updateInput2)
 		if err == nil {
 			t.Error("使用旧版本号编辑应该失败")
 			return
@@ -421,14 +516,19 @@ func TestUpdateTodo(t *testing.T) {
 
 		t.Logf("✅ 乐观锁正常工作（编辑场景）: %v", err)
 
+		// 本地化提示带上当前版本号
+		if detail := i18n.Detail(i18n.LangZhCN, err); detail != "数据已被其他设备修改（当前版本：1）" {
+			t.Errorf("版本冲突提示不正确: %s", detail)
+		}
+
 		// 验证数据没有被覆盖
-		final, _ := service.GetTodoByID(created.ID)
+		final, _ := service.GetTodoByID(context.Background(), created.ID)
 		if final.Title != "用户A的修改" {
 			t.Error("数据被错误覆盖")
 		}
 
 		// 清理
-		service.DeleteTodo(created.ID)
+		service.DeleteTodo(context.Background(), created.ID)
 	})
 
 	t.Run("验证：标题为空应该失败", func(t *testing.T) {
@@ -437,7 +537,7 @@ func TestUpdateTodo(t *testing.T) {
 			Title:    "测试任务",
 			Category: "work",
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -450,7 +550,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority: 3,
 			Version:  created.Version,
 		}
-		_, err = service.UpdateTodo(created.ID, updateInput)
+		_, err = service.UpdateTodo(context.Background(), created.ID, updateInput)
 		if err == nil {
 			t.Error("标题为空应该返回错误")
 		}
@@ -458,7 +558,7 @@ func TestUpdateTodo(t *testing.T) {
 		t.Logf("✅ 正确拦截空标题: %v", err)
 
 		// 清理
-		service.DeleteTodo(created.ID)
+		service.DeleteTodo(context.Background(), created.ID)
 	})
 
 	t.Run("验证：无效分类应该失败", func(t *testing.T) {
@@ -467,7 +567,7 @@ func TestUpdateTodo(t *testing.T) {
 			Title:    "测试任务",
 			Category: "work",
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -480,7 +580,7 @@ func TestUpdateTodo(t *testing.T) {
 			Priority: 3,
 			Version:  created.Version,
 		}
-		_, err = service.UpdateTodo(created.ID, updateInput)
+		_, err = service.UpdateTodo(context.Background(), created.ID, updateInput)
 		if err == nil {
 			t.Error("无效分类应该返回错误")
 		}
@@ -488,7 +588,7 @@ func TestUpdateTodo(t *testing.T) {
 		t.Logf("✅ 正确拦截无效分类: %v", err)
 
 		// 清理
-		service.DeleteTodo(created.ID)
+		service.DeleteTodo(context.Background(), created.ID)
 	})
 
 	t.Run("验证：ID不存在应该失败", func(t *testing.T) {
@@ -498,9 +598,13 @@ func TestUpdateTodo(t *testing.T) {
 			Priority: 3,
 			Version:  0,
 		}
-		_, err := service.UpdateTodo(999999, updateInput)
+		_, err := service.UpdateTodo(context.Background(), 999999, updateInput)
 		if err == nil {
 			t.Error("不存在的ID应该返回错误")
+			return
+		}
+		if appErr := customerrors.AsAppError(err); appErr.Code != customerrors.CodeTodoNotFound || appErr.Status != 404 || appErr.Details["id"] != uint(999999) {
+			t.Errorf("错误码、状态码或附加信息不正确: %s %d %v", appErr.Code, appErr.Status, appErr.Details)
 		}
 
 		t.Logf("✅ 正确处理不存在的ID: %v", err)
@@ -516,7 +620,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Category: "study",
 			Priority: 4,
 		}
-		created, err := service.CreateTodo(input)
+		created, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -530,7 +634,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Completed: true,
 			Version:   originalVersion,
 		}
-		updated, err := service.UpdateTodoStatus(created.ID, updateInput)
+		updated, err := service.UpdateTodoStatus(context.Background(), created.ID, updateInput)
 		if err != nil {
 			t.Errorf("更新失败: %v", err)
 			return
@@ -553,7 +657,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Category: "work",
 			Priority: 5,
 		}
-		created, err := service.CreateTodo(input)
+		created, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -564,7 +668,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Completed: true,
 			Version:   0,
 		}
-		_, err = service.UpdateTodoStatus(created.ID, updateInput1)
+		_, err = service.UpdateTodoStatus(context.Background(), created.ID, updateInput1)
 		if err != nil {
 			t.Errorf("第一次更新失败: %v", err)
 			return
@@ -576,7 +680,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Completed: false,
 			Version:   0, // 使用旧版本号
 		}
-		_, err = service.UpdateTodoStatus(created.ID, updateInput2)
+		_, err = service.UpdateTodoStatus(context.Background(), created.ID, updateInput2)
 		if err == nil {
 			t.Error("使用旧版本号应该失败")
 			return
@@ -595,7 +699,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Completed: true,
 			Version:   0,
 		}
-		_, err := service.UpdateTodoStatus(0, updateInput)
+		_, err := service.UpdateTodoStatus(context.Background(), 0, updateInput)
 		if err == nil {
 			t.Error("ID为0应该返回错误")
 			return
@@ -609,7 +713,7 @@ func TestUpdateTodoStatus(t *testing.T) {
 			Completed: true,
 			Version:   -1,
 		}
-		_, err := service.UpdateTodoStatus(1, updateInput)
+		_, err := service.UpdateTodoStatus(context.Background(), 1, updateInput)
 		if err == nil {
 			t.Error("负数版本号应该返回错误")
 			return
@@ -628,7 +732,7 @@ func TestDeleteTodo(t *testing.T) {
 			Category: "life",
 			Priority: 1,
 		}
-		created, err := service.CreateTodo(input)
+		created, err := service.CreateTodo(context.Background(), input)
 		if err != nil {
 			t.Errorf("创建失败: %v", err)
 			return
@@ -638,14 +742,14 @@ func TestDeleteTodo(t *testing.T) {
 		t.Logf("创建了 ID=%d 的待办事项", todoID)
 
 		// 删除
-		err = service.DeleteTodo(todoID)
+		err = service.DeleteTodo(context.Background(), todoID)
 		if err != nil {
 			t.Errorf("删除失败: %v", err)
 			return
 		}
 
 		// 验证已删除
-		_, err = service.GetTodoByID(todoID)
+		_, err = service.GetTodoByID(context.Background(), todoID)
 		if err == nil {
 			t.Error("删除后查询应该失败")
 			return
@@ -655,7 +759,7 @@ func TestDeleteTodo(t *testing.T) {
 	})
 
 	t.Run("验证：ID为0应该失败", func(t *testing.T) {
-		err := service.DeleteTodo(0)
+		err := service.DeleteTodo(context.Background(), 0)
 		if err == nil {
 			t.Error("ID为0应该返回错误")
 			return
@@ -665,7 +769,7 @@ func TestDeleteTodo(t *testing.T) {
 	})
 
 	t.Run("验证：删除不存在的待办事项应该失败", func(t *testing.T) {
-		err := service.DeleteTodo(999999)
+		err := service.DeleteTodo(context.Background(), 999999)
 		if err == nil {
 			t.Error("删除不存在的待办事项应该返回错误")
 			return
@@ -673,6 +777,26 @@ func TestDeleteTodo(t *testing.T) {
 
 		t.Logf("✅ 正确处理不存在的记录: %v", err)
 	})
+
+	t.Run("版本冲突计入 delete 指标", func(t *testing.T) {
+		ctx := context.Background()
+		created, err := service.CreateTodo(ctx, &models.CreateTodoInput{Title: "删除冲突测试", Category: "life", Priority: 1})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		defer service.DeleteTodo(ctx, created.ID)
+
+		conflicts := metrics.VersionConflicts.WithLabelValues("delete")
+		before := testutil.ToFloat64(conflicts)
+		err = service.DeleteTodoWithVersion(ctx, created.ID, created.Version+1)
+		if _, ok := err.(*VersionConflictError); !ok {
+			t.Fatalf("应该返回 VersionConflictError，实际: %v", err)
+		}
+		if after := testutil.ToFloat64(conflicts); after != before+1 {
+			t.Fatalf("delete 冲突计数应该加 1: %v -> %v", before, after)
+		}
+		t.Logf("✅ 删除冲突已计数")
+	})
 }
 
 // TestCompleteServiceWorkflow 测试完整服务层工作流
@@ -685,14 +809,14 @@ func TestCompleteServiceWorkflow(t *testing.T) {
 			Category:    "work",
 			Priority:    5,
 		}
-		created, err := service.CreateTodo(createInput)
+		created, err := service.CreateTodo(context.Background(), createInput)
 		if err != nil {
 			t.Fatalf("❌ 创建失败: %v", err)
 		}
 		t.Logf("✅ 1. 创建成功，ID=%d, Version=%d", created.ID, created.Version)
 
 		// 2. 查询
-		retrieved, err := service.GetTodoByID(created.ID)
+		retrieved, err := service.GetTodoByID(context.Background(), created.ID)
 		if err != nil {
 			t.Fatalf("❌ 查询失败: %v", err)
 		}
@@ -706,7 +830,7 @@ func TestCompleteServiceWorkflow(t *testing.T) {
 			Priority:    4,
 			Version:     retrieved.Version,
 		}
-		edited, err := service.UpdateTodo(created.ID, editInput)
+		edited, err := service.UpdateTodo(context.Background(), created.ID, editInput)
 		if err != nil {
 			t.Fatalf("❌ 编辑失败: %v", err)
 		}
@@ -720,7 +844,7 @@ func TestCompleteServiceWorkflow(t *testing.T) {
 			Completed: true,
 			Version:   edited.Version,
 		}
-		statusUpdated, err := service.UpdateTodoStatus(created.ID, updateStatusInput)
+		statusUpdated, err := service.UpdateTodoStatus(context.Background(), created.ID, updateStatusInput)
 		if err != nil {
 			t.Fatalf("❌ 更新状态失败: %v", err)
 		}
@@ -730,14 +854,14 @@ func TestCompleteServiceWorkflow(t *testing.T) {
 		t.Logf("✅ 4. 更新状态成功，版本号: %d -> %d", edited.Version, statusUpdated.Version)
 
 		// 5. 删除
-		err = service.DeleteTodo(created.ID)
+		err = service.DeleteTodo(context.Background(), created.ID)
 		if err != nil {
 			t.Fatalf("❌ 删除失败: %v", err)
 		}
 		t.Log("✅ 5. 删除成功")
 
 		// 6. 验证删除
-		_, err = service.GetTodoByID(created.ID)
+		_, err = service.GetTodoByID(context.Background(), created.ID)
 		if err == nil {
 			t.Error("❌ 删除后不应该能查询到")
 		}
@@ -746,3 +870,90 @@ func TestCompleteServiceWorkflow(t *testing.T) {
 		t.Log("🎉 完整服务层工作流测试全部通过（包含编辑功能）！")
 	})
 }
+
+// TestContextCancellation 测试请求取消和超时
+func TestContextCancellation(t *testing.T) {
+	t.Run("上下文已取消时返回 REQUEST_CANCELED 而不是内部错误", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := service.GetAllTodos(ctx, "", "")
+		if err == nil {
+			t.Fatal("应该返回错误")
+		}
+		appErr := customerrors.AsAppError(err)
+		if appErr.Code != customerrors.CodeRequestCanceled || appErr.Status != customerrors.StatusClientClosedRequest {
+			t.Fatalf("错误码应该为 REQUEST_CANCELED/499，实际: %s/%d", appErr.Code, appErr.Status)
+		}
+		t.Logf("✅ %v", err)
+	})
+
+	t.Run("超过处理时限时返回 REQUEST_TIMEOUT", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		_, err := service.GetTodoByID(ctx, 1)
+		// 驱动返回的错误不一定是 context 包的错误，按请求的上下文归类
+		appErr := customerrors.AsAppError(customerrors.FromContext(ctx, err))
+		if appErr.Code != customerrors.CodeRequestTimeout {
+			t.Fatalf("错误码应该为 REQUEST_TIMEOUT，实际: %s（%v）", appErr.Code, err)
+		}
+		t.Logf("✅ %v", err)
+	})
+}
+
+func TestTodoLimit(t *testing.T) {
+	create := func(t *testing.T, ctx context.Context, title string) (*models.Todo, error) {
+		todo, err := service.CreateTodo(ctx, &models.CreateTodoInput{Title: title, Category: "work"})
+		if err == nil {
+			t.Cleanup(func() { service.DeleteTodo(context.Background(), todo.ID) })
+		}
+		return todo, err
+	}
+
+	t.Run("按登录用户计数，导入不受限制", func(t *testing.T) {
+		defer func(limit int) { MaxTodos = limit }(MaxTodos)
+		MaxTodos = 1
+		// 使用不存在的用户 ID，不受其他测试数据的影响
+		base := uint(time.Now().UnixNano() % 1000000000)
+		alice, bob := WithUserID(context.Background(), base+1), WithUserID(context.Background(), base+2)
+
+		if _, err := create(t, alice, "第一条"); err != nil {
+			t.Fatalf("未达到上限时应该允许创建: %v", err)
+		}
+		if _, err := create(t, alice, "超出上限"); !errors.Is(err, customerrors.ErrTodoLimitReached) {
+			t.Fatalf("应该返回 TODO_LIMIT_REACHED，实际: %v", err)
+		}
+		if _, err := create(t, bob, "其他用户"); err != nil {
+			t.Errorf("其他用户不应受影响: %v", err)
+		}
+		imported, err := service.ImportTodo(alice, &models.Todo{Title: "导入", Category: "work"})
+		if err != nil {
+			t.Errorf("导入不受上限限制: %v", err)
+		} else {
+			service.DeleteTodo(context.Background(), imported.ID)
+		}
+		t.Logf("✅ 每个用户最多 %d 条", MaxTodos)
+	})
+
+	t.Run("匿名创建合计计数，不影响登录用户", func(t *testing.T) {
+		defer func(limit int) { MaxTodos = limit }(MaxTodos)
+		anonymous, err := models.CountLiveAnonymous(context.Background())
+		if err != nil {
+			t.Fatalf("统计匿名待办事项失败: %v", err)
+		}
+		// 数据库中已有其他测试创建的匿名记录，上限设为只能再创建一条
+		MaxTodos = int(anonymous) + 1
+
+		if _, err := create(t, context.Background(), "匿名一"); err != nil {
+			t.Fatalf("未达到上限时应该允许匿名创建: %v", err)
+		}
+		if _, err := create(t, context.Background(), "匿名二"); !errors.Is(err, customerrors.ErrTodoLimitReached) {
+			t.Fatalf("匿名创建达到上限应该返回 TODO_LIMIT_REACHED，实际: %v", err)
+		}
+		user := WithUserID(context.Background(), uint(time.Now().UnixNano()%1000000000)+3)
+		if _, err := create(t, user, "登录用户"); err != nil {
+			t.Errorf("匿名写满不应影响登录用户: %v", err)
+		}
+		t.Logf("✅ 匿名创建合计最多 %d 条", MaxTodos)
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracing 测试 TodoService 和 GORM 的 span
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	created, err := service.CreateTodo(context.Background(), &models.CreateTodoInput{Title: "追踪测试", Category: "work"})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	defer service.DeleteTodo(context.Background(), created.ID)
+
+	t.Run("Service 的 span 挂在请求的 span 下，SQL 的 span 挂在 Service 的 span 下", func(t *testing.T) {
+		exporter.Reset()
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+		if _, err := service.GetTodoByID(ctx, created.ID); err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		parent.End()
+
+		spans := map[string]tracetest.SpanStub{}
+		for _, s := range exporter.GetSpans() {
+			spans[s.Name] = s
+		}
+		serviceSpan, ok := spans["TodoService.GetTodoByID"]
+		if !ok || serviceSpan.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("缺少 TodoService.GetTodoByID 的 span 或父 span 不对: %+v", spans)
+		}
+		dbSpan, ok := spans["gorm.query"]
+		if !ok || dbSpan.Parent.SpanID() != serviceSpan.SpanContext.SpanID() {
+			t.Fatalf("缺少 gorm.query 的 span 或父 span 不对")
+		}
+		attrs := map[string]string{}
+		for _, a := range dbSpan.Attributes {
+			attrs[string(a.Key)] = a.Value.Emit()
+		}
+		if attrs["db.query.text"] == "" || attrs["db.rows_affected"] != "1" {
+			t.Errorf("SQL span 应该带有语句和影响行数，实际: %v", attrs)
+		}
+		t.Logf("✅ %s", attrs["db.query.text"])
+	})
+
+	t.Run("版本冲突记录错误码，但不把 span 标记为失败", func(t *testing.T) {
+		exporter.Reset()
+		_, err := service.UpdateTodoStatus(context.Background(), created.ID, &models.UpdateStatusInput{Completed: true, Version: created.Version + 10})
+		if err == nil {
+			t.Fatal("应该返回版本冲突错误")
+		}
+		for _, s := range exporter.GetSpans() {
+			if s.Name != "TodoService.UpdateTodoStatus" {
+				continue
+			}
+			if s.Status.Code == codes.Error {
+				t.Errorf("版本冲突不应标记为失败")
+			}
+			if len(s.Events) == 0 {
+				t.Errorf("应该记录错误事件")
+			}
+			t.Logf("✅ span 状态: %v", s.Status.Code)
+			return
+		}
+		t.Fatal("缺少 TodoService.UpdateTodoStatus 的 span")
+	})
+}
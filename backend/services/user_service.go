@@ -0,0 +1,115 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinPasswordLength 密码最短长度
+	MinPasswordLength = 8
+	// passwordIterations PBKDF2 迭代次数（OWASP 对 PBKDF2-HMAC-SHA256 的建议值）
+	passwordIterations = 600000
+	// passwordHashScheme 密码哈希的格式标识，写在哈希值开头，以后更换算法时据此区分
+	passwordHashScheme = "pbkdf2-sha256"
+)
+
+// usernamePattern 用户名只能包含字母、数字、点、下划线和短横线
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,64}$`)
+
+// UserService 用户账号服务
+type UserService struct{}
+
+func NewUserService() *UserService {
+	return &UserService{}
+}
+
+// CreateUser 创建用户，密码只保存哈希值
+func (s *UserService) CreateUser(ctx context.Context, input *models.CreateUserInput) (*models.User, error) {
+	if input.Role == "" {
+		input.Role = models.RoleUser
+	}
+
+	// 验证输入
+	var errs []*customerrors.AppError
+	if !usernamePattern.MatchString(input.Username) {
+		errs = append(errs, customerrors.ErrInvalidUsername)
+	}
+	if len(input.Password) < MinPasswordLength {
+		errs = append(errs, customerrors.ErrPasswordTooShort)
+	}
+	if input.Role != models.RoleUser && input.Role != models.RoleAdmin {
+		errs = append(errs, customerrors.ErrInvalidRole(input.Role))
+	}
+	if err := customerrors.Validation(errs...); err != nil {
+		return nil, err
+	}
+
+	exists, err := models.UsernameExists(ctx, input.Username)
+	if err != nil {
+		return nil, customerrors.WrapQueryUserError(err)
+	}
+	if exists {
+		return nil, customerrors.ErrUserExistsWithName(input.Username)
+	}
+
+	hash, err := HashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{Username: input.Username, PasswordHash: hash, Role: input.Role}
+	if err := user.Create(ctx); err != nil {
+		return nil, customerrors.WrapCreateUserError(err)
+	}
+	return user, nil
+}
+
+// HashPassword 使用 PBKDF2-HMAC-SHA256 和随机盐计算密码哈希
+// 格式为 pbkdf2-sha256$迭代次数$盐$哈希（base64）
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", passwordHashScheme, passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword 校验密码是否与哈希值匹配
+func VerifyPassword(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"backend/models"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MaxWebhookAttempts 最大投递次数，超过后进入死信
+	MaxWebhookAttempts = 8
+	// webhookBaseBackoff 第一次重试的等待时间，之后每次翻倍
+	webhookBaseBackoff = 10 * time.Second
+	// webhookMaxBackoff 重试等待时间上限
+	webhookMaxBackoff = time.Hour
+	// webhookTimeout 单次投递的超时时间
+	webhookTimeout = 10 * time.Second
+	// webhookLease 领取后占用的时间，需大于单次投递超时，实例崩溃后到期自动释放
+	webhookLease = time.Minute
+	// webhookPollInterval 轮询到期投递的间隔
+	webhookPollInterval = 5 * time.Second
+	// webhookBatchSize 每次领取的投递条数
+	webhookBatchSize = 20
+)
+
+// WebhookDispatcher 后台投递协程：轮询持久化的投递队列，签名后发送，失败按指数退避重试
+type WebhookDispatcher struct {
+	client   *http.Client
+	interval time.Duration
+	nudge    chan struct{}
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+	lastPoll atomic.Int64 // 最近一次轮询完成的时间（UnixNano），就绪检查使用
+}
+
+// DefaultWebhookDispatcher 全局投递协程，由 main 启动和停止；不允许投递到内网地址，见 WebhookAllowedTargets
+var DefaultWebhookDispatcher = NewWebhookDispatcher(newWebhookClient())
+
+func NewWebhookDispatcher(client *http.Client) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		client:   client,
+		interval: webhookPollInterval,
+		nudge:    make(chan struct{}, 1),
+	}
+}
+
+// Start 启动投递协程，重复调用无效
+func (d *WebhookDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go d.run(ctx, d.done)
+}
+
+// Stop 停止投递协程：中断正在进行的请求并等待协程退出
+// 未完成的投递保留在队列中，下次启动后继续
+func (d *WebhookDispatcher) Stop() {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel, d.done = nil, nil
+	d.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Status 投递协程的运行状态
+func (d *WebhookDispatcher) Status() WorkerStatus {
+	d.mu.Lock()
+	running := d.cancel != nil
+	d.mu.Unlock()
+	return newWorkerStatus(running, d.lastPoll.Load())
+}
+
+// Notify 通知有新的投递入队，立即处理而不必等到下次轮询
+func (d *WebhookDispatcher) Notify() {
+	select {
+	case d.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// run 轮询循环
+func (d *WebhookDispatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.processDue(ctx)
+		d.lastPoll.Store(time.Now().UnixNano())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.nudge:
+		}
+	}
+}
+
+// processDue 领取并投递所有到期的记录
+func (d *WebhookDispatcher) processDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := models.ClaimDueWebhookDeliveries(time.Now(), webhookBatchSize, webhookLease)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim deliveries", "component", "webhook", "error", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		for i := range deliveries {
+			d.process(ctx, &deliveries[i])
+		}
+	}
+}
+
+// process 投递一条记录并保存结果
+func (d *WebhookDispatcher) process(ctx context.Context, delivery *models.WebhookDelivery) {
+	sub, err := models.GetWebhookSubscriptionByID(ctx, delivery.SubscriptionID, nil) // 投递不限创建者
+	if ctx.Err() != nil {
+		// 服务关闭，查询被中断，不能当作订阅已删除
+		return
+	}
+	if err != nil || !sub.Active {
+		// 订阅已删除或停用，不再重试
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = "subscription removed or inactive"
+		if err := models.UpdateWebhookDeliveryResult(delivery); err != nil {
+			slog.ErrorContext(ctx, "failed to save delivery", "component", "webhook", "delivery_id", delivery.ID, "error", err)
+		}
+		return
+	}
+
+	code, err := d.Deliver(ctx, sub, delivery)
+	if ctx.Err() != nil {
+		// 服务关闭导致的中断不计入重试次数，占用到期后由下次启动继续投递
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxWebhookAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = truncate(err.Error(), 1024)
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.LastError = truncate(err.Error(), 1024)
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+
+	if err := models.UpdateWebhookDeliveryResult(delivery); err != nil {
+		slog.ErrorContext(ctx, "failed to save delivery", "component", "webhook", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// Deliver 发送一次签名请求，返回对方的 HTTP 状态码；非 2xx 视为失败
+func (d *WebhookDispatcher) Deliver(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-webhook/1.0")
+	req.Header.Set(WebhookEventHeader, "todo."+delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读取少量响应体以便复用连接
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff 第 attempts 次失败后的等待时间：10s、20s、40s……最长 1 小时，附加 0~20% 的随机抖动
+func webhookBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := webhookMaxBackoff
+	if attempts <= 20 {
+		if b := webhookBaseBackoff << (attempts - 1); b < webhookMaxBackoff {
+			backoff = b
+		}
+	}
+	jitter := time.Duration(rand.Int63n(int64(backoff)/5 + 1))
+	return backoff + jitter
+}
+
+// truncate 截断过长的错误信息
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
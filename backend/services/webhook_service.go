@@ -0,0 +1,269 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 投递请求头
+const (
+	WebhookEventHeader     = "X-Webhook-Event"     // 事件类型，如 todo.completed
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"  // 投递 ID，重试时不变，接收方可用于去重
+	WebhookTimestampHeader = "X-Webhook-Timestamp" // 发送时间（Unix 秒），参与签名，接收方可拒绝过旧的请求
+	WebhookSignatureHeader = "X-Webhook-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+)
+
+// validWebhookEvents 可订阅的事件类型
+var validWebhookEvents = []string{
+	models.WebhookEventCreated,
+	models.WebhookEventCompleted,
+	models.WebhookEventDeleted,
+}
+
+// unsupportedWebhookEvents 需求中列出但暂不支持的事件：待办事项没有截止日期，无法判断是否逾期，
+// 订阅时返回 WEBHOOK_EVENT_UNSUPPORTED，而不是当作拼错的事件名
+var unsupportedWebhookEvents = []string{"overdue"}
+
+// WebhookPayload 投递的 JSON 内容
+type WebhookPayload struct {
+	ID        string       `json:"id"`         // 事件 ID
+	Event     string       `json:"event"`      // 事件类型，如 todo.completed
+	CreatedAt time.Time    `json:"created_at"` // 事件产生时间
+	Data      *models.Todo `json:"data"`       // 事件发生后的待办事项
+}
+
+// WebhookService Webhook 订阅管理与事件入队
+type WebhookService struct {
+	dispatcher *WebhookDispatcher // 入队后通知其立即投递
+}
+
+func NewWebhookService(dispatcher *WebhookDispatcher) *WebhookService {
+	return &WebhookService{dispatcher: dispatcher}
+}
+
+// validateCreateInput 验证创建输入
+func (s *WebhookService) validateCreateInput(input *models.CreateWebhookInput) error {
+	var errs []*customerrors.AppError
+
+	u, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, customerrors.ErrWebhookURLInvalid)
+	} else if err := checkWebhookTarget(u); err != nil {
+		errs = append(errs, customerrors.AsAppError(err))
+	}
+
+	if strings.TrimSpace(input.Secret) == "" {
+		errs = append(errs, customerrors.ErrWebhookSecretRequired)
+	}
+
+	if len(input.Events) == 0 {
+		errs = append(errs, customerrors.ErrWebhookEventsRequired)
+	}
+	for _, e := range input.Events {
+		if contains(unsupportedWebhookEvents, e) {
+			errs = append(errs, customerrors.ErrWebhookEventUnsupported(e))
+			break
+		}
+		if !contains(validWebhookEvents, e) {
+			errs = append(errs, customerrors.ErrInvalidWebhookEvent(e))
+			break
+		}
+	}
+
+	return customerrors.Validation(errs...)
+}
+
+// CreateSubscription 创建订阅
+func (s *WebhookService) CreateSubscription(ctx context.Context, input *models.CreateWebhookInput) (*models.WebhookSubscription, error) {
+	if err := s.validateCreateInput(input); err != nil {
+		return nil, err
+	}
+	// 订阅属于创建它的用户
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, customerrors.ErrUnauthenticated
+	}
+
+	// 事件去重，保持输入顺序
+	events := make([]string, 0, len(input.Events))
+	for _, e := range input.Events {
+		if !contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		OwnerID:     &userID,
+		URL:         strings.TrimSpace(input.URL),
+		Events:      strings.Join(events, ","),
+		Secret:      input.Secret,
+		Description: strings.TrimSpace(input.Description),
+		Active:      true,
+		EventList:   events,
+	}
+	if err := models.CreateWebhookSubscription(ctx, sub); err != nil {
+		return nil, customerrors.WrapCreateError(err)
+	}
+
+	return sub, nil
+}
+
+// webhookOwner 订阅的查询范围：用户只能管理自己创建的订阅，返回其 ID；
+// 管理员可以管理所有订阅（包括迁移前没有创建者的），返回 nil。投递内容包含待办事项，不能让其他用户读取和修改
+func webhookOwner(ctx context.Context) (*uint, error) {
+	if IsAdmin(ctx) {
+		return nil, nil
+	}
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, customerrors.ErrUnauthenticated
+	}
+	return &userID, nil
+}
+
+// ListSubscriptions 获取当前用户的订阅，管理员获取所有订阅
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	owner, err := webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := models.GetWebhookSubscriptions(ctx, owner)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+	return subs, nil
+}
+
+// GetSubscription 获取单个订阅
+func (s *WebhookService) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	if id == 0 {
+		return nil, customerrors.ErrInvalidID
+	}
+	owner, err := webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return models.GetWebhookSubscriptionByID(ctx, id, owner)
+}
+
+// DeleteSubscription 删除订阅，未完成的投递一并删除
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	if id == 0 {
+		return customerrors.ErrInvalidID
+	}
+	owner, err := webhookOwner(ctx)
+	if err != nil {
+		return err
+	}
+	return models.DeleteWebhookSubscription(ctx, id, owner)
+}
+
+// ListDeliveries 获取最近的投递记录及响应状态码
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	if status != "" && status != models.DeliveryPending && status != models.DeliverySucceeded && status != models.DeliveryDead {
+		return nil, customerrors.ErrInvalidDeliveryStatus(status)
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	owner, err := webhookOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := models.GetWebhookDeliveries(ctx, subscriptionID, status, limit, owner)
+	if err != nil {
+		return nil, customerrors.WrapQueryError(err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver 重新投递（通常用于死信）
+func (s *WebhookService) Redeliver(ctx context.Context, id uint) error {
+	if id == 0 {
+		return customerrors.ErrInvalidID
+	}
+	owner, err := webhookOwner(ctx)
+	if err != nil {
+		return err
+	}
+	if err := models.RetryWebhookDelivery(ctx, id, time.Now(), owner); err != nil {
+		return err
+	}
+	s.dispatcher.Notify()
+	return nil
+}
+
+// Enqueue 为订阅了该事件的所有订阅写入待投递记录
+func (s *WebhookService) Enqueue(event string, todo *models.Todo) error {
+	return s.enqueue(newEventID(), event, time.Now(), todo)
+}
+
+// enqueue 使用指定的事件 ID 和产生时间入队
+func (s *WebhookService) enqueue(id, event string, createdAt time.Time, todo *models.Todo) error {
+	subs, err := models.GetActiveWebhookSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        id,
+		Event:     "todo." + event,
+		CreatedAt: createdAt,
+		Data:      todo,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, sub := range subs {
+		if !sub.Accepts(event) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			Event:          event,
+			Payload:        string(payload),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := models.CreateWebhookDeliveries(deliveries); err != nil {
+		return err
+	}
+	s.dispatcher.Notify()
+	return nil
+}
+
+// SignWebhookPayload 计算签名：HMAC-SHA256(secret, timestamp + "." + body)
+// 接收方用同样的方式计算并用 hmac.Equal 比较 X-Webhook-Signature
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEventID 生成随机事件 ID
+func newEventID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"backend/models"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver 本地测试接收方，记录收到的请求并校验签名
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	payloads []WebhookPayload
+	badSigs  int
+}
+
+func newWebhookReceiver(t *testing.T, secret string, status int) (*webhookReceiver, *httptest.Server) {
+	r := &webhookReceiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		timestamp, _ := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+		expected := SignWebhookPayload(secret, timestamp, body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !hmac.Equal([]byte(expected), []byte(req.Header.Get(WebhookSignatureHeader))) {
+			r.badSigs++
+		}
+		var payload WebhookPayload
+		_ = json.Unmarshal(body, &payload)
+		r.payloads = append(r.payloads, payload)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+// TestWebhookDelivery 测试签名投递、失败重试和投递记录
+func TestWebhookDelivery(t *testing.T) {
+	// 本地测试接收方监听在 127.0.0.1 上
+	WebhookAllowedTargets = NewTargetAllowlist([]string{"127.0.0.1"})
+	defer func() { WebhookAllowedTargets = NewTargetAllowlist(nil) }()
+	webhookService := NewWebhookService(NewWebhookDispatcher(http.DefaultClient))
+	dispatcher := webhookService.dispatcher
+	owner := WithUserID(context.Background(), 1)
+
+	t.Run("投递成功并校验签名", func(t *testing.T) {
+		receiver, srv := newWebhookReceiver(t, "test-secret", http.StatusOK)
+		sub, err := webhookService.CreateSubscription(owner, &models.CreateWebhookInput{
+			URL:    srv.URL,
+			Events: []string{"completed"},
+			Secret: "test-secret",
+		})
+		if err != nil {
+			t.Fatalf("创建订阅失败: %v", err)
+		}
+		defer webhookService.DeleteSubscription(owner, sub.ID)
+
+		todo := &models.Todo{ID: 42, Title: "Webhook 测试", Completed: true}
+		if err := webhookService.Enqueue(models.WebhookEventCompleted, todo); err != nil {
+			t.Fatalf("入队失败: %v", err)
+		}
+		// 未订阅的事件不会入队
+		if err := webhookService.Enqueue(models.WebhookEventDeleted, todo); err != nil {
+			t.Fatalf("入队失败: %v", err)
+		}
+
+		dispatcher.processDue(context.Background())
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		if len(receiver.payloads) != 1 {
+			t.Fatalf("应该只收到 1 次投递，实际 %d 次", len(receiver.payloads))
+		}
+		if receiver.badSigs != 0 {
+			t.Error("签名校验失败")
+		}
+		if p := receiver.payloads[0]; p.Event != "todo.completed" || p.Data == nil || p.Data.ID != 42 {
+			t.Errorf("投递内容不正确: %+v", p)
+		}
+
+		deliveries, err := webhookService.ListDeliveries(owner, sub.ID, "", 10)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("查询投递记录失败: %v, %d 条", err, len(deliveries))
+		}
+		if d := deliveries[0]; d.Status != models.DeliverySucceeded || d.ResponseCode != http.StatusOK || d.Attempts != 1 {
+			t.Errorf("投递记录不正确: %+v", d)
+		}
+
+		t.Logf("✅ 投递成功: %s -> %d", receiver.payloads[0].Event, deliveries[0].ResponseCode)
+	})
+
+	t.Run("接收方返回 5xx 时按退避时间重试", func(t *testing.T) {
+		receiver, srv := newWebhookReceiver(t, "retry-secret", http.StatusServiceUnavailable)
+		sub, err := webhookService.CreateSubscription(owner, &models.CreateWebhookInput{
+			URL:    srv.URL,
+			Events: []string{"created"},
+			Secret: "retry-secret",
+		})
+		if err != nil {
+			t.Fatalf("创建订阅失败: %v", err)
+		}
+		defer webhookService.DeleteSubscription(owner, sub.ID)
+
+		if err := webhookService.Enqueue(models.WebhookEventCreated, &models.Todo{ID: 7}); err != nil {
+			t.Fatalf("入队失败: %v", err)
+		}
+
+		before := time.Now()
+		dispatcher.processDue(context.Background())
+		// 还没到重试时间，再次处理不会投递
+		dispatcher.processDue(context.Background())
+
+		receiver.mu.Lock()
+		count := len(receiver.payloads)
+		receiver.mu.Unlock()
+		if count != 1 {
+			t.Errorf("退避期间不应重复投递，实际投递 %d 次", count)
+		}
+
+		deliveries, _ := webhookService.ListDeliveries(owner, sub.ID, models.DeliveryPending, 10)
+		if len(deliveries) != 1 {
+			t.Fatalf("失败的投递应该保持 pending 等待重试")
+		}
+		d := deliveries[0]
+		if d.Attempts != 1 || d.ResponseCode != http.StatusServiceUnavailable || d.LastError == "" {
+			t.Errorf("投递记录不正确: %+v", d)
+		}
+		if !d.NextAttemptAt.After(before.Add(webhookBaseBackoff - time.Second)) {
+			t.Errorf("下次投递时间应该在 %v 之后，实际 %v", webhookBaseBackoff, d.NextAttemptAt)
+		}
+
+		t.Logf("✅ 第 %d 次投递失败（%d），下次投递: %v", d.Attempts, d.ResponseCode, d.NextAttemptAt)
+	})
+
+	t.Run("验证：无效事件和 URL 应该失败", func(t *testing.T) {
+		_, err := webhookService.CreateSubscription(owner, &models.CreateWebhookInput{
+			URL: "http://example.com/hook", Events: []string{"overdue"}, Secret: "secret-123",
+		})
+		if customerrors.CodeOf(err) != customerrors.CodeWebhookEventUnsupported {
+			t.Errorf("overdue 应该返回 WEBHOOK_EVENT_UNSUPPORTED，实际: %v", err)
+		}
+		_, err = webhookService.CreateSubscription(owner, &models.CreateWebhookInput{
+			URL: "ftp://example.com/hook", Events: []string{"created"}, Secret: "secret-123",
+		})
+		if err == nil {
+			t.Error("非 http(s) 地址应该返回错误")
+		}
+		t.Log("✅ 正确拒绝无效输入")
+	})
+}
+
+// TestWebhookTargets 测试不允许投递到内网地址
+func TestWebhookTargets(t *testing.T) {
+	webhookService := NewWebhookService(NewWebhookDispatcher(http.DefaultClient))
+	defer func() { WebhookAllowedTargets = NewTargetAllowlist(nil) }()
+
+	t.Run("创建订阅时拒绝回环、链路本地和内网地址", func(t *testing.T) {
+		WebhookAllowedTargets = NewTargetAllowlist(nil)
+		for _, target := range []string{
+			"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://[::1]/hook", "http://10.1.2.3/hook",
+			"http://192.168.0.10/hook", "http://169.254.169.254/latest/meta-data", "http://[::ffff:127.0.0.1]/hook", "http://0.0.0.0/hook",
+		} {
+			_, err := webhookService.CreateSubscription(context.Background(), &models.CreateWebhookInput{
+				URL: target, Events: []string{"created"}, Secret: "secret-123",
+			})
+			if customerrors.CodeOf(err) != customerrors.CodeWebhookTargetForbidden {
+				t.Errorf("%s 应该被拒绝，实际: %v", target, err)
+			}
+		}
+		t.Logf("✅ 内网地址被拒绝")
+	})
+
+	t.Run("投递连接时检查实际的地址，白名单中的目标允许", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer srv.Close()
+		client := newWebhookClient()
+
+		WebhookAllowedTargets = NewTargetAllowlist(nil)
+		if _, err := client.Get(srv.URL); err == nil || !strings.Contains(err.Error(), "internal address") {
+			t.Errorf("连接内网地址应该失败，实际: %v", err)
+		}
+
+		WebhookAllowedTargets = NewTargetAllowlist([]string{"127.0.0.0/8"})
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("白名单中的地址应该允许，实际: %v", err)
+		}
+		resp.Body.Close()
+		if err := checkWebhookTarget(&url.URL{Host: "127.0.0.1:9000"}); err != nil {
+			t.Errorf("白名单中的地址应该允许创建订阅，实际: %v", err)
+		}
+		t.Logf("✅ 白名单中的目标: %s", srv.URL)
+	})
+}
+
+// TestWebhookBackoff 测试退避时间
+func TestWebhookBackoff(t *testing.T) {
+	for attempts, base := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 4: 80 * time.Second, 30: time.Hour} {
+		got := webhookBackoff(attempts)
+		if got < base || got > base+base/5 {
+			t.Errorf("第 %d 次失败后等待时间应该在 [%v, %v]，实际 %v", attempts, base, base+base/5, got)
+		}
+	}
+	t.Log("✅ 退避时间正确")
+}
+
+// TestWebhookOwner 测试用户只能管理自己创建的订阅和投递记录，管理员可以管理所有订阅
+func TestWebhookOwner(t *testing.T) {
+	webhookService := NewWebhookService(NewWebhookDispatcher(http.DefaultClient))
+	base := uint(time.Now().UnixNano() % 1000000000)
+	alice, bob := WithUserID(context.Background(), base+1), WithUserID(context.Background(), base+2)
+	admin := WithAdmin(WithUserID(context.Background(), base+3))
+
+	sub, err := webhookService.CreateSubscription(alice, &models.CreateWebhookInput{
+		URL: "https://hooks.example.com/owner", Events: []string{"created"}, Secret: "secret-123",
+	})
+	if err != nil {
+		t.Fatalf("创建订阅失败: %v", err)
+	}
+	defer webhookService.DeleteSubscription(alice, sub.ID)
+	if sub.OwnerID == nil || *sub.OwnerID != base+1 {
+		t.Fatalf("订阅应该记录创建者: %+v", sub.OwnerID)
+	}
+	if err := webhookService.Enqueue(models.WebhookEventCreated, &models.Todo{ID: 1, Title: "投递内容"}); err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	deliveries, err := webhookService.ListDeliveries(alice, sub.ID, "", 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("创建者应该能看到投递记录: %d %v", len(deliveries), err)
+	}
+	// 标记为死信，才能重新投递
+	dead := deliveries[0]
+	dead.Status = models.DeliveryDead
+	if err := models.UpdateWebhookDeliveryResult(&dead); err != nil {
+		t.Fatalf("更新投递记录失败: %v", err)
+	}
+
+	t.Run("其他用户看不到、不能修改，按不存在处理", func(t *testing.T) {
+		subs, _ := webhookService.ListSubscriptions(bob)
+		for _, s := range subs {
+			if s.ID == sub.ID {
+				t.Errorf("其他用户的订阅不应出现在列表中")
+			}
+		}
+		if _, err := webhookService.GetSubscription(bob, sub.ID); !errors.Is(err, customerrors.ErrWebhookNotFound) {
+			t.Errorf("获取其他用户的订阅应该返回 WEBHOOK_NOT_FOUND，实际: %v", err)
+		}
+		if all, _ := webhookService.ListDeliveries(bob, 0, "", 200); len(all) != 0 {
+			t.Errorf("不应看到其他用户订阅的投递记录: %d 条", len(all))
+		}
+		if err := webhookService.Redeliver(bob, dead.ID); !errors.Is(err, customerrors.ErrDeliveryNotRetryable) {
+			t.Errorf("不能重新投递其他用户的记录，实际: %v", err)
+		}
+		if err := webhookService.DeleteSubscription(bob, sub.ID); !errors.Is(err, customerrors.ErrWebhookNotFound) {
+			t.Errorf("不能删除其他用户的订阅，实际: %v", err)
+		}
+		if _, err := webhookService.ListSubscriptions(context.Background()); !errors.Is(err, customerrors.ErrUnauthenticated) {
+			t.Errorf("未登录应该返回 UNAUTHENTICATED，实际: %v", err)
+		}
+		t.Logf("✅ 订阅 %d 只对创建者可见", sub.ID)
+	})
+
+	t.Run("管理员可以管理所有订阅", func(t *testing.T) {
+		if _, err := webhookService.GetSubscription(admin, sub.ID); err != nil {
+			t.Errorf("管理员应该能获取订阅: %v", err)
+		}
+		if err := webhookService.Redeliver(admin, dead.ID); err != nil {
+			t.Errorf("管理员应该能重新投递: %v", err)
+		}
+		t.Logf("✅ 管理员可以管理")
+	})
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	customerrors "backend/errors"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WebhookAllowedTargets 允许投递到的内网目标，由 serve 按 TODO_WEBHOOK_ALLOWED_TARGETS 设置
+// Webhook 地址由调用方提交，不加限制时任何人都能让服务端向内网服务（如云平台的元数据接口）发送请求
+var WebhookAllowedTargets = NewTargetAllowlist(nil)
+
+// sharedAddressSpace 运营商级 NAT 地址（100.64.0.0/10），同样不对外
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// TargetAllowlist 内网目标白名单：主机名精确匹配（不区分大小写），IP 按 CIDR 匹配
+type TargetAllowlist struct {
+	hosts    map[string]bool
+	prefixes []netip.Prefix
+}
+
+// NewTargetAllowlist 解析白名单，每一项为主机名、IP 或 CIDR
+func NewTargetAllowlist(entries []string) *TargetAllowlist {
+	a := &TargetAllowlist{hosts: make(map[string]bool)}
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			a.prefixes = append(a.prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			a.prefixes = append(a.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else {
+			a.hosts[strings.ToLower(entry)] = true
+		}
+	}
+	return a
+}
+
+// allowsHost 主机名在白名单中时不检查它解析出的地址
+func (a *TargetAllowlist) allowsHost(host string) bool {
+	return a.hosts[strings.ToLower(host)]
+}
+
+// allowsAddr 公网地址总是允许，内网地址需要在白名单中
+func (a *TargetAllowlist) allowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !isInternalAddr(addr) {
+		return true
+	}
+	for _, prefix := range a.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternalAddr 回环、内网、链路本地、组播和未指定地址
+func isInternalAddr(addr netip.Addr) bool {
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr)
+}
+
+// checkWebhookTarget 创建订阅时检查地址：localhost 和指向内网的 IP 直接拒绝
+// 域名在这里不解析（解析结果随时可能变化），投递时由 dialWebhook 检查实际连接的地址
+func checkWebhookTarget(u *url.URL) error {
+	host := u.Hostname()
+	allow := WebhookAllowedTargets
+	if allow.allowsHost(host) {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !allow.allowsAddr(addr) {
+			return customerrors.ErrWebhookTargetForbidden
+		}
+		return nil
+	}
+	if h := strings.ToLower(strings.TrimSuffix(host, ".")); h == "localhost" || strings.HasSuffix(h, ".localhost") {
+		return customerrors.ErrWebhookTargetForbidden
+	}
+	return nil
+}
+
+// dialWebhook 投递时建立连接，在连接前检查解析出的每个地址，
+// 域名解析到内网（包括创建订阅后才改变解析的 DNS 重绑定）和重定向到内网地址时都会失败
+func dialWebhook(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	allow := WebhookAllowedTargets
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(_, resolved string, _ syscall.RawConn) error {
+			if allow.allowsHost(host) {
+				return nil
+			}
+			addrPort, err := netip.ParseAddrPort(resolved)
+			if err != nil {
+				return err
+			}
+			if !allow.allowsAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhook target %s resolves to internal address %s", host, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// newWebhookClient 投递使用的 HTTP 客户端：连接时检查目标地址，不使用环境变量中的代理（代理会绕过地址检查）
+func newWebhookClient() *http.Client {
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialWebhook,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: webhookTimeout,
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TLS 证书的热加载
+// 每次握手通过 GetConfigForClient 取当前的配置，证书、私钥或客户端 CA 文件变化后重新加载并原子替换，
+// 已建立的连接不受影响。加载失败（如文件只写了一半）时继续使用旧证书，下次检查时重试。
+// 文件变化通过定期比较修改时间和大小发现，不依赖文件系统通知，符号链接切换（如 Kubernetes Secret 挂载）也能发现
+
+// Options 证书文件与客户端证书校验
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string             // 校验客户端证书的 CA，为空时不要求客户端证书
+	ClientAuth   tls.ClientAuthType // ClientCAFile 不为空时生效，如 tls.RequireAndVerifyClientCert
+	Interval     time.Duration      // 检查文件变化的间隔
+}
+
+// Reloader 持有当前的 TLS 配置
+type Reloader struct {
+	opts    Options
+	current atomic.Pointer[tls.Config]
+
+	mu    sync.Mutex
+	stamp string // 上次加载时文件的修改时间和大小
+}
+
+// New 加载证书，失败时返回错误（启动时证书必须可用）
+func New(opts Options) (*Reloader, error) {
+	r := &Reloader{opts: opts}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig 给 http.Server 使用的配置，启用 HTTP/2，最低 TLS 1.2
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// Reload 重新加载证书、私钥和客户端 CA
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+func (r *Reloader) load() error {
+	stamp := r.fileStamp()
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in TLS client CA %s", r.opts.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = r.opts.ClientAuth
+	}
+
+	r.current.Store(cfg)
+	r.stamp = stamp
+	slog.Info("TLS certificate loaded", "file", r.opts.CertFile,
+		"subject", cert.Leaf.Subject.String(), "not_after", cert.Leaf.NotAfter)
+	return nil
+}
+
+// Watch 定期检查文件变化并重新加载，直到 ctx 结束
+func (r *Reloader) Watch(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reloadIfChanged(); err != nil {
+				slog.Error("TLS certificate reload failed, keeping the previous certificate", "error", err)
+			}
+		}
+	}
+}
+
+// reloadIfChanged 文件有变化时重新加载
+func (r *Reloader) reloadIfChanged() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fileStamp() == r.stamp {
+		return nil
+	}
+	return r.load()
+}
+
+// fileStamp 证书、私钥和 CA 文件的修改时间和大小，文件不存在时记为空
+func (r *Reloader) fileStamp() string {
+	var b strings.Builder
+	for _, name := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		if fi, err := os.Stat(name); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", name, fi.ModTime().UnixNano(), fi.Size())
+		}
+	}
+	return b.String()
+}
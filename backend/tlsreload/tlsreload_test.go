@@ -0,0 +1,231 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 测试用的自签名 CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发证书，返回证书和私钥的 PEM；server 为 true 时签发 127.0.0.1 的服务端证书，否则签发客户端证书
+func (ca *testCA) issue(t *testing.T, commonName string, server bool) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile 写入文件并把修改时间设为 at，避免两次写入落在同一个时间戳上
+func writeFile(t *testing.T, name string, data []byte, at time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve 使用 Reloader 的配置启动 HTTPS 服务，处理函数返回客户端证书的 CN
+func serve(t *testing.T, r *Reloader) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		TLSConfig: r.TLSConfig(),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if len(req.TLS.VerifiedChains) > 0 {
+				w.Write([]byte(req.TLS.VerifiedChains[0][0].Subject.CommonName))
+			}
+		}),
+	}
+	go srv.ServeTLS(ln, "", "")
+	t.Cleanup(func() { srv.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+// client 信任测试 CA 的 HTTPS 客户端，certs 为客户端证书
+func client(ca *testCA, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, Certificates: certs},
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+// servedCertificate 服务端证书的 CN
+func servedCertificate(t *testing.T, c *http.Client, url string) (string, *http.Response) {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	c.CloseIdleConnections()
+	return resp.TLS.PeerCertificates[0].Subject.CommonName, resp
+}
+
+func TestReloader(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	now := time.Now()
+	certPEM, keyPEM := ca.issue(t, "server v1", true)
+	writeFile(t, certFile, certPEM, now)
+	writeFile(t, keyFile, keyPEM, now)
+
+	r, err := New(Options{CertFile: certFile, KeyFile: keyFile, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("加载证书失败: %v", err)
+	}
+	url := serve(t, r)
+	c := client(ca)
+
+	t.Run("使用 HTTP/2", func(t *testing.T) {
+		name, resp := servedCertificate(t, c, url)
+		if resp.ProtoMajor != 2 || name != "server v1" {
+			t.Fatalf("应该通过 HTTP/2 返回 server v1 证书，实际: %s %s", resp.Proto, name)
+		}
+		t.Logf("✅ %s，证书 %s", resp.Proto, name)
+	})
+
+	t.Run("证书文件变化后新连接使用新证书", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go r.Watch(ctx)
+
+		// 写了一半的证书加载失败，继续使用旧证书
+		writeFile(t, certFile, certPEM[:len(certPEM)/2], now.Add(time.Second))
+		time.Sleep(50 * time.Millisecond)
+		if name, _ := servedCertificate(t, c, url); name != "server v1" {
+			t.Fatalf("加载失败时应该继续使用旧证书，实际: %s", name)
+		}
+
+		certPEM, keyPEM := ca.issue(t, "server v2", true)
+		writeFile(t, keyFile, keyPEM, now.Add(2*time.Second))
+		writeFile(t, certFile, certPEM, now.Add(2*time.Second))
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			name, _ := servedCertificate(t, c, url)
+			if name == "server v2" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("证书没有重新加载，实际: %s", name)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Logf("✅ 已切换到 server v2")
+	})
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	certPEM, keyPEM := ca.issue(t, "server", true)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	r, err := New(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile,
+		ClientAuth: tls.RequireAndVerifyClientCert, Interval: time.Minute})
+	if err != nil {
+		t.Fatalf("加载证书失败: %v", err)
+	}
+	url := serve(t, r)
+
+	t.Run("没有客户端证书时握手失败", func(t *testing.T) {
+		if _, err := client(ca).Get(url); err == nil {
+			t.Fatal("没有客户端证书应该握手失败")
+		}
+		t.Logf("✅ 握手失败")
+	})
+
+	t.Run("客户端证书的 CN 可以取到", func(t *testing.T) {
+		clientPEM, clientKey := ca.issue(t, "alice", false)
+		cert, err := tls.X509KeyPair(clientPEM, clientKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client(ca, cert).Get(url)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		defer resp.Body.Close()
+		body := make([]byte, 16)
+		n, _ := resp.Body.Read(body)
+		if string(body[:n]) != "alice" {
+			t.Fatalf("应该取到客户端证书的 CN alice，实际: %q", body[:n])
+		}
+		t.Logf("✅ 客户端身份: %s", body[:n])
+	})
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormSpanKey span 在 gorm.Statement 中的键
+const gormSpanKey = "tracing:span"
+
+// GormPlugin 为每条 SQL 创建一个 span，带有 SQL 语句、表名和影响的行数
+// 查询需要带上下文（DB.WithContext），span 才会挂在请求的 span 下
+type GormPlugin struct{}
+
+// NewGormPlugin 创建 GORM 追踪插件，通过 DB.Use 注册
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize 在 GORM 每类操作的前后注册回调
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		register(cb.Create(), "create"),
+		register(cb.Query(), "query"),
+		register(cb.Update(), "update"),
+		register(cb.Delete(), "delete"),
+		register(cb.Row(), "row"),
+		register(cb.Raw(), "raw"),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callback GORM 的回调注册器，processor 为某类操作的回调链
+type callback interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+type processor[C callback] interface {
+	Before(name string) C
+	After(name string) C
+}
+
+// register 在 gorm:<操作> 回调之前开始 span，之后结束 span
+func register[C callback, P processor[C]](p P, operation string) error {
+	if err := p.Before("gorm:"+operation).Register("tracing:before_"+operation, before(operation)); err != nil {
+		return err
+	}
+	return p.After("gorm:"+operation).Register("tracing:after_"+operation, after)
+}
+
+// before 开始 span，span 名为 gorm.<操作>
+func before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// 没有上级 span（如后台协程、管理命令）时不单独创建根 span
+			return
+		}
+		_, span := Tracer().Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNameMySQL, semconv.DBOperationName(operation)),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// after 结束 span，记录实际执行的 SQL（参数为占位符，不含用户数据）和影响的行数
+func after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	attrs := []attribute.KeyValue{
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	}
+	if db.Statement.Table != "" {
+		attrs = append(attrs, semconv.DBCollectionName(db.Statement.Table))
+	}
+	span.SetAttributes(attrs...)
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	customerrors "backend/errors"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 链路追踪
+// 使用 OpenTelemetry 的全局 TracerProvider：未调用 Setup 时为空实现，各处创建 span 几乎没有开销，
+// 所以 HTTP 中间件、Service 和 GORM 插件可以无条件埋点；跨服务传递使用 W3C traceparent / tracestate
+
+// ServiceName 未设置 OTEL_SERVICE_NAME 时上报的服务名
+const ServiceName = "todo-backend"
+
+// instrumentationName 本服务创建的 span 所属的 tracer
+const instrumentationName = "backend"
+
+// Options 追踪的导出配置
+type Options struct {
+	Exporter    string  // otlp、stdout 或 file，其他值不导出
+	File        string  // Exporter 为 file 时写入的文件，每行一个 span（JSON）
+	SampleRatio float64 // 根 span 的采样比例
+}
+
+// Setup 设置全局 TracerProvider 和 W3C 传播格式，返回的函数在退出时调用，导出缓冲中剩余的 span
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch opts.Exporter {
+	case "otlp":
+		exporter, err = otlptracegrpc.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case "file":
+		var f *os.File
+		if f, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+			closer = f
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		}
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName())))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// serviceName OTEL_SERVICE_NAME 优先（resource.Default 已读取），否则使用 ServiceName
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return ServiceName
+}
+
+// Tracer 本服务创建 span 使用的 tracer，从全局 TracerProvider 获取，Setup 之后才会导出
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 创建本服务的子 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误和错误码；只有内部错误把状态设为 Error，
+// 参数错误、记录不存在、版本冲突等是正常的业务结果，不应在追踪系统中显示为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		appErr := customerrors.AsAppError(err)
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.code", appErr.Code))
+		if appErr.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -1,26 +1,44 @@
 package utils
 
 import (
+	customerrors "backend/errors"
+	"backend/i18n"
 	"backend/services"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Response 统一响应结构
+// ProblemContentType RFC 7807 错误响应的 Content-Type
+const ProblemContentType = "application/problem+json"
+
+// Response 统一响应结构（成功）
 type Response struct {
-	Code    int         `json:"code"`    // 业务状态码：0表示成功，其他表示失败
+	Code    int         `json:"code"`    // 业务状态码：0表示成功
 	Message string      `json:"message"` // 提示消息
 	Data    interface{} `json:"data,omitempty"`
 }
 
-// VersionConflictResponse 版本冲突响应结构
-type VersionConflictResponse struct {
-	Code            int         `json:"code"`
-	Message         string      `json:"message"`
-	CurrentVersion  int         `json:"current_version"`
-	ProvidedVersion int         `json:"provided_version"`
-	LatestData      interface{} `json:"latest_data"`
+// Problem RFC 7807 错误响应结构
+// type/title/status/detail/instance 为标准字段，其余为扩展字段
+type Problem struct {
+	Type     string                    `json:"type"`               // 错误类型的 URI，同一个错误码对应同一个 type
+	Title    string                    `json:"title"`              // 错误类型的概括，不随具体参数变化
+	Status   int                       `json:"status"`             // HTTP 状态码
+	Detail   string                    `json:"detail"`             // 本次错误的具体描述
+	Instance string                    `json:"instance,omitempty"` // 出错的请求路径
+	Code     string                    `json:"error_code"`         // 错误码，如 TODO_NOT_FOUND
+	Errors   []customerrors.FieldError `json:"errors,omitempty"`   // 字段级校验错误
+	Details  map[string]interface{}    `json:"details,omitempty"`  // 错误的附加信息
+
+	// 版本冲突时携带的最新数据
+	CurrentVersion  *int        `json:"current_version,omitempty"`
+	ProvidedVersion *int        `json:"provided_version,omitempty"`
+	LatestData      interface{} `json:"latest_data,omitempty"`
 }
 
 // Success 成功响应
@@ -41,92 +59,106 @@ func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 	})
 }
 
-// Error 错误响应（通用）
-func Error(c *gin.Context, httpStatus int, code int, message string) {
-	c.JSON(httpStatus, Response{
-		Code:    code,
-		Message: message,
-	})
-}
-
-// BadRequest 400 错误请求
-func BadRequest(c *gin.Context, message string) {
-	c.JSON(http.StatusBadRequest, Response{
-		Code:    http.StatusBadRequest,
-		Message: message,
-	})
+// InvalidParam 400 路径或查询参数格式错误，如 ID 不是数字
+func InvalidParam(c *gin.Context, name string) {
+	Fail(c, customerrors.ErrInvalidRequest.
+		WithMessage("Invalid %s format", name).
+		WithDetails("param", name))
 }
 
-// NotFound 404 未找到
-func NotFound(c *gin.Context, message string) {
-	c.JSON(http.StatusNotFound, Response{
-		Code:    http.StatusNotFound,
-		Message: message,
-	})
+// BindError 参数绑定或校验失败，返回每个出错字段
+func BindError(c *gin.Context, err error) {
+	Fail(c, BindingError(err))
 }
 
-// Conflict 409 冲突（用于乐观锁冲突）
-func Conflict(c *gin.Context, message string) {
-	c.JSON(http.StatusConflict, Response{
-		Code:    http.StatusConflict,
-		Message: message,
-	})
+// Fail 以 application/problem+json 返回 AppError
+func Fail(c *gin.Context, err *customerrors.AppError) {
+	writeProblem(c, newProblem(c, err, nil))
 }
 
 // VersionConflict 版本冲突响应（包含最新数据）
 func VersionConflict(c *gin.Context, conflictErr *services.VersionConflictError) {
-	c.JSON(http.StatusConflict, VersionConflictResponse{
-		Code:            http.StatusConflict,
-		Message:         conflictErr.Message,
-		CurrentVersion:  conflictErr.CurrentVersion,
-		ProvidedVersion: conflictErr.ProvidedVersion,
-		LatestData:      conflictErr.LatestData,
-	})
+	VersionConflictWithData(c, conflictErr, conflictErr.LatestData)
 }
 
-// InternalServerError 500 服务器内部错误
-func InternalServerError(c *gin.Context, message string) {
-	c.JSON(http.StatusInternalServerError, Response{
-		Code:    http.StatusInternalServerError,
-		Message: message,
-	})
+// VersionConflictWithData 版本冲突响应，latestData 为按接口版本转换后的最新数据
+func VersionConflictWithData(c *gin.Context, conflictErr *services.VersionConflictError, latestData interface{}) {
+	p := newProblem(c, customerrors.ErrVersionConflict, conflictErr.LocalizationParams())
+	p.CurrentVersion = &conflictErr.CurrentVersion
+	p.ProvidedVersion = &conflictErr.ProvidedVersion
+	p.LatestData = latestData
+	writeProblem(c, p)
 }
 
 // HandleServiceError 统一处理 Service 层错误
-// 根据错误类型自动返回合适的 HTTP 状态码
+// 沿错误链查找 AppError，按其 HTTP 状态码和错误码返回；没有错误码的错误视为 500 内部错误，
+// 但请求已被取消或超时的返回 499 REQUEST_CANCELED / 504 REQUEST_TIMEOUT，不作为内部错误记录
 func HandleServiceError(c *gin.Context, err error) {
-	// 处理版本冲突错误
-	if conflictErr, ok := err.(*services.VersionConflictError); ok {
+	// 处理版本冲突错误（携带最新数据）
+	var conflictErr *services.VersionConflictError
+	if errors.As(err, &conflictErr) {
 		VersionConflict(c, conflictErr)
 		return
 	}
 
-	// 处理其他错误
-	errMsg := err.Error()
-
-	// 根据错误消息判断错误类型
+	appErr := customerrors.AsAppError(customerrors.FromContext(c.Request.Context(), err))
 	switch {
-	case contains(errMsg, "not found"):
-		NotFound(c, errMsg)
-	case contains(errMsg, "invalid"), contains(errMsg, "required"), contains(errMsg, "cannot exceed"):
-		BadRequest(c, errMsg)
-	case contains(errMsg, "conflict"):
-		Conflict(c, errMsg)
-	default:
-		InternalServerError(c, errMsg)
+	case appErr.Code == customerrors.CodeRequestCanceled, appErr.Code == customerrors.CodeRequestTimeout:
+		slog.WarnContext(c.Request.Context(), "request interrupted", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+	case appErr.Status >= http.StatusInternalServerError:
+		// 内部错误不把原始错误返回给客户端，只记录日志
+		slog.ErrorContext(c.Request.Context(), "internal error", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+	}
+	writeProblem(c, newProblem(c, appErr, nil))
+}
+
+// Language 当前请求使用的语言
+func Language(c *gin.Context) string {
+	return i18n.FromRequest(c.Request)
+}
+
+// newProblem 根据 AppError 构造错误响应，title、detail 和字段错误按请求的语言本地化
+func newProblem(c *gin.Context, err *customerrors.AppError, params map[string]interface{}) *Problem {
+	localized := i18n.Error(Language(c), err, params)
+	return &Problem{
+		Type:     ProblemType(err.Code),
+		Title:    localized.Title,
+		Status:   err.Status,
+		Detail:   localized.Detail,
+		Instance: c.Request.URL.RequestURI(),
+		Code:     err.Code,
+		Errors:   localized.Fields,
+		Details:  err.Details,
 	}
 }
 
-// contains 判断字符串是否包含子串
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && containsHelper(s, substr))
+// ProblemType 错误码对应的 type URI，如 TODO_NOT_FOUND -> /problems/todo-not-found，该路径返回错误类型的说明页面
+func ProblemType(code string) string {
+	return "/problems/" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
 }
 
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+// ProblemCode ProblemType 的逆转换，type 的最后一段不是小写字母、数字和连字符组成时返回 false
+func ProblemCode(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return "", false
 		}
 	}
-	return false
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")), true
+}
+
+// writeProblem 写出错误响应，先设置 Content-Type，c.JSON 不会覆盖已有的 Content-Type
+func writeProblem(c *gin.Context, p *Problem) {
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", Language(c))
+	// 追加而不是覆盖，保留 CORS 中间件设置的 Vary: Origin
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	// 429 带 Retry-After（秒），业务层按操作计数的限流（ratelimit.Charge）被拒绝时也一样
+	if retryAfter, ok := p.Details["retry_after"]; ok && p.Code == customerrors.CodeRateLimited {
+		c.Header("Retry-After", fmt.Sprint(retryAfter))
+	}
+	c.JSON(p.Status, p)
 }
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	customerrors "backend/errors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// 校验错误使用 JSON 字段名（title）而不是结构体字段名（Title），与请求体和前端表单一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// BindingError 将 ShouldBindJSON / ShouldBindQuery 的错误转换为带字段信息的 AppError
+func BindingError(err error) *customerrors.AppError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		appErr := customerrors.ErrValidationFailed.WithMessage("invalid input: %d field(s) failed validation", len(validationErrs))
+		for _, fe := range validationErrs {
+			field := fieldPath(fe.Namespace())
+			appErr = appErr.WithField(field, fe.Tag(), fe.Param(), ruleMessage(field, fe.Tag(), fe.Param()))
+		}
+		return appErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		message := fmt.Sprintf("%s must be of type %s", field, typeErr.Type.String())
+		return customerrors.ErrValidationFailed.
+			WithMessage("invalid input: %s", message).
+			WithField(field, "type", typeErr.Type.String(), message)
+	}
+
+	// JSON 格式错误等无法定位到字段的错误
+	return customerrors.ErrInvalidRequest.WithMessage("invalid input: %s", err.Error())
+}
+
+// fieldPath 去掉最外层的结构体名：CreateTodoInput.title -> title，SyncPushInput.changes[0].op -> changes[0].op
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// ruleMessage 生成单条校验规则的提示信息
+func ruleMessage(field, rule, param string) string {
+	switch rule {
+	case "required":
+		return field + " is required"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	case "url":
+		return field + " must be a valid url"
+	default:
+		return fmt.Sprintf("%s failed on the %s rule", field, rule)
+	}
+}
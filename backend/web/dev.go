@@ -0,0 +1,35 @@
+//go:build !embed
+
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Embedded 前端是否内嵌在二进制中
+const Embedded = false
+
+// Handler 把页面请求（包括 Vite 热更新的 WebSocket）转发到 devURL 上的 Vite 开发服务器，devURL 为空时返回 nil
+func Handler(devURL string) (gin.HandlerFunc, error) {
+	if devURL == "" {
+		return nil, nil
+	}
+	target, err := url.Parse(devURL)
+	if err != nil || target.Host == "" {
+		return nil, fmt.Errorf("invalid frontend dev server URL %q", devURL)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		slog.WarnContext(r.Context(), "frontend dev server unavailable", "url", devURL, "error", err)
+		http.Error(w, "frontend dev server is not running at "+devURL+", start it with `npm run dev`", http.StatusBadGateway)
+	}
+	return func(c *gin.Context) {
+		proxy.ServeHTTP(c.Writer, c.Request)
+	}, nil
+}
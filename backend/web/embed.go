@@ -0,0 +1,27 @@
+//go:build embed
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dist 前端的构建产物，由 frontend 的 npm run build 输出到这里
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Embedded 前端是否内嵌在二进制中
+const Embedded = true
+
+// Handler 提供内嵌的前端页面，devURL 只在开发构建中使用
+func Handler(devURL string) (gin.HandlerFunc, error) {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, err
+	}
+	return Static(files)
+}
@@ -0,0 +1,186 @@
+// Package web 提供前端页面：使用 embed 构建标签编译时内嵌前端的构建产物（web/dist），
+// 否则把页面请求转发到 Vite 开发服务器
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// indexFile 单页应用的入口，前端路由的路径都返回它
+const indexFile = "index.html"
+
+// immutableCache 文件名带内容哈希的资源（Vite 输出到 assets/ 下），内容变化时文件名随之变化，可以长期缓存
+const immutableCache = "public, max-age=31536000, immutable"
+
+// minCompressSize 小于这个大小的文件压缩后收益不大，不压缩
+const minCompressSize = 1024
+
+// asset 一个静态文件及其压缩版本
+type asset struct {
+	data        []byte
+	gzip        []byte // 为空表示没有 gzip 版本
+	brotli      []byte // 为空表示没有 brotli 版本，只使用构建时预先压缩的文件
+	etag        string
+	contentType string
+	cacheCtrl   string
+}
+
+// Static 返回提供静态文件的处理函数，文件在启动时全部读入内存
+// 构建时生成的 x.br、x.gz 作为 x 的压缩版本，按 Accept-Encoding 选择；没有 gzip 版本的文本文件在读入时压缩；
+// 不存在且没有扩展名的路径返回 index.html，由前端路由处理；不存在的资源文件返回 404
+func Static(files fs.FS) (gin.HandlerFunc, error) {
+	assets, err := loadAssets(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+c.Request.URL.Path), "/")
+		if name == "" {
+			name = indexFile
+		}
+		a, ok := assets[name]
+		if !ok {
+			if path.Ext(name) != "" {
+				return
+			}
+			if a, ok = assets[indexFile]; !ok {
+				return
+			}
+		}
+		a.serve(c)
+	}, nil
+}
+
+// serve 写出文件，If-None-Match、Range 和 HEAD 由 http.ServeContent 处理
+func (a *asset) serve(c *gin.Context) {
+	data, etag := a.data, a.etag
+	header := c.Writer.Header()
+	if a.gzip != nil || a.brotli != nil {
+		header.Add("Vary", "Accept-Encoding")
+		accept := c.GetHeader("Accept-Encoding")
+		switch {
+		case a.brotli != nil && acceptsEncoding(accept, "br"):
+			data, etag = a.brotli, a.etag+"-br"
+			header.Set("Content-Encoding", "br")
+		case a.gzip != nil && acceptsEncoding(accept, "gzip"):
+			data, etag = a.gzip, a.etag+"-gz"
+			header.Set("Content-Encoding", "gzip")
+		}
+	}
+	header.Set("Content-Type", a.contentType)
+	header.Set("Cache-Control", a.cacheCtrl)
+	header.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(data))
+}
+
+// loadAssets 读入所有文件，把预先压缩的文件关联到原文件上
+func loadAssets(files fs.FS) (map[string]*asset, error) {
+	raw := make(map[string][]byte)
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, name)
+		raw[name] = data
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make(map[string]*asset, len(raw))
+	for name, data := range raw {
+		if isPrecompressed(name, raw) {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		a := &asset{
+			data:        data,
+			gzip:        raw[name+".gz"],
+			brotli:      raw[name+".br"],
+			etag:        hex.EncodeToString(sum[:8]),
+			contentType: contentType(name),
+			cacheCtrl:   "no-cache",
+		}
+		if strings.HasPrefix(name, "assets/") {
+			a.cacheCtrl = immutableCache
+		}
+		if a.gzip == nil && compressible(a.contentType) && len(data) >= minCompressSize {
+			a.gzip = gzipBytes(data)
+		}
+		assets[name] = a
+	}
+	return assets, nil
+}
+
+// isPrecompressed x.br、x.gz 在 x 存在时是它的压缩版本，不单独提供
+func isPrecompressed(name string, raw map[string][]byte) bool {
+	for _, ext := range []string{".br", ".gz"} {
+		if original, ok := strings.CutSuffix(name, ext); ok {
+			if _, exists := raw[original]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contentType 按扩展名确定 Content-Type，未知类型按二进制处理
+func contentType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// compressible 文本类的文件压缩效果好；图片、字体等已经是压缩格式
+func compressible(contentType string) bool {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.HasPrefix(t, "text/") || t == "application/javascript" || t == "application/json" ||
+		t == "image/svg+xml" || t == "application/wasm" || t == "application/manifest+json"
+}
+
+// gzipBytes 以最高压缩率压缩，只在启动时执行一次；压缩后没有变小的返回 nil
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	w.Write(data)
+	w.Close()
+	if buf.Len() >= len(data) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// acceptsEncoding Accept-Encoding 是否接受 encoding，q=0 表示明确拒绝
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) && strings.TrimSpace(name) != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
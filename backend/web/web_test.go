@@ -0,0 +1,102 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestStatic 测试静态文件的缓存、压缩和单页应用的路由回退
+func TestStatic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	script := strings.Repeat("console.log('todo');\n", 100)
+	files := fstest.MapFS{
+		"index.html":              {Data: []byte(`<!doctype html><div id="app"></div>`)},
+		"favicon.ico":             {Data: []byte{0, 0, 1, 0}},
+		"assets/index-a1b2.js":    {Data: []byte(script)},
+		"assets/index-a1b2.js.br": {Data: []byte("brotli")},
+		"assets/style-c3d4.css":   {Data: []byte(strings.Repeat("body{margin:0}", 100))},
+	}
+	handler, err := Static(files)
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	r := gin.New()
+	r.NoRoute(handler)
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("前端路由返回 index.html，不存在的资源文件返回 404", func(t *testing.T) {
+		for _, path := range []string{"/", "/todos/42", "/settings/"} {
+			w := get(path)
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `id="app"`) {
+				t.Errorf("%s 应该返回 index.html，实际: %d %s", path, w.Code, w.Body.String())
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+				t.Errorf("index.html 每次都应该重新验证，实际: %s", cc)
+			}
+		}
+		if w := get("/assets/missing.js"); w.Code != http.StatusNotFound {
+			t.Errorf("不存在的资源文件应该返回 404，实际: %d", w.Code)
+		}
+		if w := get("/assets/index-a1b2.js.br"); w.Code != http.StatusNotFound {
+			t.Errorf("压缩版本不应该单独提供，实际: %d", w.Code)
+		}
+		t.Logf("✅ 前端路由回退到 index.html")
+	})
+
+	t.Run("带哈希的资源长期缓存，按 Accept-Encoding 选择压缩版本", func(t *testing.T) {
+		w := get("/assets/index-a1b2.js", "Accept-Encoding", "gzip, br")
+		if w.Header().Get("Content-Encoding") != "br" || w.Body.String() != "brotli" {
+			t.Errorf("应该返回预先压缩的 brotli 版本，实际: %q", w.Header().Get("Content-Encoding"))
+		}
+		if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+			t.Errorf("带哈希的资源应该长期缓存，实际: %s", cc)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("应该带 Vary: Accept-Encoding")
+		}
+
+		// 没有预先压缩的 gzip 版本时，读入时压缩
+		w = get("/assets/index-a1b2.js", "Accept-Encoding", "gzip, br;q=0")
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("应该返回 gzip 版本，实际: %q", w.Header().Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip 格式错误: %v", err)
+		}
+		if body, _ := io.ReadAll(zr); string(body) != script {
+			t.Errorf("解压后的内容与原文件不同")
+		}
+
+		if w := get("/assets/style-c3d4.css"); w.Header().Get("Content-Encoding") != "" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+			t.Errorf("不接受压缩时应该返回原文件，实际: %q %q", w.Header().Get("Content-Encoding"), w.Header().Get("Content-Type"))
+		}
+		t.Logf("✅ %s", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("ETag 未变化时返回 304", func(t *testing.T) {
+		etag := get("/assets/index-a1b2.js", "Accept-Encoding", "br").Header().Get("ETag")
+		if w := get("/assets/index-a1b2.js", "Accept-Encoding", "br", "If-None-Match", etag); w.Code != http.StatusNotModified {
+			t.Errorf("应该返回 304，实际: %d", w.Code)
+		}
+		if w := get("/assets/index-a1b2.js", "If-None-Match", etag); w.Code != http.StatusOK {
+			t.Errorf("不同编码的 ETag 不同，应该返回 200，实际: %d", w.Code)
+		}
+		t.Logf("✅ ETag: %s", etag)
+	})
+}
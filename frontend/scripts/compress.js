@@ -0,0 +1,38 @@
+// 为构建产物中的文本文件生成 .br 和 .gz 压缩版本，后端按 Accept-Encoding 直接返回，不在请求时压缩
+// 只使用 Node 自带的 zlib，不增加依赖
+import { readdirSync, readFileSync, statSync, writeFileSync } from 'node:fs'
+import { join } from 'node:path'
+import { brotliCompressSync, constants, gzipSync } from 'node:zlib'
+
+const outDir = new URL('../../backend/web/dist', import.meta.url).pathname
+const compressible = /\.(html|js|mjs|css|json|svg|txt|xml|wasm|webmanifest)$/
+const minSize = 1024
+
+function walk(dir) {
+  for (const name of readdirSync(dir)) {
+    const file = join(dir, name)
+    if (statSync(file).isDirectory()) {
+      walk(file)
+    } else if (compressible.test(name)) {
+      compress(file)
+    }
+  }
+}
+
+function compress(file) {
+  const data = readFileSync(file)
+  if (data.length < minSize) {
+    return
+  }
+  const br = brotliCompressSync(data, { params: { [constants.BROTLI_PARAM_QUALITY]: constants.BROTLI_MAX_QUALITY } })
+  const gz = gzipSync(data, { level: 9 })
+  // 压缩后没有变小的不保存
+  if (br.length < data.length) {
+    writeFileSync(file + '.br', br)
+  }
+  if (gz.length < data.length) {
+    writeFileSync(file + '.gz', gz)
+  }
+}
+
+walk(outDir)
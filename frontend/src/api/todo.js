@@ -85,3 +85,53 @@ export function deleteTodo(id) {
   })
 }
 
+
+/**
+ * 拉取增量变更（离线同步）
+ * @param {string} since - 上次返回的 sync_token，为空时返回全量快照
+ * @param {number} limit - 每次最多返回的条数
+ * @returns {Promise} data: { changes, sync_token, has_more, full }
+ */
+export function pullChanges(since = '', limit) {
+  return request({
+    url: '/sync',
+    method: 'get',
+    params: { since, limit },
+  })
+}
+
+/**
+ * 上传离线期间的修改
+ * @param {Array} changes - 修改列表，每项 { client_id, op: create/update/status/delete, id, base_version, ...字段 }
+ * @returns {Promise} data: { results, sync_token }，results 与 changes 一一对应
+ */
+export function pushChanges(changes) {
+  return request({
+    url: '/sync',
+    method: 'post',
+    data: { changes },
+  })
+}
+
+/**
+ * 订阅待办事项实时变更（Server-Sent Events）
+ * 断线后浏览器会自动重连并带上 Last-Event-ID，服务端补发期间的事件
+ * @param {Object} handlers - 回调
+ * @param {Function} handlers.onEvent - 收到变更 (type, event)
+ * @param {Function} handlers.onReset - 服务端无法补发时触发，需要全量刷新
+ * @param {Function} handlers.onOpen - 连接建立
+ * @param {Function} handlers.onError - 连接出错（浏览器会自动重连）
+ * @returns {EventSource} 调用 close() 取消订阅
+ */
+export function subscribeTodoEvents({ onEvent, onReset, onOpen, onError }) {
+  const source = new EventSource('/api/events')
+
+  for (const type of ['todo.created', 'todo.updated', 'todo.deleted']) {
+    source.addEventListener(type, (e) => onEvent && onEvent(type, JSON.parse(e.data)))
+  }
+  source.addEventListener('reset', () => onReset && onReset())
+  source.onopen = () => onOpen && onOpen()
+  source.onerror = () => onError && onError()
+
+  return source
+}
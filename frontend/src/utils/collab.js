@@ -0,0 +1,89 @@
+import { reactive } from 'vue'
+
+// 协作通道（WebSocket）：共享同一个连接，提供编辑状态（presence）
+// 数据变更仍然通过 SSE 推送，这里只负责"谁在编辑什么"
+// 编辑者的名字由服务端按登录身份确定，未登录时显示为 anonymous-连接 ID
+
+// todoId -> 编辑者列表 [{ client_id, user, since }]
+export const presence = reactive({})
+
+let socket = null
+let clientId = null
+let heartbeatTimer = null
+let reconnectTimer = null
+const editing = new Set() // 本连接正在编辑的待办，重连后重新声明
+
+const send = (message) => {
+  if (socket && socket.readyState === WebSocket.OPEN) {
+    socket.send(JSON.stringify(message))
+  }
+}
+
+const handleMessage = (message) => {
+  switch (message.type) {
+    case 'welcome':
+      clientId = message.client_id
+      send({ type: 'subscribe', topic: 'todos' })
+      editing.forEach((id) => send({ type: 'editing', todo_id: id }))
+      break
+    case 'presence':
+      if (message.editors && message.editors.length > 0) {
+        presence[message.todo_id] = message.editors
+      } else {
+        delete presence[message.todo_id]
+      }
+      break
+    case 'event':
+      if (message.event.type === 'todo.deleted') {
+        delete presence[message.todo_id]
+      }
+      break
+  }
+}
+
+// 建立连接，断开后 3 秒重连
+export const connectCollab = () => {
+  if (socket) return
+
+  const protocol = location.protocol === 'https:' ? 'wss' : 'ws'
+  socket = new WebSocket(`${protocol}://${location.host}/api/ws`)
+
+  socket.onmessage = (e) => handleMessage(JSON.parse(e.data))
+  socket.onopen = () => {
+    // 应用层心跳，服务端 60 秒收不到消息会断开
+    heartbeatTimer = setInterval(() => send({ type: 'ping' }), 30000)
+  }
+  socket.onclose = () => {
+    clearInterval(heartbeatTimer)
+    socket = null
+    clientId = null
+    Object.keys(presence).forEach((id) => delete presence[id])
+    reconnectTimer = setTimeout(connectCollab, 3000)
+  }
+}
+
+// 断开连接，不再重连
+export const disconnectCollab = () => {
+  clearTimeout(reconnectTimer)
+  if (socket) {
+    socket.onclose = null
+    socket.close()
+    socket = null
+  }
+  clearInterval(heartbeatTimer)
+}
+
+// 声明开始 / 结束编辑某个待办
+export const startEditing = (todoId) => {
+  editing.add(todoId)
+  send({ type: 'editing', todo_id: todoId })
+}
+
+export const stopEditing = (todoId) => {
+  editing.delete(todoId)
+  send({ type: 'idle', todo_id: todoId })
+}
+
+// 除自己以外正在编辑某个待办的用户名
+export const otherEditors = (todoId) =>
+  (presence[todoId] || []).filter((e) => e.client_id !== clientId).map((e) => e.user)
@@ -8,11 +8,16 @@ const request = axios.create({
   headers: {
     'Content-Type': 'application/json',
   },
+  // 使用会话 Cookie 登录时，修改请求需要带上 CSRF 令牌：后端放在 todo_csrf Cookie 中，axios 对同源请求自动加到请求头
+  xsrfCookieName: 'todo_csrf',
+  xsrfHeaderName: 'X-CSRF-Token',
 })
 
 // 请求拦截器
 request.interceptors.request.use(
   (config) => {
+    // 错误提示由后端本地化，这里声明界面使用的语言
+    config.headers['Accept-Language'] = 'zh-CN'
     // 可以在这里添加 token 等认证信息
     // const token = localStorage.getItem('token')
     // if (token) {
@@ -26,28 +31,20 @@ request.interceptors.request.use(
   }
 )
 
-// 将英文错误信息转换为中文
-const translateErrorMessage = (message) => {
-  const errorMap = {
-    'title is required and cannot be empty': '标题不能为空',
-    'title cannot exceed 255 characters': '标题长度不能超过 255 个字符',
-    'invalid category': '分类无效',
-    'priority must be between 0 and 5': '优先级必须在 0 到 5 之间',
-    'invalid id': 'ID 无效',
-    'todo not found': '待办事项不存在',
-    'version conflict': '数据已被其他设备修改',
-    'Invalid input': '输入内容有误',
-    'required': '必填项未填写',
-  }
+// 错误响应为 RFC 7807 格式（application/problem+json），detail 已由后端按 Accept-Language 本地化
+const errorMessage = (data, fallback) => {
+  return data?.detail || fallback
+}
 
-  // 查找匹配的错误信息
-  for (const [en, zh] of Object.entries(errorMap)) {
-    if (message.toLowerCase().includes(en.toLowerCase())) {
-      return zh
+// fieldErrorsOf 取出校验失败的字段，返回 { 字段名: 后端返回的提示信息 }，用于在表单中标出对应的表单项
+export const fieldErrorsOf = (error) => {
+  const result = {}
+  for (const item of error?.response?.data?.errors || []) {
+    if (!result[item.field]) {
+      result[item.field] = item.message
     }
   }
-
-  return message // 如果没有匹配，返回原始信息
+  return result
 }
 
 // 响应拦截器
@@ -60,8 +57,8 @@ request.interceptors.response.use(
     if (res.code === 0) {
       return res
     } else {
-      // 业务错误 - 转换为中文提示
-      const errorMsg = translateErrorMessage(res.message || '请求失败')
+      // 业务错误 - 显示后端返回的提示
+      const errorMsg = errorMessage(res, '请求失败')
       ElMessage.error(errorMsg)
       return Promise.reject(new Error(errorMsg))
     }
@@ -75,19 +72,23 @@ request.interceptors.response.use(
 
       switch (status) {
         case 400:
-          ElMessage.error(translateErrorMessage(data.message) || '请求参数错误')
+          ElMessage.error(errorMessage(data, '请求参数错误'))
           break
         case 404:
-          ElMessage.error(translateErrorMessage(data.message) || '请求的资源不存在')
+          ElMessage.error(errorMessage(data, '请求的资源不存在'))
           break
         case 409:
           // 版本冲突（乐观锁）- 不在这里提示，交给业务层处理
-          return Promise.reject(error.response)
+          if (data?.error_code === 'VERSION_CONFLICT') {
+            return Promise.reject(error.response)
+          }
+          ElMessage.error(errorMessage(data, '请求冲突'))
+          break
         case 500:
           ElMessage.error('服务器内部错误')
           break
         default:
-          ElMessage.error(translateErrorMessage(data.message) || `请求失败 (${status})`)
+          ElMessage.error(errorMessage(data, `请求失败 (${status})`))
       }
     } else if (error.request) {
       // 请求已发出但没有收到响应
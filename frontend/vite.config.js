@@ -4,6 +4,11 @@ import vue from '@vitejs/plugin-vue'
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [vue()],
+  build: {
+    // 输出到后端的 web/dist，使用 embed 构建标签编译后端时内嵌到二进制中
+    outDir: '../backend/web/dist',
+    emptyOutDir: true
+  },
   server: {
     port: 5173,
     // 配置代理解决开发环境跨域问题
@@ -11,6 +16,7 @@ export default defineConfig({
       '/api': {
         target: 'http://localhost:8080', // 后端服务地址
         changeOrigin: true,
+        ws: true, // 代理协作通道的 WebSocket 连接
         // rewrite: (path) => path.replace(/^\/api/, '') // 如果后端没有 /api 前缀，取消注释
       }
     }